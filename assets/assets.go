@@ -0,0 +1,8 @@
+package assets
+
+import _ "embed"
+
+// PluginsList is the catalog of godspeed plugins compiled into the binary
+//
+//go:embed plugins_list.json
+var PluginsList []byte
@@ -3,15 +3,25 @@ package main
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/asyncapi"
 	"github.com/godspeedsystems/godspeed-cli/internal/create"
 	"github.com/godspeedsystems/godspeed-cli/internal/devops"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/export"
+	"github.com/godspeedsystems/godspeed-cli/internal/generate"
+	"github.com/godspeedsystems/godspeed-cli/internal/graph"
 	"github.com/godspeedsystems/godspeed-cli/internal/graphql"
+	"github.com/godspeedsystems/godspeed-cli/internal/mock"
+	"github.com/godspeedsystems/godspeed-cli/internal/openapi"
 	"github.com/godspeedsystems/godspeed-cli/internal/otel"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
 	"github.com/godspeedsystems/godspeed-cli/internal/plugin"
 	"github.com/godspeedsystems/godspeed-cli/internal/prisma"
+	"github.com/godspeedsystems/godspeed-cli/internal/prompt"
+	"github.com/godspeedsystems/godspeed-cli/internal/typegen"
 	"github.com/godspeedsystems/godspeed-cli/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -19,12 +29,39 @@ import (
 var version = "1.0.0" // This would be set during build
 
 func main() {
-	printBanner()
+	// Completion requests must only print completions, and JSON output only JSON
+	jsonRequested := output.Requested(os.Args[1:])
+	if !jsonRequested && (len(os.Args) < 2 || (os.Args[1] != cobra.ShellCompRequestCmd && os.Args[1] != cobra.ShellCompNoDescRequestCmd)) {
+		printBanner()
+	}
 
+	// Errors returned before PersistentPreRunE runs are usage errors, e.g.
+	// unknown commands or flags and wrong argument counts
+	validated := false
 	rootCmd := &cobra.Command{
-		Use:     "godspeed",
-		Short:   "Godspeed CLI tool for the Godspeed Framework",
-		Version: version,
+		Use:           "godspeed",
+		Short:         "Godspeed CLI tool for the Godspeed Framework",
+		Version:       version,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			validated = true
+			yes, _ := cmd.Flags().GetBool("yes")
+			nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+			prompt.Init(yes || nonInteractive)
+			return output.Start(outputFormat(cmd), cmd.CommandPath())
+		},
+	}
+	rootCmd.PersistentFlags().String("output", output.FormatText, "Output format: text or json")
+	rootCmd.PersistentFlags().Bool("json", false, "Print the result as JSON, the same as --output json")
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "Never prompt: use defaults or fail naming the missing flag. Without it, CI=true or a stdin that is not a terminal also stops prompts, but those a flag answers need the flag")
+	rootCmd.PersistentFlags().Bool("non-interactive", false, "The same as --yes")
+	if jsonRequested {
+		rootCmd.SetVersionTemplate(output.Marshal(output.Envelope{
+			Command: "godspeed",
+			OK:      true,
+			Result:  map[string]string{"version": version},
+		}) + "\n")
 	}
 
 	// Add create command
@@ -32,24 +69,27 @@ func main() {
 		Use:   "create [projectName]",
 		Short: "Create a new godspeed project",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			fromTemplate, _ := cmd.Flags().GetString("from-template")
 			fromExample, _ := cmd.Flags().GetString("from-example")
-			create.Execute(args[0], fromTemplate, fromExample, version)
+			overwrite, _ := cmd.Flags().GetBool("overwrite")
+			return create.Execute(args[0], fromTemplate, fromExample, version, overwrite)
 		},
 	}
 	createCmd.Flags().String("from-template", "", "Create a project from a template")
 	createCmd.Flags().String("from-example", "", "Create a project from examples")
+	createCmd.Flags().Bool("overwrite", false, "Replace the project directory if it already exists")
 	rootCmd.AddCommand(createCmd)
 
 	// Add dev command
 	devCmd := &cobra.Command{
 		Use:   "dev",
 		Short: "Run godspeed development server",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				utils.ExecuteCommand("npm", []string{"run", "dev"})
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.RequireProject(); err != nil {
+				return err
 			}
+			return utils.ExecuteCommand("npm", []string{"run", "dev"})
 		},
 	}
 	rootCmd.AddCommand(devCmd)
@@ -58,10 +98,11 @@ func main() {
 	cleanCmd := &cobra.Command{
 		Use:   "clean",
 		Short: "Clean the previous build",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				utils.ExecuteCommand("npm", []string{"run", "clean"})
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.RequireProject(); err != nil {
+				return err
 			}
+			return utils.ExecuteCommand("npm", []string{"run", "clean"})
 		},
 	}
 	rootCmd.AddCommand(cleanCmd)
@@ -70,10 +111,11 @@ func main() {
 	linkCmd := &cobra.Command{
 		Use:   "link",
 		Short: "Link a local Godspeed project to the global environment for development in godspeed-daemon",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				utils.UpdateServicesJson(true)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.RequireProject(); err != nil {
+				return err
 			}
+			return utils.UpdateServicesJson(true)
 		},
 	}
 	rootCmd.AddCommand(linkCmd)
@@ -82,10 +124,11 @@ func main() {
 	unlinkCmd := &cobra.Command{
 		Use:   "unlink",
 		Short: "Unlink a local Godspeed project from the global environment",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				utils.UpdateServicesJson(false)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.RequireProject(); err != nil {
+				return err
 			}
+			return utils.UpdateServicesJson(false)
 		},
 	}
 	rootCmd.AddCommand(unlinkCmd)
@@ -94,10 +137,11 @@ func main() {
 	genCrudApiCmd := &cobra.Command{
 		Use:   "gen-crud-api",
 		Short: "Scans your prisma datasources and generate CRUD APIs events and workflows",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				utils.ExecuteCommand("npm", []string{"run", "gen-crud-api"})
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.RequireProject(); err != nil {
+				return err
 			}
+			return utils.ExecuteCommand("npm", []string{"run", "gen-crud-api"})
 		},
 	}
 	rootCmd.AddCommand(genCrudApiCmd)
@@ -106,22 +150,198 @@ func main() {
 	genGraphqlSchemaCmd := &cobra.Command{
 		Use:   "gen-graphql-schema",
 		Short: "Scans your graphql events and generate graphql schema",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				graphql.GenerateSchema()
-			}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			eventSources, _ := cmd.Flags().GetStringSlice("eventsource")
+			return graphql.GenerateSchema(eventSources)
 		},
 	}
+	genGraphqlSchemaCmd.Flags().StringSlice("eventsource", nil, "GraphQL eventsources to generate the schema for (default asks)")
 	rootCmd.AddCommand(genGraphqlSchemaCmd)
 
+	// Add gen-asyncapi command
+	genAsyncapiCmd := &cobra.Command{
+		Use:   "gen-asyncapi",
+		Short: "Scans your kafka and other message broker events and generate an AsyncAPI document",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			specVersion, _ := cmd.Flags().GetString("spec-version")
+			out, _ := cmd.Flags().GetString("out")
+			eventSource, _ := cmd.Flags().GetString("eventsource")
+			return asyncapi.Generate(specVersion, out, eventSource)
+		},
+	}
+	genAsyncapiCmd.Flags().String("spec-version", asyncapi.Version26, "AsyncAPI version to generate: 2.6 or 3.0")
+	genAsyncapiCmd.Flags().StringP("out", "o", asyncapi.DefaultOutputPath, "Output file, written as JSON if it ends in .json")
+	genAsyncapiCmd.Flags().String("eventsource", "", "Only include events of this eventsource")
+	rootCmd.AddCommand(genAsyncapiCmd)
+
+	// Add gen-types command
+	genTypesCmd := &cobra.Command{
+		Use:   "gen-types",
+		Short: "Generate TypeScript types for your events and definitions in src/types/generated.ts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			check, _ := cmd.Flags().GetBool("check")
+			return typegen.Generate(check)
+		},
+	}
+	genTypesCmd.Flags().Bool("check", false, "Exit with a non-zero code if the generated types are out of date")
+	rootCmd.AddCommand(genTypesCmd)
+
+	// Add export command
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export event definitions for use in other tools",
+	}
+
+	exportPostmanCmd := &cobra.Command{
+		Use:   "postman",
+		Short: "Export http events as a Postman (or Insomnia) collection",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			out, _ := cmd.Flags().GetString("out")
+			eventSource, _ := cmd.Flags().GetString("eventsource")
+			return export.Collection(format, out, eventSource)
+		},
+	}
+	exportPostmanCmd.Flags().String("format", export.FormatPostman, "Collection format: postman or insomnia")
+	exportPostmanCmd.Flags().StringP("out", "o", "", "Output file (default <projectName>.<format>_collection.json)")
+	exportPostmanCmd.Flags().String("eventsource", "", "Only export events of this eventsource")
+	exportCmd.AddCommand(exportPostmanCmd)
+	rootCmd.AddCommand(exportCmd)
+
+	// Add mock command
+	mockCmd := &cobra.Command{
+		Use:   "mock",
+		Short: "Start a mock server for your http events, with example responses generated from their schemas",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			port, _ := cmd.Flags().GetInt("port")
+			overrides, _ := cmd.Flags().GetString("overrides")
+			return mock.Serve(port, overrides)
+		},
+	}
+	mockCmd.Flags().IntP("port", "p", 0, "Port to listen on (default servicePort from .godspeed)")
+	mockCmd.Flags().String("overrides", mock.DefaultOverridesDir, "Directory with canned responses, one <EventTypeName>.yaml file per event")
+	rootCmd.AddCommand(mockCmd)
+
+	// Add generate command
+	generateCmd := &cobra.Command{
+		Use:     "generate",
+		Aliases: []string{"g"},
+		Short:   "Generate events, workflows, functions and definitions",
+	}
+
+	generateEventCmd := &cobra.Command{
+		Use:   "event",
+		Short: "Add an event to src/events, generating its function if it does not exist",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts generate.EventOptions
+			opts.EventSource, _ = cmd.Flags().GetString("eventsource")
+			opts.Method, _ = cmd.Flags().GetString("method")
+			opts.Path, _ = cmd.Flags().GetString("path")
+			opts.Topic, _ = cmd.Flags().GetString("topic")
+			opts.Group, _ = cmd.Flags().GetString("group")
+			opts.Fn, _ = cmd.Flags().GetString("fn")
+			opts.Summary, _ = cmd.Flags().GetString("summary")
+			opts.Language, _ = cmd.Flags().GetString("lang")
+			opts.File, _ = cmd.Flags().GetString("file")
+			return generate.Event(opts)
+		},
+	}
+	generateEventCmd.Flags().String("eventsource", "", "Eventsource that triggers the event, e.g. http")
+	generateEventCmd.Flags().String("method", "", "HTTP method of the event")
+	generateEventCmd.Flags().String("path", "", "HTTP path of the event, e.g. /users/:id")
+	generateEventCmd.Flags().String("topic", "", "Topic of a message broker event")
+	generateEventCmd.Flags().String("group", "", "Consumer group of a message broker event")
+	generateEventCmd.Flags().String("fn", "", "Function called by the event")
+	generateEventCmd.Flags().String("summary", "", "Summary of the event")
+	generateEventCmd.Flags().String("lang", "", "Language of a newly generated function: ts or yaml")
+	generateEventCmd.Flags().String("file", "", "Events file relative to src/events (default <first path segment>.yaml)")
+	generateCmd.AddCommand(generateEventCmd)
+
+	generateWorkflowCmd := &cobra.Command{
+		Use:   "workflow [name]",
+		Short: "Generate a YAML workflow in src/functions",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			summary, _ := cmd.Flags().GetString("summary")
+			return generate.Workflow(firstArg(args), summary)
+		},
+	}
+	generateWorkflowCmd.Flags().String("summary", "", "Summary of the workflow")
+	generateCmd.AddCommand(generateWorkflowCmd)
+
+	generateFunctionCmd := &cobra.Command{
+		Use:   "function [name]",
+		Short: "Generate a TypeScript function in src/functions",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			summary, _ := cmd.Flags().GetString("summary")
+			return generate.Function(firstArg(args), summary)
+		},
+	}
+	generateFunctionCmd.Flags().String("summary", "", "Summary of the function")
+	generateCmd.AddCommand(generateFunctionCmd)
+
+	generateDefinitionCmd := &cobra.Command{
+		Use:   "definition [name]",
+		Short: "Generate a schema definition in src/definitions",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			typ, _ := cmd.Flags().GetString("type")
+			return generate.Definition(firstArg(args), typ)
+		},
+	}
+	generateDefinitionCmd.Flags().String("type", "object", "JSON schema type of the definition")
+	generateCmd.AddCommand(generateDefinitionCmd)
+	rootCmd.AddCommand(generateCmd)
+
+	// Add graph command
+	graphCmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export the dependency graph of eventsources, events, functions and datasources",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			out, _ := cmd.Flags().GetString("out")
+			eventSource, _ := cmd.Flags().GetString("eventsource")
+			datasource, _ := cmd.Flags().GetString("datasource")
+			return graph.Export(format, out, eventSource, datasource)
+		},
+	}
+	graphCmd.Flags().String("format", graph.FormatMermaid, "Output format: mermaid, dot or json")
+	graphCmd.Flags().StringP("out", "o", "", "Output file (default stdout)")
+	graphCmd.Flags().String("eventsource", "", "Only show what is reachable from this eventsource")
+	graphCmd.Flags().String("datasource", "", "Only show what leads to this datasource")
+	rootCmd.AddCommand(graphCmd)
+
+	// Add import command
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Scaffold a project from existing API definitions",
+	}
+
+	importOpenAPICmd := &cobra.Command{
+		Use:   "openapi <file>",
+		Short: "Generate events, definitions and stub workflows from an OpenAPI spec",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			force, _ := cmd.Flags().GetBool("force")
+			eventSource, _ := cmd.Flags().GetString("eventsource")
+			return openapi.Import(args[0], eventSource, force)
+		},
+	}
+	importOpenAPICmd.Flags().Bool("force", false, "Overwrite existing files")
+	importOpenAPICmd.Flags().String("eventsource", "http", "Eventsource the imported events are bound to")
+	importCmd.AddCommand(importOpenAPICmd)
+	rootCmd.AddCommand(importCmd)
+
 	// Add build command
 	buildCmd := &cobra.Command{
 		Use:   "build",
 		Short: "Build the godspeed project. Create a production build",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				utils.ExecuteCommand("npm", []string{"run", "build"})
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.RequireProject(); err != nil {
+				return err
 			}
+			return utils.ExecuteCommand("npm", []string{"run", "build"})
 		},
 	}
 	rootCmd.AddCommand(buildCmd)
@@ -130,10 +350,11 @@ func main() {
 	previewCmd := &cobra.Command{
 		Use:   "preview",
 		Short: "Preview the production build",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				utils.ExecuteCommand("npm", []string{"run", "preview"})
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.RequireProject(); err != nil {
+				return err
 			}
+			return utils.ExecuteCommand("npm", []string{"run", "preview"})
 		},
 	}
 	rootCmd.AddCommand(previewCmd)
@@ -142,10 +363,11 @@ func main() {
 	serveCmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Build and preview the production build in watch mode",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				utils.ExecuteCommand("npm", []string{"run", "serve"})
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.RequireProject(); err != nil {
+				return err
 			}
+			return utils.ExecuteCommand("npm", []string{"run", "serve"})
 		},
 	}
 	rootCmd.AddCommand(serveCmd)
@@ -158,10 +380,8 @@ func main() {
 	prepareCmd := &cobra.Command{
 		Use:   "prepare",
 		Short: "Prepare your prisma database for use",
-		Run: func(cmd *cobra.Command, args []string) {
-			if utils.IsGodspeedProject() {
-				prisma.Prepare()
-			}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return prisma.Prepare()
 		},
 	}
 	prismaCmd.AddCommand(prepareCmd)
@@ -174,40 +394,170 @@ func main() {
 	}
 
 	pluginAddCmd := &cobra.Command{
-		Use:   "add [pluginName]",
+		Use:   "add [pluginName[@version]]",
 		Short: "Add an eventsource/datasource plugin",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var pluginName string
 			if len(args) > 0 {
 				pluginName = args[0]
 			}
-			plugin.Add(pluginName)
+			instance, _ := cmd.Flags().GetString("as")
+			force, _ := cmd.Flags().GetBool("force")
+			if instance != "" {
+				if pluginName == "" {
+					return exitcode.New(exitcode.Usage, "please provide the plugin to create the instance %s of", instance)
+				}
+				return plugin.AddInstance(pluginName, instance, force)
+			}
+			return plugin.Add(pluginName, force)
 		},
 	}
+	pluginAddCmd.Flags().String("as", "", "Create an additional named instance of the plugin, e.g. a second datasource")
+	pluginAddCmd.Flags().Bool("force", false, "Install even if the plugin is incompatible with the project's framework version")
 
 	pluginRemoveCmd := &cobra.Command{
 		Use:   "remove [pluginName]",
 		Short: "Remove an eventsource/datasource plugin",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var pluginName string
 			if len(args) > 0 {
 				pluginName = args[0]
 			}
-			plugin.Remove(pluginName)
+			instance, _ := cmd.Flags().GetString("as")
+			return plugin.Remove(pluginName, instance)
 		},
 	}
+	pluginRemoveCmd.Flags().String("as", "", "Only remove this named instance of the plugin")
 
 	pluginUpdateCmd := &cobra.Command{
-		Use:   "update",
-		Short: "Update an eventsource/datasource plugin",
-		Run: func(cmd *cobra.Command, args []string) {
-			plugin.Update()
+		Use:   "update [pluginName...]",
+		Short: "Update eventsource/datasource plugins to their latest compatible versions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			force, _ := cmd.Flags().GetBool("force")
+			return plugin.Update(args, all, force)
+		},
+	}
+	pluginUpdateCmd.Flags().Bool("all", false, "Update every installed plugin")
+	pluginUpdateCmd.Flags().Bool("force", false, "Update even if the new version is incompatible with the project's framework version")
+
+	pluginSyncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Install the plugin versions and files recorded in " + plugin.LockFileName,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return plugin.Sync()
+		},
+	}
+
+	pluginRestoreCmd := &cobra.Command{
+		Use:   "restore [entry]",
+		Short: "Restore the files of a removed plugin from the trash",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return plugin.Restore(firstArg(args))
 		},
 	}
 
-	pluginCmd.AddCommand(pluginAddCmd, pluginRemoveCmd, pluginUpdateCmd)
+	pluginInfoCmd := &cobra.Command{
+		Use:   "info <pluginName>",
+		Short: "Show the metadata of an installed plugin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return plugin.Info(args[0])
+		},
+	}
+
+	pluginListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the installed plugins with their versions, types and files",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return plugin.List()
+		},
+	}
+
+	pluginOutdatedCmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "Show the installed plugins that have newer versions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return plugin.Outdated()
+		},
+	}
+
+	pluginNewCmd := &cobra.Command{
+		Use:   "new <name>",
+		Short: "Scaffold a new eventsource/datasource plugin package",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sourceType, _ := cmd.Flags().GetString("type")
+			loader, _ := cmd.Flags().GetString("loader")
+			dir, _ := cmd.Flags().GetString("dir")
+			return plugin.New(args[0], sourceType, loader, dir)
+		},
+	}
+	pluginNewCmd.Flags().String("type", plugin.ModuleTypeDS, "Plugin type: ES, DS or BOTH")
+	pluginNewCmd.Flags().String("loader", "", "Loader type and config file name (default derived from the name)")
+	pluginNewCmd.Flags().String("dir", "", "Directory to create the package in (default the package name)")
+
+	pluginLinkCmd := &cobra.Command{
+		Use:   "link <path>",
+		Short: "Install a local plugin package into the project without publishing it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return plugin.Link(args[0])
+		},
+	}
+
+	pluginSearchCmd := &cobra.Command{
+		Use:   "search [query]",
+		Short: "Search the plugin catalog by name, description and tags",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sourceType, _ := cmd.Flags().GetString("type")
+			return plugin.Search(strings.Join(args, " "), sourceType)
+		},
+	}
+	pluginSearchCmd.Flags().String("type", "", "Only show plugins of this type: es, ds or both")
+
+	pluginDoctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that installed plugins and their loader and config files agree",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fix, _ := cmd.Flags().GetBool("fix")
+			return plugin.Doctor(fix)
+		},
+	}
+	pluginDoctorCmd.Flags().Bool("fix", false, "Regenerate missing files and move files of uninstalled plugins to the trash")
+
+	pluginCmd.AddCommand(pluginAddCmd, pluginRemoveCmd, pluginUpdateCmd, pluginSyncCmd, pluginRestoreCmd, pluginInfoCmd, pluginListCmd, pluginOutdatedCmd, pluginNewCmd, pluginLinkCmd, pluginDoctorCmd, pluginSearchCmd)
 	rootCmd.AddCommand(pluginCmd)
 
+	// Add datasource command
+	datasourceCmd := &cobra.Command{
+		Use:   "datasource",
+		Short: "Manage the named datasources of the project",
+	}
+
+	datasourceAddCmd := &cobra.Command{
+		Use:   "add [name]",
+		Short: "Add a named datasource using an installed datasource plugin",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pluginName, _ := cmd.Flags().GetString("plugin")
+			return plugin.AddDatasource(firstArg(args), pluginName)
+		},
+	}
+	datasourceAddCmd.Flags().String("plugin", "", "Datasource plugin of the new datasource")
+
+	datasourceListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the datasources of the project by plugin",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return plugin.ListDatasources()
+		},
+	}
+
+	datasourceCmd.AddCommand(datasourceAddCmd, datasourceListCmd)
+	rootCmd.AddCommand(datasourceCmd)
+
 	// Add devops-plugin command
 	devopsPluginCmd := &cobra.Command{
 		Use:   "devops-plugin",
@@ -215,68 +565,75 @@ func main() {
 	}
 
 	devopsPluginInstallCmd := &cobra.Command{
-		Use:   "install",
+		Use:   "install [pluginName[@version]]",
 		Short: "Install a godspeed devops plugin",
-		Run: func(cmd *cobra.Command, args []string) {
-			devops.Install("")
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, _ := cmd.Flags().GetBool("project")
+			return devops.Install(firstArg(args), project)
 		},
 	}
+	devopsPluginInstallCmd.Flags().Bool("project", false, "Install into the project's devops plugins directory instead of the global one")
 
 	devopsPluginRemoveCmd := &cobra.Command{
-		Use:   "remove",
+		Use:   "remove [pluginName]",
 		Short: "Remove a godspeed devops plugin",
-		Run: func(cmd *cobra.Command, args []string) {
-			devops.Remove("")
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, _ := cmd.Flags().GetBool("project")
+			return devops.Remove(firstArg(args), project)
 		},
 	}
+	devopsPluginRemoveCmd.Flags().Bool("project", false, "Remove from the project's devops plugins directory")
 
 	devopsPluginListCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List available godspeed devops plugins",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			installed, _ := cmd.Flags().GetBool("installed")
-			devops.List(installed)
+			return devops.List(installed)
 		},
 	}
 	devopsPluginListCmd.Flags().Bool("installed", false, "List installed plugins only")
 
 	devopsPluginUpdateCmd := &cobra.Command{
-		Use:   "update",
-		Short: "Update a godspeed devops plugin",
-		Run: func(cmd *cobra.Command, args []string) {
-			devops.Update()
+		Use:   "update [pluginName...]",
+		Short: "Update godspeed devops plugins to their latest versions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			project, _ := cmd.Flags().GetBool("project")
+			return devops.Update(args, all, project)
 		},
 	}
+	devopsPluginUpdateCmd.Flags().Bool("all", false, "Update every installed devops plugin")
+	devopsPluginUpdateCmd.Flags().Bool("project", false, "Update the plugins of the project's devops plugins directory")
 
-	devopsPluginCmd.AddCommand(devopsPluginInstallCmd, devopsPluginRemoveCmd, devopsPluginListCmd, devopsPluginUpdateCmd)
+	devopsPluginInfoCmd := &cobra.Command{
+		Use:   "info <pluginName>",
+		Short: "Show the version, description, path and last update of a devops plugin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return devops.Info(args[0])
+		},
+	}
+
+	devopsPluginCmd.AddCommand(devopsPluginInstallCmd, devopsPluginRemoveCmd, devopsPluginListCmd, devopsPluginUpdateCmd, devopsPluginInfoCmd)
 
 	// Add devops plugin subcommands for installed plugins
-	home, _ := os.UserHomeDir()
-	pluginPath := filepath.Join(home, ".godspeed", "devops-plugins")
-
-	if utils.DirExists(pluginPath) {
-		plugins, err := os.ReadDir(pluginPath)
-		if err == nil {
-			for _, plugin := range plugins {
-				if plugin.IsDir() {
-					pluginName := plugin.Name()
-					pluginCmd := &cobra.Command{
-						Use:                pluginName,
-						Short:              "Installed godspeed devops plugin",
-						DisableFlagParsing: true,
-						Run: func(cmd *cobra.Command, args []string) {
-							pluginPath := filepath.Join(home, ".godspeed", "devops-plugins", pluginName, "dist", "index.js")
-							if utils.FileExists(pluginPath) {
-								utils.ExecuteCommand("node", append([]string{pluginPath}, args...))
-							} else {
-								fmt.Printf("%s is not installed properly. Please make sure %s exists.\n", pluginName, pluginPath)
-							}
-						},
-					}
-					devopsPluginCmd.AddCommand(pluginCmd)
-				}
-			}
-		}
+	for _, command := range devops.Discover() {
+		command := command
+		devopsPluginCmd.AddCommand(&cobra.Command{
+			Use:                strings.TrimSpace(command.Name + " " + command.Describe.Usage),
+			Short:              fmt.Sprintf("%s [%s]", command.Description, command.Origin),
+			Long:               command.Describe.Long,
+			DisableFlagParsing: true,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				return command.Describe.Completions, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return command.Run(args, version)
+			},
+		})
 	}
 
 	rootCmd.AddCommand(devopsPluginCmd)
@@ -290,16 +647,16 @@ func main() {
 	otelEnableCmd := &cobra.Command{
 		Use:   "enable",
 		Short: "Enable Observability in project",
-		Run: func(cmd *cobra.Command, args []string) {
-			otel.Enable()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return otel.Enable()
 		},
 	}
 
 	otelDisableCmd := &cobra.Command{
 		Use:   "disable",
 		Short: "Disable Observability in project",
-		Run: func(cmd *cobra.Command, args []string) {
-			otel.Disable()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return otel.Disable()
 		},
 	}
 
@@ -307,25 +664,59 @@ func main() {
 	rootCmd.AddCommand(otelCmd)
 
 	// Execute the root command
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil && !validated {
+		err = exitcode.Wrap(exitcode.Usage, err)
+		if jsonRequested {
+			output.Start(output.FormatJSON, cmd.CommandPath())
+		}
+	}
+	output.Finish(err)
+	if err == nil {
+		return
 	}
+	if !output.JSON() {
+		color.Red("Error: %v", err)
+		if !validated {
+			fmt.Printf("Run '%s --help' for usage.\n", cmd.CommandPath())
+		}
+	}
+	os.Exit(exitcode.Of(err))
 }
 
+// printBanner prints the welcome banner to stderr, so that it never mixes
+// with data a command prints to stdout, e.g. godspeed graph > deps.mmd
 func printBanner() {
-	fmt.Println()
+	fmt.Fprintln(os.Stderr)
 	white := color.New(color.FgWhite).SprintFunc()
 	boldWhite := color.New(color.FgWhite, color.Bold).SprintFunc()
 	red := color.New(color.FgRed, color.Bold).SprintFunc()
 	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
 	blue := color.New(color.FgBlue).SprintFunc()
 
-	fmt.Printf("%s %s\n", white("       ,_,   "), red("╔════════════════════════════════════╗"))
+	fmt.Fprintf(os.Stderr, "%s %s\n", white("       ,_,   "), red("╔════════════════════════════════════╗"))
 
-	fmt.Printf("%s %s\n", boldWhite("      (o o)  "), red("║")+yellow("        Welcome to Godspeed         ")+red("║"))
+	fmt.Fprintf(os.Stderr, "%s %s\n", boldWhite("      (o o)  "), red("║")+yellow("        Welcome to Godspeed         ")+red("║"))
 
-	fmt.Printf("%s %s\n", blue("     ({___}) "), red("║")+yellow("    World's First Meta Framework    ")+red("║"))
-	fmt.Printf("%s %s\n", boldWhite("       \" \"   "), red("╚════════════════════════════════════╝"))
-	fmt.Println()
+	fmt.Fprintf(os.Stderr, "%s %s\n", blue("     ({___}) "), red("║")+yellow("    World's First Meta Framework    ")+red("║"))
+	fmt.Fprintf(os.Stderr, "%s %s\n", boldWhite("       \" \"   "), red("╚════════════════════════════════════╝"))
+	fmt.Fprintln(os.Stderr)
+}
+
+// outputFormat returns the output format a command runs with, json if
+// --json is given
+func outputFormat(cmd *cobra.Command) string {
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		return output.FormatJSON
+	}
+	format, _ := cmd.Flags().GetString("output")
+	return format
+}
+
+// firstArg returns the first positional argument, or "" if there is none
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
 }
@@ -0,0 +1,572 @@
+package asyncapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/config"
+	"github.com/godspeedsystems/godspeed-cli/internal/events"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported AsyncAPI versions
+const (
+	Version26 = "2.6"
+	Version30 = "3.0"
+)
+
+// DefaultOutputPath is where the document is written unless --out is given
+const DefaultOutputPath = "asyncapi.yaml"
+
+// protocols maps eventsource types to AsyncAPI protocols
+var protocols = map[string]string{
+	"kafka":    "kafka",
+	"rabbitmq": "amqp",
+	"amqp":     "amqp",
+	"mqtt":     "mqtt",
+	"nats":     "nats",
+	"sqs":      "sqs",
+	"sns":      "sns",
+	"redis":    "redis",
+	"pulsar":   "pulsar",
+	"ibmmq":    "ibmmq",
+	"solace":   "solace",
+}
+
+// Result is the JSON result of gen-asyncapi
+type Result struct {
+	Version      string   `json:"version"`
+	Path         string   `json:"path"`
+	EventSources []string `json:"eventsources"`
+	Messages     int      `json:"messages"`
+}
+
+// messageEvent is an event consumed from a broker topic
+type messageEvent struct {
+	event    events.Event
+	source   string
+	protocol string
+	topic    string
+	group    string
+}
+
+// Generate writes an AsyncAPI document for the events bound to message
+// broker eventsources such as Kafka
+func Generate(version, outputPath, eventSource string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	if version != Version26 && version != Version30 {
+		return exitcode.New(exitcode.Usage, "unsupported AsyncAPI version %s. Use %s or %s", version, Version26, Version30)
+	}
+
+	allEvents, err := events.Load(filepath.Join("src", "events"))
+	if err != nil {
+		return fmt.Errorf("reading events: %w", err)
+	}
+
+	definitions, err := schema.LoadDefinitions(filepath.Join("src", "definitions"))
+	if err != nil {
+		return fmt.Errorf("reading definitions: %w", err)
+	}
+
+	messages, sources := collectMessageEvents(allEvents, eventSource)
+	if len(messages) == 0 {
+		return fmt.Errorf("no events bound to message broker eventsources found")
+	}
+
+	var document map[string]interface{}
+	if version == Version26 {
+		document, err = documentV2(messages, sources, definitions)
+	} else {
+		document, err = documentV3(messages, sources, definitions)
+	}
+	if err != nil {
+		return fmt.Errorf("generating AsyncAPI document: %w", err)
+	}
+
+	if outputPath == "" {
+		outputPath = DefaultOutputPath
+	}
+
+	var data []byte
+	if strings.HasSuffix(outputPath, ".json") {
+		data, err = json.MarshalIndent(document, "", "  ")
+	} else {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		err = encoder.Encode(document)
+		data = buf.Bytes()
+	}
+	if err != nil {
+		return fmt.Errorf("encoding AsyncAPI document: %w", err)
+	}
+
+	if err := ioutil.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", outputPath, err)
+	}
+
+	result := Result{Version: version, Path: outputPath, EventSources: []string{}, Messages: len(messages)}
+	for source := range sources {
+		result.EventSources = append(result.EventSources, source)
+	}
+	sort.Strings(result.EventSources)
+	output.Set(result)
+
+	color.Green("AsyncAPI %s document generated at %s", version, outputPath)
+	return nil
+}
+
+// collectMessageEvents finds the events bound to message broker eventsources
+// and returns them along with the configs of those eventsources
+func collectMessageEvents(allEvents map[string]events.Event, onlyEventSource string) ([]messageEvent, map[string]map[string]interface{}) {
+	sources := make(map[string]map[string]interface{})
+	protocolOf := make(map[string]string)
+
+	var messages []messageEvent
+	for _, key := range events.SortedKeys(allEvents) {
+		event := allEvents[key]
+		if event.IsHTTP() {
+			continue
+		}
+
+		for _, source := range event.Sources() {
+			if onlyEventSource != "" && source != onlyEventSource {
+				continue
+			}
+
+			protocol, ok := protocolOf[source]
+			if !ok {
+				esConfig, _ := events.LoadEventSource(source)
+				protocol = protocolFor(source, esConfig)
+				protocolOf[source] = protocol
+				if protocol != "" {
+					sources[source] = esConfig
+				}
+			}
+
+			if protocol == "" {
+				continue
+			}
+
+			topic, group := event.Channel()
+			if topic == "" {
+				continue
+			}
+
+			messages = append(messages, messageEvent{
+				event:    event,
+				source:   source,
+				protocol: protocol,
+				topic:    topic,
+				group:    group,
+			})
+		}
+	}
+
+	return messages, sources
+}
+
+// IsMessageEventSource reports whether an eventsource consumes from a
+// message broker, so its events are keyed "<eventsource>.<topic>.<group>"
+func IsMessageEventSource(source string, esConfig map[string]interface{}) bool {
+	return protocolFor(source, esConfig) != ""
+}
+
+// protocolFor returns the AsyncAPI protocol of an eventsource, based on its
+// configured type or its name, or "" if it is not a message broker
+func protocolFor(source string, esConfig map[string]interface{}) string {
+	typ, _ := esConfig["type"].(string)
+	names := make([]string, 0, len(protocols))
+	for name := range protocols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, candidate := range []string{strings.ToLower(typ), strings.ToLower(source)} {
+		for _, name := range names {
+			if candidate != "" && strings.Contains(candidate, name) {
+				return protocols[name]
+			}
+		}
+	}
+	return ""
+}
+
+// documentV2 builds an AsyncAPI 2.6 document. In 2.x a "publish" operation
+// describes messages the application receives.
+func documentV2(messages []messageEvent, sources map[string]map[string]interface{}, definitions *schema.Definitions) (map[string]interface{}, error) {
+	channels := make(map[string]interface{})
+
+	for _, m := range messages {
+		payload, err := payloadSchema(m.event, definitions)
+		if err != nil {
+			return nil, err
+		}
+
+		operation := map[string]interface{}{
+			"operationId": operationID(m),
+			"message": map[string]interface{}{
+				"name":    m.event.TypeName() + "Message",
+				"payload": payload,
+			},
+		}
+		addDescriptions(operation, m.event)
+		if bindings := operationBindings(m); bindings != nil {
+			operation["bindings"] = bindings
+		}
+
+		channel, ok := channels[m.topic].(map[string]interface{})
+		if !ok {
+			channel = map[string]interface{}{"servers": []string{}}
+			channels[m.topic] = channel
+		}
+
+		channel["servers"] = appendUnique(channel["servers"].([]string), serverNames(m.source, sources[m.source])...)
+
+		// AsyncAPI 2.x allows a single publish operation per channel, so
+		// additional consumers of the same topic are listed as oneOf messages
+		if existing, ok := channel["publish"].(map[string]interface{}); ok {
+			existing["message"] = mergeMessages(existing["message"], operation["message"])
+			if m.protocol == "kafka" && m.group != "" {
+				addGroup(existing, m.group)
+			}
+			continue
+		}
+		channel["publish"] = operation
+	}
+
+	document := map[string]interface{}{
+		"asyncapi": "2.6.0",
+		"info":     info(),
+		"servers":  serversV2(sources),
+		"channels": channels,
+	}
+
+	return withComponents(document, definitions)
+}
+
+// documentV3 builds an AsyncAPI 3.0 document, where channels declare their
+// address and messages, and "receive" operations refer to them
+func documentV3(messages []messageEvent, sources map[string]map[string]interface{}, definitions *schema.Definitions) (map[string]interface{}, error) {
+	channels := make(map[string]interface{})
+	operations := make(map[string]interface{})
+
+	for _, m := range messages {
+		payload, err := payloadSchema(m.event, definitions)
+		if err != nil {
+			return nil, err
+		}
+
+		channelID := identifier(m.topic)
+		messageName := m.event.TypeName() + "Message"
+
+		channel, ok := channels[channelID].(map[string]interface{})
+		if !ok {
+			channel = map[string]interface{}{
+				"address":  m.topic,
+				"messages": map[string]interface{}{},
+				"servers":  []interface{}{},
+			}
+			channels[channelID] = channel
+		}
+
+		channel["messages"].(map[string]interface{})[messageName] = map[string]interface{}{
+			"name":    messageName,
+			"payload": payload,
+		}
+
+		for _, server := range serverNames(m.source, sources[m.source]) {
+			ref := map[string]interface{}{"$ref": "#/servers/" + server}
+			if !containsRef(channel["servers"].([]interface{}), ref) {
+				channel["servers"] = append(channel["servers"].([]interface{}), ref)
+			}
+		}
+
+		operation := map[string]interface{}{
+			"action":   "receive",
+			"channel":  map[string]interface{}{"$ref": "#/channels/" + channelID},
+			"messages": []interface{}{map[string]interface{}{"$ref": fmt.Sprintf("#/channels/%s/messages/%s", channelID, messageName)}},
+		}
+		addDescriptions(operation, m.event)
+		if bindings := operationBindings(m); bindings != nil {
+			operation["bindings"] = bindings
+		}
+		operations[operationID(m)] = operation
+	}
+
+	document := map[string]interface{}{
+		"asyncapi":   "3.0.0",
+		"info":       info(),
+		"servers":    serversV3(sources),
+		"channels":   channels,
+		"operations": operations,
+	}
+
+	return withComponents(document, definitions)
+}
+
+// payloadSchema returns the body schema of an event with its references
+// pointing at the document's component schemas
+func payloadSchema(event events.Event, definitions *schema.Definitions) (interface{}, error) {
+	body := event.RequestBody()
+	if body == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	payload := events.ContentSchema(body)
+	if payload == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	rewritten, err := definitions.Rewrite(payload, schema.ComponentsRefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", event.Key, err)
+	}
+	return rewritten, nil
+}
+
+// withComponents adds the definitions as component schemas
+func withComponents(document map[string]interface{}, definitions *schema.Definitions) (map[string]interface{}, error) {
+	components, err := definitions.Components(schema.ComponentsRefPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(components) > 0 {
+		document["components"] = map[string]interface{}{"schemas": components}
+	}
+	return document, nil
+}
+
+// serversV2 describes each broker of each eventsource as a 2.6 server
+func serversV2(sources map[string]map[string]interface{}) map[string]interface{} {
+	servers := make(map[string]interface{})
+	for source, esConfig := range sources {
+		protocol := protocolFor(source, esConfig)
+		names := serverNames(source, esConfig)
+		for i, broker := range brokers(esConfig) {
+			servers[names[i]] = map[string]interface{}{
+				"url":         broker,
+				"protocol":    protocol,
+				"description": fmt.Sprintf("Broker of the %s eventsource", source),
+			}
+		}
+	}
+	return servers
+}
+
+// serversV3 describes each broker of each eventsource as a 3.0 server
+func serversV3(sources map[string]map[string]interface{}) map[string]interface{} {
+	servers := make(map[string]interface{})
+	for source, esConfig := range sources {
+		protocol := protocolFor(source, esConfig)
+		names := serverNames(source, esConfig)
+		for i, broker := range brokers(esConfig) {
+			host, pathname := broker, ""
+			if i := strings.Index(host, "://"); i >= 0 {
+				host = host[i+3:]
+			}
+			if i := strings.Index(host, "/"); i >= 0 {
+				host, pathname = host[:i], host[i:]
+			}
+
+			server := map[string]interface{}{
+				"host":        host,
+				"protocol":    protocol,
+				"description": fmt.Sprintf("Broker of the %s eventsource", source),
+			}
+			if pathname != "" {
+				server["pathname"] = pathname
+			}
+			servers[names[i]] = server
+		}
+	}
+	return servers
+}
+
+// brokers reads the broker addresses from an eventsource config. Godspeed
+// message eventsources use "brokers", others a single "url" or host/port.
+func brokers(esConfig map[string]interface{}) []string {
+	var result []string
+	switch value := esConfig["brokers"].(type) {
+	case []interface{}:
+		for _, broker := range value {
+			if str, ok := broker.(string); ok {
+				result = append(result, str)
+			}
+		}
+	case string:
+		for _, broker := range strings.Split(value, ",") {
+			if broker = strings.TrimSpace(broker); broker != "" {
+				result = append(result, broker)
+			}
+		}
+	}
+
+	if len(result) == 0 {
+		if url, ok := esConfig["url"].(string); ok {
+			result = append(result, url)
+		} else if host, ok := esConfig["host"].(string); ok {
+			if port, ok := esConfig["port"]; ok {
+				host = fmt.Sprintf("%s:%v", host, port)
+			}
+			result = append(result, host)
+		}
+	}
+
+	if len(result) == 0 {
+		result = append(result, "localhost")
+	}
+	return result
+}
+
+// serverNames returns the server names of an eventsource's brokers
+func serverNames(source string, esConfig map[string]interface{}) []string {
+	addresses := brokers(esConfig)
+	if len(addresses) == 1 {
+		return []string{identifier(source)}
+	}
+
+	names := make([]string, len(addresses))
+	for i := range addresses {
+		names[i] = fmt.Sprintf("%s-%d", identifier(source), i)
+	}
+	return names
+}
+
+// operationBindings returns the protocol bindings of an operation
+func operationBindings(m messageEvent) map[string]interface{} {
+	if m.protocol != "kafka" || m.group == "" {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"kafka": map[string]interface{}{
+			"groupId": map[string]interface{}{
+				"type": "string",
+				"enum": []string{m.group},
+			},
+		},
+	}
+}
+
+// addGroup adds a consumer group to the kafka bindings of a 2.x operation
+func addGroup(operation map[string]interface{}, group string) {
+	bindings, ok := operation["bindings"].(map[string]interface{})
+	if !ok {
+		operation["bindings"] = operationBindings(messageEvent{protocol: "kafka", group: group})
+		return
+	}
+
+	groupID := bindings["kafka"].(map[string]interface{})["groupId"].(map[string]interface{})
+	groupID["enum"] = appendUnique(groupID["enum"].([]string), group)
+}
+
+// operationID derives a stable operation id from the event
+func operationID(m messageEvent) string {
+	if m.event.ID != "" {
+		return m.event.ID
+	}
+	return "receive" + m.event.TypeName()
+}
+
+// addDescriptions copies the event summary and description to an operation
+func addDescriptions(operation map[string]interface{}, event events.Event) {
+	if event.Summary != "" {
+		operation["summary"] = event.Summary
+	}
+	if event.Description != "" {
+		operation["description"] = event.Description
+	}
+}
+
+// mergeMessages combines two 2.x operation messages into a oneOf
+func mergeMessages(existing, message interface{}) interface{} {
+	if existingMap, ok := existing.(map[string]interface{}); ok {
+		if oneOf, ok := existingMap["oneOf"].([]interface{}); ok {
+			existingMap["oneOf"] = append(oneOf, message)
+			return existingMap
+		}
+	}
+	return map[string]interface{}{"oneOf": []interface{}{existing, message}}
+}
+
+// info builds the info object from the project's .godspeed and package.json
+func info() map[string]interface{} {
+	title := "Godspeed service"
+	if godspeedConfig, err := config.LoadGodspeedConfig(".godspeed"); err == nil {
+		if name, ok := godspeedConfig["projectName"].(string); ok && name != "" {
+			title = name
+		}
+	}
+
+	version := "1.0.0"
+	if data, err := ioutil.ReadFile("package.json"); err == nil {
+		var pkg struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(data, &pkg) == nil && pkg.Version != "" {
+			version = pkg.Version
+		}
+	}
+
+	return map[string]interface{}{
+		"title":       title,
+		"version":     version,
+		"description": "Generated by godspeed gen-asyncapi from src/events",
+	}
+}
+
+// identifier converts a topic or eventsource name into a valid AsyncAPI key
+func identifier(name string) string {
+	var result strings.Builder
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			result.WriteRune(r)
+		} else {
+			result.WriteRune('_')
+		}
+	}
+	return result.String()
+}
+
+// appendUnique appends the values that are not already present
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// containsRef reports whether list holds a reference equal to ref
+func containsRef(list []interface{}, ref map[string]interface{}) bool {
+	for _, item := range list {
+		if itemMap, ok := item.(map[string]interface{}); ok && itemMap["$ref"] == ref["$ref"] {
+			return true
+		}
+	}
+	return false
+}
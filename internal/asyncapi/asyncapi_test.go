@@ -0,0 +1,219 @@
+package asyncapi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/events"
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+)
+
+// inProject writes files into a temporary project and makes it the working
+// directory for the rest of the test
+func inProject(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestProtocolFor(t *testing.T) {
+	tests := []struct {
+		source string
+		typ    string
+		want   string
+	}{
+		{"kafka", "", "kafka"},
+		{"orders", "kafka", "kafka"},
+		{"events", "RabbitMQ", "amqp"},
+		{"mqtt-sensors", "", "mqtt"},
+		{"queue", "aws-sqs", "sqs"},
+		{"http", "express", ""},
+		{"cron", "cron", ""},
+	}
+
+	for _, tt := range tests {
+		if got := protocolFor(tt.source, map[string]interface{}{"type": tt.typ}); got != tt.want {
+			t.Errorf("protocolFor(%q, %q) = %q, want %q", tt.source, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestBrokers(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   []string
+	}{
+		{"list", map[string]interface{}{"brokers": []interface{}{"k1:9092", "k2:9092"}}, []string{"k1:9092", "k2:9092"}},
+		{"comma separated", map[string]interface{}{"brokers": "k1:9092, k2:9092,"}, []string{"k1:9092", "k2:9092"}},
+		{"url", map[string]interface{}{"url": "amqp://mq:5672/vhost"}, []string{"amqp://mq:5672/vhost"}},
+		{"host and port", map[string]interface{}{"host": "nats", "port": 4222}, []string{"nats:4222"}},
+		{"nothing configured", map[string]interface{}{}, []string{"localhost"}},
+	}
+
+	for _, tt := range tests {
+		if got := brokers(tt.config); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: brokers = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// testMessages loads the message events of a project with a kafka
+// eventsource of two brokers, where two groups consume the orders topic
+func testMessages(t *testing.T) ([]messageEvent, map[string]map[string]interface{}) {
+	inProject(t, map[string]string{
+		"src/eventsources/kafka.yaml": "type: kafka\nbrokers: [k1:9092, k2:9092]\n",
+		"src/eventsources/http.yaml":  "type: express\n",
+	})
+
+	allEvents := map[string]events.Event{
+		"kafka.orders.billing":  {Key: "kafka.orders.billing", Summary: "Bill an order"},
+		"kafka.orders.shipping": {Key: "kafka.orders.shipping", ID: "shipOrder"},
+		"kafka.users":           {Key: "kafka.users"},
+		"http.get./orders":      {Key: "http.get./orders"},
+		"http.orders.ignored":   {Key: "http.orders.ignored"},
+	}
+	return collectMessageEvents(allEvents, "")
+}
+
+func TestCollectMessageEvents(t *testing.T) {
+	messages, sources := testMessages(t)
+
+	type channel struct{ source, protocol, topic, group string }
+	var got []channel
+	for _, m := range messages {
+		got = append(got, channel{m.source, m.protocol, m.topic, m.group})
+	}
+	want := []channel{
+		{"kafka", "kafka", "orders", "billing"},
+		{"kafka", "kafka", "orders", "shipping"},
+		{"kafka", "kafka", "users", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %v, want %v", got, want)
+	}
+	if _, ok := sources["http"]; ok || len(sources) != 1 {
+		t.Errorf("sources = %v, want only kafka", sources)
+	}
+}
+
+func TestDocumentV2(t *testing.T) {
+	messages, sources := testMessages(t)
+	definitions, _ := schema.LoadDefinitions("missing")
+
+	document, err := documentV2(messages, sources, definitions)
+	if err != nil {
+		t.Fatalf("documentV2: %v", err)
+	}
+
+	servers := document["servers"].(map[string]interface{})
+	if len(servers) != 2 || servers["kafka-0"] == nil || servers["kafka-1"] == nil {
+		t.Errorf("servers = %v, want kafka-0 and kafka-1", servers)
+	}
+
+	channels := document["channels"].(map[string]interface{})
+	orders := channels["orders"].(map[string]interface{})
+	if want := []string{"kafka-0", "kafka-1"}; !reflect.DeepEqual(orders["servers"], want) {
+		t.Errorf("orders servers = %v, want %v", orders["servers"], want)
+	}
+
+	publish := orders["publish"].(map[string]interface{})
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"operationId", publish["operationId"], "receiveKafkaOrdersBilling"},
+		{"summary", publish["summary"], "Bill an order"},
+		{"groups", publish["bindings"].(map[string]interface{})["kafka"].(map[string]interface{})["groupId"].(map[string]interface{})["enum"], []string{"billing", "shipping"}},
+		{"messages", len(publish["message"].(map[string]interface{})["oneOf"].([]interface{})), 2},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("orders %s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	users := channels["users"].(map[string]interface{})["publish"].(map[string]interface{})
+	if _, ok := users["bindings"]; ok {
+		t.Errorf("users publish has bindings %v, want none without a group", users["bindings"])
+	}
+	if name := users["message"].(map[string]interface{})["name"]; name != "KafkaUsersMessage" {
+		t.Errorf("users message name = %v, want KafkaUsersMessage", name)
+	}
+}
+
+func TestDocumentV3(t *testing.T) {
+	messages, sources := testMessages(t)
+	definitions, _ := schema.LoadDefinitions("missing")
+
+	document, err := documentV3(messages, sources, definitions)
+	if err != nil {
+		t.Fatalf("documentV3: %v", err)
+	}
+
+	orders := document["channels"].(map[string]interface{})["orders"].(map[string]interface{})
+	if orders["address"] != "orders" {
+		t.Errorf("orders address = %v, want orders", orders["address"])
+	}
+	var names []string
+	for name := range orders["messages"].(map[string]interface{}) {
+		names = append(names, name)
+	}
+	if len(names) != 2 {
+		t.Errorf("orders messages = %v, want one per consumer", names)
+	}
+	wantServers := []interface{}{
+		map[string]interface{}{"$ref": "#/servers/kafka-0"},
+		map[string]interface{}{"$ref": "#/servers/kafka-1"},
+	}
+	if !reflect.DeepEqual(orders["servers"], wantServers) {
+		t.Errorf("orders servers = %v, want %v", orders["servers"], wantServers)
+	}
+
+	operations := document["operations"].(map[string]interface{})
+	if len(operations) != 3 {
+		t.Errorf("operations = %v, want one per event", operations)
+	}
+	ship := operations["shipOrder"].(map[string]interface{})
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"action", ship["action"], "receive"},
+		{"channel", ship["channel"], map[string]interface{}{"$ref": "#/channels/orders"}},
+		{"messages", ship["messages"], []interface{}{map[string]interface{}{"$ref": "#/channels/orders/messages/KafkaOrdersShippingMessage"}}},
+		{"group", ship["bindings"].(map[string]interface{})["kafka"].(map[string]interface{})["groupId"].(map[string]interface{})["enum"], []string{"shipping"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("shipOrder %s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	server := document["servers"].(map[string]interface{})["kafka-1"]
+	if want := map[string]interface{}{"host": "k2:9092", "protocol": "kafka", "description": "Broker of the kafka eventsource"}; !reflect.DeepEqual(server, want) {
+		t.Errorf("server kafka-1 = %v, want %v", server, want)
+	}
+}
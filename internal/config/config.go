@@ -2,11 +2,14 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/godspeedsystems/godspeed-cli/assets"
 	"github.com/godspeedsystems/godspeed-cli/internal/utils"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 // Init initializes the configuration
@@ -40,29 +43,8 @@ func Init() error {
 
 // LoadPluginsList loads the plugins list from the embedded asset
 func LoadPluginsList() ([]map[string]interface{}, error) {
-	// Get the executable path
-	execPath, err := os.Executable()
-	if err != nil {
-		return nil, err
-	}
-
-	// Path to the plugins list json
-	pluginsListPath := filepath.Join(filepath.Dir(execPath), "assets", "plugins_list.json")
-
-	// If the file doesn't exist, use a default empty list
-	if !utils.FileExists(pluginsListPath) {
-		return []map[string]interface{}{}, nil
-	}
-
-	// Read the file
-	data, err := os.ReadFile(pluginsListPath)
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse the JSON
 	var plugins []map[string]interface{}
-	if err := json.Unmarshal(data, &plugins); err != nil {
+	if err := json.Unmarshal(assets.PluginsList, &plugins); err != nil {
 		return nil, err
 	}
 
@@ -102,3 +84,119 @@ func LoadGodspeedConfig(configPath string) (map[string]interface{}, error) {
 
 	return config, nil
 }
+
+// ServicePort returns the service port configured in the project's .godspeed
+// file, or 0 if it is not set
+func ServicePort() int {
+	godspeedConfig, err := LoadGodspeedConfig(".godspeed")
+	if err != nil {
+		return 0
+	}
+
+	if port, ok := godspeedConfig["servicePort"].(float64); ok {
+		return int(port)
+	}
+
+	return 0
+}
+
+// GSNodeServiceVersion returns the framework version configured in the
+// project's .godspeed file, or "" if it is not set
+func GSNodeServiceVersion() string {
+	godspeedConfig, err := LoadGodspeedConfig(".godspeed")
+	if err != nil {
+		return ""
+	}
+
+	version, _ := godspeedConfig["gsNodeServiceVersion"].(string)
+	return version
+}
+
+// DefaultDevopsPluginsDir is the project-scoped devops plugins directory
+// declared by devops-plugin install --project
+const DefaultDevopsPluginsDir = ".godspeed-devops"
+
+// DevopsPluginsDir returns the project-scoped devops plugins directory
+// declared under devopsPlugins in a .godspeed file, or "" if there is none
+func DevopsPluginsDir(configPath string) string {
+	godspeedConfig, err := LoadGodspeedConfig(configPath)
+	if err != nil {
+		return ""
+	}
+
+	dir, _ := godspeedConfig["devopsPlugins"].(string)
+	return dir
+}
+
+// DeclareDevopsPluginsDir declares dir as the project-scoped devops plugins
+// directory in a .godspeed file
+func DeclareDevopsPluginsDir(configPath, dir string) error {
+	godspeedConfig, err := LoadGodspeedConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	godspeedConfig["devopsPlugins"] = dir
+	return SaveGodspeedConfig(configPath, godspeedConfig)
+}
+
+// ProjectName returns the project name configured in the project's .godspeed
+// file, defaulting to the name of the current directory
+func ProjectName() string {
+	godspeedConfig, err := LoadGodspeedConfig(".godspeed")
+	if err == nil {
+		if name, ok := godspeedConfig["projectName"].(string); ok && name != "" {
+			return name
+		}
+	}
+
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		return "godspeed"
+	}
+	return filepath.Base(dir)
+}
+
+// UserConfigPath returns the path of the user's CLI config, ~/.godspeed/config
+func UserConfigPath() string {
+	return filepath.Join(utils.GetGodspeedDir(), "config")
+}
+
+// LoadUserConfig loads the user's CLI config. It is YAML (or JSON), and a
+// missing file yields an empty config.
+func LoadUserConfig() (map[string]interface{}, error) {
+	data, err := os.ReadFile(UserConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, err
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %v", UserConfigPath(), err)
+	}
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	return config, nil
+}
+
+// PluginCatalogs returns the URLs and files of the plugin catalogs configured
+// under "pluginCatalogs" in the user's CLI config
+func PluginCatalogs() ([]string, error) {
+	userConfig, err := LoadUserConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var catalogs []string
+	list, _ := userConfig["pluginCatalogs"].([]interface{})
+	for _, item := range list {
+		if catalog, ok := item.(string); ok && catalog != "" {
+			catalogs = append(catalogs, catalog)
+		}
+	}
+	return catalogs, nil
+}
@@ -2,6 +2,7 @@ package create
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,6 +18,9 @@ import (
 	"github.com/fatih/color"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing" // Add this line
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/prompt"
 	"github.com/godspeedsystems/godspeed-cli/internal/utils"
 )
 
@@ -35,17 +39,32 @@ type GodspeedOptions struct {
 	Meta                 map[string]interface{} `json:"meta"`
 }
 
-// Execute creates a new godspeed project
-func Execute(projectName, fromTemplate, fromExample, cliVersion string) {
+// Result is the JSON result of create. Created is false when the user kept
+// an existing project directory.
+type Result struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Template string `json:"template,omitempty"`
+	Example  string `json:"example,omitempty"`
+	Created  bool   `json:"created"`
+}
+
+// Execute creates a new godspeed project. An existing project directory is
+// only replaced with overwrite or when the user confirms it.
+func Execute(projectName, fromTemplate, fromExample, cliVersion string, overwrite bool) error {
 	fmt.Println()
 
 	// Create project directory
 	projectDirPath := filepath.Join(".", projectName)
+	result := Result{Name: projectName, Path: projectDirPath, Template: fromTemplate, Example: fromExample}
+	defer output.Set(&result)
 
 	// Validate and create project directory
-	if err := validateAndCreateProjectDirectory(projectDirPath); err != nil {
-		color.Red("Error creating project directory: %v", err)
-		os.Exit(1)
+	if err := validateAndCreateProjectDirectory(projectDirPath, overwrite); err == errNotOverwritten {
+		fmt.Println(color.RedString("\nExiting godspeed create without creating project."))
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("creating project directory: %w", err)
 	}
 
 	var godspeedOptions *GodspeedOptions
@@ -53,29 +72,25 @@ func Execute(projectName, fromTemplate, fromExample, cliVersion string) {
 	// Handle template or clone default template
 	if fromTemplate != "" {
 		if err := copyingLocalTemplate(projectDirPath, fromTemplate); err != nil {
-			color.Red("Error copying template: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("copying template: %w", err)
 		}
 	} else {
 		if err := cloneProjectTemplate(projectDirPath); err != nil {
-			color.Red("Error cloning template: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("cloning template: %w", err)
 		}
 	}
 
 	// Generate from examples
 	var err error
 	if godspeedOptions, err = generateFromExamples(projectDirPath, fromExample); err != nil {
-		color.Red("Error generating from examples: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("generating from examples: %w", err)
 	}
 
 	// If no options were loaded from examples, use interactive mode
 	if godspeedOptions == nil {
 		godspeedOptions, err = interactiveMode(projectName)
 		if err != nil {
-			color.Red("Error in interactive mode: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("asking for project options: %w", err)
 		}
 	}
 
@@ -91,48 +106,55 @@ func Execute(projectName, fromTemplate, fromExample, cliVersion string) {
 
 	// Generate project files
 	if err := generateProjectFromDotGodspeed(projectName, projectDirPath, godspeedOptions, fromExample); err != nil {
-		color.Red("Error generating project: %v", err)
 		utils.RemoveDir(projectDirPath)
-		os.Exit(1)
+		return fmt.Errorf("generating project: %w", err)
 	}
+	result.Created = true
 
 	// Install specific plugins for examples
 	if fromExample == "mongo-as-prisma" {
 		spinner := utils.NewSpinner("Installing prisma plugin... ")
 		spinner.Start()
-		utils.ExecuteCommand("npm", []string{"install", "@godspeedsystems/plugins-prisma-as-datastore", "--quiet"})
+		err := utils.ExecuteCommand("npm", []string{"install", "@godspeedsystems/plugins-prisma-as-datastore", "--quiet"})
 		spinner.Stop()
+		if err != nil {
+			return exitcode.New(exitcode.ToolFailure, "installing prisma plugin: %v", err)
+		}
 	}
 
 	// Install dependencies
 	if err := installDependencies(projectDirPath, projectName); err != nil {
-		color.Red("Error installing dependencies: %v", err)
-		os.Exit(1)
+		return exitcode.New(exitcode.ToolFailure, "installing dependencies: %v", err)
 	}
 
 	color.Green("\nSuccessfully created the project %s.", color.YellowString(projectName))
 	color.Green("Use `godspeed help` command for available commands.")
 	fmt.Println()
 	color.Green("\nHappy building microservices with Godspeed! 🚀🎉\n")
+	return nil
 }
 
-// validateAndCreateProjectDirectory ensures the project directory can be created
-func validateAndCreateProjectDirectory(projectDirPath string) error {
+// errNotOverwritten is returned when the user keeps an existing project directory
+var errNotOverwritten = errors.New("project directory not overwritten")
+
+// validateAndCreateProjectDirectory ensures the project directory can be
+// created, replacing an existing one with overwrite or if the user confirms
+func validateAndCreateProjectDirectory(projectDirPath string, overwrite bool) error {
 	// Check if directory already exists
 	if utils.DirExists(projectDirPath) {
-		var overwrite bool
-		prompt := &survey.Confirm{
-			Message: fmt.Sprintf("%s already exists.\nDo you want to overwrite the project folder?", color.YellowString(projectDirPath)),
-			Default: false,
-		}
+		if !overwrite {
+			question := &survey.Confirm{
+				Message: fmt.Sprintf("%s already exists.\nDo you want to overwrite the project folder?", color.YellowString(projectDirPath)),
+				Default: false,
+			}
 
-		if err := survey.AskOne(prompt, &overwrite); err != nil {
-			return err
+			if err := prompt.Ask(question, &overwrite, "--overwrite"); err != nil {
+				return err
+			}
 		}
 
 		if !overwrite {
-			fmt.Println(color.RedString("\nExiting godspeed create without creating project."))
-			os.Exit(0)
+			return errNotOverwritten
 		}
 
 		// Remove existing directory
@@ -185,9 +207,9 @@ func cloneProjectTemplate(projectDirPath string) error {
 		// Fallback to system git command
 		color.Yellow("Falling back to system git command...")
 		cmd := exec.Command("git", "clone", repoURL, "--branch", branch, "--depth", "1", projectDirPath)
-		output, err := cmd.CombinedOutput()
+		out, err := cmd.CombinedOutput()
 		if err != nil {
-			return fmt.Errorf("git clone failed: %v\nOutput: %s", err, output)
+			return fmt.Errorf("git clone failed: %v\nOutput: %s", err, out)
 		}
 		color.Green("Git clone successful using system git")
 	} else {
@@ -276,10 +298,10 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 
 	// MongoDB questions
 	var useMongoDB bool
-	if err := survey.AskOne(&survey.Confirm{
+	if err := prompt.Ask(&survey.Confirm{
 		Message: "Do you want mongoDB as database?",
 		Default: false,
-	}, &useMongoDB); err != nil {
+	}, &useMongoDB, ""); err != nil {
 		return nil, err
 	}
 
@@ -288,31 +310,31 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 		var dbName string
 		var port1, port2, port3 int
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "What do you want to name your MongoDB database?",
 			Default: "godspeed",
-		}, &dbName, survey.WithValidator(wordValidator)); err != nil {
+		}, &dbName, "", survey.WithValidator(wordValidator)); err != nil {
 			return nil, err
 		}
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "Please enter the port for MongoDB node[1].",
 			Default: "27017",
-		}, &port1, survey.WithValidator(portValidator)); err != nil {
+		}, &port1, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "Please enter the port for MongoDB node[2].",
 			Default: "27018",
-		}, &port2, survey.WithValidator(portValidator)); err != nil {
+		}, &port2, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "Please enter the port for MongoDB node[3].",
 			Default: "27019",
-		}, &port3, survey.WithValidator(portValidator)); err != nil {
+		}, &port3, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
@@ -324,10 +346,10 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 
 	// MySQL questions
 	var useMySQL bool
-	if err := survey.AskOne(&survey.Confirm{
+	if err := prompt.Ask(&survey.Confirm{
 		Message: "Do you want to use MySQL as database?",
 		Default: false,
-	}, &useMySQL); err != nil {
+	}, &useMySQL, ""); err != nil {
 		return nil, err
 	}
 
@@ -336,17 +358,17 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 		var dbName string
 		var port int
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "What will be the name of MySQL database?",
 			Default: "godspeed",
-		}, &dbName, survey.WithValidator(wordValidator)); err != nil {
+		}, &dbName, "", survey.WithValidator(wordValidator)); err != nil {
 			return nil, err
 		}
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "What will be the port of MySQL database?",
 			Default: "3306",
-		}, &port, survey.WithValidator(portValidator)); err != nil {
+		}, &port, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
@@ -358,10 +380,10 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 
 	// PostgreSQL questions
 	var usePostgreSQL bool
-	if err := survey.AskOne(&survey.Confirm{
+	if err := prompt.Ask(&survey.Confirm{
 		Message: "Do you want to use PostgreSQL as database?",
 		Default: false,
-	}, &usePostgreSQL); err != nil {
+	}, &usePostgreSQL, ""); err != nil {
 		return nil, err
 	}
 
@@ -370,17 +392,17 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 		var dbName string
 		var port int
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "What will be the name of PostgreSQL database?",
 			Default: "godspeed",
-		}, &dbName, survey.WithValidator(wordValidator)); err != nil {
+		}, &dbName, "", survey.WithValidator(wordValidator)); err != nil {
 			return nil, err
 		}
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "What will be the port of PostgreSQL database?",
 			Default: "5432",
-		}, &port, survey.WithValidator(portValidator)); err != nil {
+		}, &port, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
@@ -392,10 +414,10 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 
 	// Kafka questions
 	var useKafka bool
-	if err := survey.AskOne(&survey.Confirm{
+	if err := prompt.Ask(&survey.Confirm{
 		Message: "Do you want to use Apache Kafka?",
 		Default: false,
-	}, &useKafka); err != nil {
+	}, &useKafka, ""); err != nil {
 		return nil, err
 	}
 
@@ -403,17 +425,17 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 	if useKafka {
 		var kafkaPort, zookeeperPort int
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "Please enter kafka port.",
 			Default: "9092",
-		}, &kafkaPort, survey.WithValidator(portValidator)); err != nil {
+		}, &kafkaPort, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "Please enter zookeeper port.",
 			Default: "2181",
-		}, &zookeeperPort, survey.WithValidator(portValidator)); err != nil {
+		}, &zookeeperPort, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
@@ -425,10 +447,10 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 
 	// Elasticsearch questions
 	var useElasticsearch bool
-	if err := survey.AskOne(&survey.Confirm{
+	if err := prompt.Ask(&survey.Confirm{
 		Message: "Do you want to use Elasticsearch?",
 		Default: false,
-	}, &useElasticsearch); err != nil {
+	}, &useElasticsearch, ""); err != nil {
 		return nil, err
 	}
 
@@ -436,10 +458,10 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 	if useElasticsearch {
 		var port int
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "Please enter Elasticsearch port.",
 			Default: "9200",
-		}, &port, survey.WithValidator(portValidator)); err != nil {
+		}, &port, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
@@ -450,10 +472,10 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 
 	// Redis questions
 	var useRedis bool
-	if err := survey.AskOne(&survey.Confirm{
+	if err := prompt.Ask(&survey.Confirm{
 		Message: "Do you want to use Redis as database?",
 		Default: false,
-	}, &useRedis); err != nil {
+	}, &useRedis, ""); err != nil {
 		return nil, err
 	}
 
@@ -462,17 +484,17 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 		var dbName string
 		var port int
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "Please enter Redis database name.",
 			Default: "godspeed",
-		}, &dbName, survey.WithValidator(wordValidator)); err != nil {
+		}, &dbName, "", survey.WithValidator(wordValidator)); err != nil {
 			return nil, err
 		}
 
-		if err := survey.AskOne(&survey.Input{
+		if err := prompt.Ask(&survey.Input{
 			Message: "Please enter the Redis port?",
 			Default: "6379",
-		}, &port, survey.WithValidator(portValidator)); err != nil {
+		}, &port, "", survey.WithValidator(portValidator)); err != nil {
 			return nil, err
 		}
 
@@ -484,20 +506,20 @@ func interactiveMode(projectName string) (*GodspeedOptions, error) {
 
 	// Service port
 	var servicePort int
-	if err := survey.AskOne(&survey.Input{
+	if err := prompt.Ask(&survey.Input{
 		Message: "Please enter host port on which you want to run your service.",
 		Default: "3000",
-	}, &servicePort, survey.WithValidator(portValidator)); err != nil {
+	}, &servicePort, "", survey.WithValidator(portValidator)); err != nil {
 		return nil, err
 	}
 
 	// Framework version
 	var gsNodeServiceVersion string
-	if err := survey.AskOne(&survey.Select{
+	if err := prompt.Ask(&survey.Select{
 		Message: "Please select gs-node-service(Godspeed Framework) version.",
 		Options: versions,
 		Default: "latest",
-	}, &gsNodeServiceVersion); err != nil {
+	}, &gsNodeServiceVersion, ""); err != nil {
 		return nil, err
 	}
 
@@ -561,9 +583,9 @@ func getCurrentTimestamp() string {
 // getUserID gets the current user ID
 func getUserID() int {
 	if runtime.GOOS == "linux" {
-		output, err := utils.ExecuteCommandWithOutput("id", []string{"-u"})
+		out, err := utils.ExecuteCommandWithOutput("id", []string{"-u"})
 		if err == nil {
-			uid, err := strconv.Atoi(strings.TrimSpace(output))
+			uid, err := strconv.Atoi(strings.TrimSpace(out))
 			if err == nil {
 				return uid
 			}
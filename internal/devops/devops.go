@@ -7,10 +7,16 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/plugin"
+	"github.com/godspeedsystems/godspeed-cli/internal/prompt"
+	"github.com/godspeedsystems/godspeed-cli/internal/semver"
 	"github.com/godspeedsystems/godspeed-cli/internal/utils"
 )
 
@@ -21,14 +27,36 @@ type DevopsPlugin struct {
 	Version     string `json:"version"`
 }
 
-// Install installs a devops plugin
-func Install(pluginName string) {
-	gsDevopsPluginsDir := filepath.Join(utils.UserHomeDir(), ".godspeed", "devops-plugins")
+// ChangedPlugin is a devops plugin installed, updated or removed by a
+// command, as reported with --output json
+type ChangedPlugin struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// ChangeResult is the JSON result of devops-plugin install, remove and update
+type ChangeResult struct {
+	Plugins []ChangedPlugin `json:"plugins"`
+	Dir     string          `json:"dir"`
+}
+
+// Install installs a devops plugin. pluginName may pin a version, e.g.
+// "@godspeedsystems/devops-plugin-deployer@1.2.0", which is saved exactly.
+// With project it is installed in the project's devops plugins directory,
+// which is declared in .godspeed if the project has none yet.
+func Install(pluginName string, project bool) error {
+	gsDevopsPluginsDir := PluginsDir()
+	if project {
+		dir, err := declareProjectPluginsDir()
+		if err != nil {
+			return err
+		}
+		gsDevopsPluginsDir = dir
+	}
 
 	// Create plugins directory if it doesn't exist
 	if err := utils.CreateDir(gsDevopsPluginsDir); err != nil {
-		color.Red("Error creating plugins directory: %v", err)
-		return
+		return fmt.Errorf("creating plugins directory: %w", err)
 	}
 
 	// Initialize package.json if it doesn't exist
@@ -37,8 +65,7 @@ func Install(pluginName string) {
 		cmd := exec.Command("npm", "init", "--yes")
 		cmd.Dir = gsDevopsPluginsDir
 		if err := cmd.Run(); err != nil {
-			color.Red("Error initializing package.json: %v", err)
-			return
+			return exitcode.New(exitcode.ToolFailure, "initializing package.json: %v", err)
 		}
 	}
 
@@ -46,13 +73,11 @@ func Install(pluginName string) {
 	if pluginName == "" {
 		availablePlugins, err := searchDevopsPlugins()
 		if err != nil {
-			color.Red("Error searching for devops plugins: %v", err)
-			return
+			return exitcode.New(exitcode.ToolFailure, "searching for devops plugins: %v", err)
 		}
 
 		if len(availablePlugins) == 0 {
-			color.Red("No devops plugins found.")
-			return
+			return fmt.Errorf("no devops plugins found")
 		}
 
 		options := make([]string, len(availablePlugins))
@@ -61,14 +86,13 @@ func Install(pluginName string) {
 		}
 
 		var selected string
-		prompt := &survey.Select{
+		question := &survey.Select{
 			Message: "Please select devops plugin to install:",
 			Options: options,
 		}
 
-		if err := survey.AskOne(prompt, &selected); err != nil {
-			color.Red("Error: %v", err)
-			return
+		if err := prompt.Ask(question, &selected, "<pluginName>"); err != nil {
+			return err
 		}
 
 		// Extract plugin name from the selected option
@@ -77,42 +101,50 @@ func Install(pluginName string) {
 	}
 
 	// Install the plugin
+	args := []string{"install", pluginName}
+	if _, version := plugin.SplitSpec(pluginName); version != "" {
+		args = append(args, "--save-exact")
+	}
+
 	color.Yellow("Installing %s...", pluginName)
-	cmd := exec.Command("npm", "install", pluginName)
+	cmd := exec.Command("npm", args...)
 	cmd.Dir = gsDevopsPluginsDir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
-		color.Red("Error installing plugin: %v", err)
-		return
+		return exitcode.New(exitcode.ToolFailure, "installing plugin: %v", err)
 	}
 
 	color.Green("Successfully installed %s", pluginName)
+	name, _ := plugin.SplitSpec(pluginName)
+	output.Set(changeResult(gsDevopsPluginsDir, []string{name}))
+	return nil
 }
 
-// Remove removes a devops plugin
-func Remove(pluginName string) {
-	gsDevopsPluginsDir := filepath.Join(utils.UserHomeDir(), ".godspeed", "devops-plugins")
+// Remove removes a devops plugin, from the project's devops plugins
+// directory with project
+func Remove(pluginName string, project bool) error {
+	gsDevopsPluginsDir, err := scopeDir(project)
+	if err != nil {
+		return err
+	}
 
 	// Check if plugins directory exists
 	if !utils.DirExists(gsDevopsPluginsDir) {
-		color.Red("Devops plugins directory not found.")
-		return
+		return fmt.Errorf("devops plugins directory not found")
 	}
 
 	// Check if package.json exists
 	packageJsonPath := filepath.Join(gsDevopsPluginsDir, "package.json")
 	if !utils.FileExists(packageJsonPath) {
-		color.Red("No devops plugins are installed.")
-		return
+		return fmt.Errorf("no devops plugins are installed")
 	}
 
 	// Read package.json to get installed plugins
 	data, err := ioutil.ReadFile(packageJsonPath)
 	if err != nil {
-		color.Red("Error reading package.json: %v", err)
-		return
+		return fmt.Errorf("reading package.json: %w", err)
 	}
 
 	var pkg struct {
@@ -120,13 +152,11 @@ func Remove(pluginName string) {
 	}
 
 	if err := json.Unmarshal(data, &pkg); err != nil {
-		color.Red("Error parsing package.json: %v", err)
-		return
+		return fmt.Errorf("parsing package.json: %w", err)
 	}
 
 	if pkg.Dependencies == nil || len(pkg.Dependencies) == 0 {
-		color.Red("No devops plugins are installed.")
-		return
+		return fmt.Errorf("no devops plugins are installed")
 	}
 
 	// If no plugin name provided, show interactive selection
@@ -135,24 +165,23 @@ func Remove(pluginName string) {
 		for name := range pkg.Dependencies {
 			options = append(options, name)
 		}
+		sort.Strings(options)
 
 		var selected string
-		prompt := &survey.Select{
+		question := &survey.Select{
 			Message: "Please select devops plugin to remove:",
 			Options: options,
 		}
 
-		if err := survey.AskOne(prompt, &selected); err != nil {
-			color.Red("Error: %v", err)
-			return
+		if err := prompt.Ask(question, &selected, "<pluginName>"); err != nil {
+			return err
 		}
 
 		pluginName = selected
 	} else {
 		// Check if the specified plugin is installed
 		if _, ok := pkg.Dependencies[pluginName]; !ok {
-			color.Red("Plugin %s is not installed.", pluginName)
-			return
+			return fmt.Errorf("plugin %s is not installed", pluginName)
 		}
 	}
 
@@ -164,158 +193,209 @@ func Remove(pluginName string) {
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
-		color.Red("Error removing plugin: %v", err)
-		return
+		return exitcode.New(exitcode.ToolFailure, "removing plugin: %v", err)
 	}
 
 	color.Green("Successfully removed %s", pluginName)
+	output.Set(changeResult(gsDevopsPluginsDir, []string{pluginName}))
+	return nil
 }
 
-// Update updates a devops plugin
-func Update() {
-	gsDevopsPluginsDir := filepath.Join(utils.UserHomeDir(), ".godspeed", "devops-plugins")
-
-	// Check if plugins directory exists
-	if !utils.DirExists(gsDevopsPluginsDir) {
-		color.Red("Devops plugins directory not found.")
-		return
-	}
-
-	// Check if package.json exists
-	packageJsonPath := filepath.Join(gsDevopsPluginsDir, "package.json")
-	if !utils.FileExists(packageJsonPath) {
-		color.Red("No devops plugins are installed.")
-		return
-	}
-
-	// Read package.json to get installed plugins
-	data, err := ioutil.ReadFile(packageJsonPath)
+// Update updates devops plugins to their latest versions: every installed
+// plugin not pinned to an exact version with all, the named plugins (package
+// or command names), or one selected interactively. With project the
+// project's devops plugins are updated.
+func Update(names []string, all, project bool) error {
+	gsDevopsPluginsDir, err := scopeDir(project)
 	if err != nil {
-		color.Red("Error reading package.json: %v", err)
-		return
+		return err
 	}
 
-	var pkg struct {
-		Dependencies map[string]string `json:"dependencies"`
-	}
-
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		color.Red("Error parsing package.json: %v", err)
-		return
+	packages, err := installedPackages(gsDevopsPluginsDir)
+	if err != nil || len(packages) == 0 {
+		return fmt.Errorf("no devops plugins are installed")
 	}
 
-	if pkg.Dependencies == nil || len(pkg.Dependencies) == 0 {
-		color.Red("No devops plugins are installed.")
-		return
-	}
+	var selected []string
+	switch {
+	case all:
+		names := make([]string, 0, len(packages))
+		for name := range packages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if version := packages[name]; semver.IsExact(version) {
+				color.Yellow("Skipping %s, pinned to %s. Name it to update it.", name, version)
+				continue
+			}
+			selected = append(selected, name)
+		}
+		if len(selected) == 0 {
+			color.Green("Every devops plugin is pinned. Nothing to update.")
+			output.Set(changeResult(gsDevopsPluginsDir, nil))
+			return nil
+		}
+	case len(names) > 0:
+		for _, name := range names {
+			command, ok := findCommand(name)
+			if ok && command.Native {
+				return fmt.Errorf("%s is a native executable (%s). Replace the file to update it", name, command.Path)
+			}
+			if ok {
+				name = command.Package
+			}
+			if _, installed := packages[name]; !installed {
+				return fmt.Errorf("plugin %s is not installed", name)
+			}
+			selected = append(selected, name)
+		}
+	default:
+		options := make([]string, 0, len(packages))
+		for name := range packages {
+			options = append(options, name)
+		}
+		sort.Strings(options)
 
-	// Show interactive selection
-	options := make([]string, 0, len(pkg.Dependencies))
-	for name := range pkg.Dependencies {
-		options = append(options, name)
+		var choice string
+		question := &survey.Select{
+			Message: "Please select devops plugin to update:",
+			Options: options,
+		}
+		if err := prompt.Ask(question, &choice, "<pluginName> or --all"); err != nil {
+			return err
+		}
+		selected = []string{choice}
 	}
 
-	var selected string
-	prompt := &survey.Select{
-		Message: "Please select devops plugin to update:",
-		Options: options,
+	// Pinned plugins are pinned again to the version they update to
+	var ranged, pinned []string
+	for _, name := range selected {
+		if semver.IsExact(packages[name]) {
+			pinned = append(pinned, name+"@latest")
+		} else {
+			ranged = append(ranged, name+"@latest")
+		}
 	}
 
-	if err := survey.AskOne(prompt, &selected); err != nil {
-		color.Red("Error: %v", err)
-		return
-	}
+	// Update the plugins
+	color.Yellow("Updating %s...", strings.Join(selected, ", "))
+	for _, group := range []struct {
+		specs []string
+		flags []string
+	}{
+		{ranged, nil},
+		{pinned, []string{"--save-exact"}},
+	} {
+		if len(group.specs) == 0 {
+			continue
+		}
 
-	// Update the plugin
-	color.Yellow("Updating %s...", selected)
-	cmd := exec.Command("npm", "install", fmt.Sprintf("%s@latest", selected))
-	cmd.Dir = gsDevopsPluginsDir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+		args := append([]string{"install"}, group.specs...)
+		cmd := exec.Command("npm", append(args, group.flags...)...)
+		cmd.Dir = gsDevopsPluginsDir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
-		color.Red("Error updating plugin: %v", err)
-		return
+		if err := cmd.Run(); err != nil {
+			return exitcode.New(exitcode.ToolFailure, "updating plugins: %v", err)
+		}
 	}
 
-	color.Green("Successfully updated %s", selected)
+	color.Green("Successfully updated %s", strings.Join(selected, ", "))
+	output.Set(changeResult(gsDevopsPluginsDir, selected))
+	return nil
 }
 
-// List lists available or installed devops plugins
-func List(installed bool) {
-	if installed {
-		listInstalledPlugins()
-	} else {
-		listAvailablePlugins()
+// changeResult returns the JSON result of a command that changed packages
+// of a devops plugins directory, with the versions now installed
+func changeResult(dir string, names []string) ChangeResult {
+	result := ChangeResult{Plugins: []ChangedPlugin{}, Dir: dir}
+	for _, name := range names {
+		changed := ChangedPlugin{Name: name}
+		if command, err := loadCommand(dir, name); err == nil {
+			changed.Version = command.Version
+		}
+		result.Plugins = append(result.Plugins, changed)
 	}
+	return result
 }
 
-// listInstalledPlugins lists installed devops plugins
-func listInstalledPlugins() {
-	gsDevopsPluginsDir := filepath.Join(utils.UserHomeDir(), ".godspeed", "devops-plugins")
-
-	// Check if plugins directory exists
-	if !utils.DirExists(gsDevopsPluginsDir) {
-		color.Red("Devops plugins directory not found.")
-		return
+// List lists available or installed devops plugins. With --output json the
+// result holds their installed and latest versions.
+func List(installed bool) error {
+	if output.JSON() {
+		plugins, err := listing(installed)
+		if err != nil {
+			return err
+		}
+		output.Set(ListResult{Plugins: plugins})
+		return nil
 	}
-
-	// Check if package.json exists
-	packageJsonPath := filepath.Join(gsDevopsPluginsDir, "package.json")
-	if !utils.FileExists(packageJsonPath) {
-		color.Red("No devops-plugin is installed")
-		return
+	if installed {
+		return listInstalledPlugins()
 	}
+	return listAvailablePlugins()
+}
 
-	// Read package.json to get installed plugins
-	data, err := ioutil.ReadFile(packageJsonPath)
-	if err != nil {
-		color.Red("Error reading package.json: %v", err)
-		return
-	}
+// listInstalledPlugins lists installed devops plugins of every scope, both
+// npm packages and native executables, with their origin
+func listInstalledPlugins() error {
+	commands := Discover()
+	plugins := installedPlugins(commands)
 
-	var pkg struct {
-		Dependencies map[string]string `json:"dependencies"`
+	var native []Command
+	for _, command := range commands {
+		if command.Native {
+			native = append(native, command)
+		}
 	}
 
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		color.Red("Error parsing package.json: %v", err)
-		return
+	if len(plugins) == 0 && len(native) == 0 {
+		color.Yellow("There are no devops plugins installed.")
+		return nil
 	}
 
-	if pkg.Dependencies == nil || len(pkg.Dependencies) == 0 {
-		color.Red("There are no devops plugins installed.")
-		return
+	for _, p := range plugins {
+		switch {
+		case p.Shadowed:
+			fmt.Printf("-> %s [%s] (shadowed by the project's version)\n", p.Package, p.Origin)
+		case p.Command == nil:
+			fmt.Printf("-> %s [%s] (not installed properly, reinstall it)\n", p.Package, p.Origin)
+		default:
+			fmt.Printf("-> %s [%s] (godspeed devops-plugin %s) - %s\n", p.Package, p.Origin, p.Command.Name, p.Command.Description)
+		}
 	}
-
-	for name := range pkg.Dependencies {
-		fmt.Printf("-> %s\n", name)
+	for _, command := range native {
+		fmt.Printf("-> %s [%s] (godspeed devops-plugin %s) - %s\n", command.Path, command.Origin, command.Name, command.Description)
 	}
+	return nil
 }
 
 // listAvailablePlugins lists available devops plugins
-func listAvailablePlugins() {
+func listAvailablePlugins() error {
 	plugins, err := searchDevopsPlugins()
 	if err != nil {
-		color.Red("Error searching for devops plugins: %v", err)
-		return
+		return exitcode.New(exitcode.ToolFailure, "searching for devops plugins: %v", err)
 	}
 
 	if len(plugins) == 0 {
-		color.Red("No devops plugins found.")
-		return
+		color.Yellow("No devops plugins found.")
+		return nil
 	}
 
 	fmt.Println("List of available devops plugins:")
 	for _, plugin := range plugins {
 		fmt.Printf("-> %s\n", plugin.Name)
 	}
+	return nil
 }
 
 // searchDevopsPlugins searches for available devops plugins on npm
 func searchDevopsPlugins() ([]DevopsPlugin, error) {
 	cmd := exec.Command("npm", "search", "@godspeedsystems/devops-plugin", "--json")
-	output, err := cmd.Output()
+	out, err := cmd.Output()
 	if err != nil {
 		return nil, err
 	}
@@ -326,7 +406,7 @@ func searchDevopsPlugins() ([]DevopsPlugin, error) {
 		Version     string `json:"version"`
 	}
 
-	if err := json.Unmarshal(output, &searchResults); err != nil {
+	if err := json.Unmarshal(out, &searchResults); err != nil {
 		return nil, err
 	}
 
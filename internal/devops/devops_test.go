@@ -0,0 +1,106 @@
+package devops
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeNPM puts an npm on the PATH that only records its arguments and
+// returns a function listing the recorded calls
+func fakeNPM(t *testing.T) func() []string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$*\" >> \"$NPM_LOG\"\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "npm"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NPM_LOG", log)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return func() []string {
+		data, _ := ioutil.ReadFile(log)
+		calls := []string{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line != "" {
+				calls = append(calls, line)
+			}
+		}
+		return calls
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		all   bool
+		deps  map[string]string
+		calls []string
+	}{
+		{
+			name:  "all skips pinned plugins",
+			all:   true,
+			deps:  map[string]string{"@acme/devops-plugin-a": "^1.0.0", "@acme/devops-plugin-b": "1.2.0", "@acme/devops-plugin-c": "~2.0.0"},
+			calls: []string{"install @acme/devops-plugin-a@latest @acme/devops-plugin-c@latest"},
+		},
+		{
+			name:  "all pinned",
+			all:   true,
+			deps:  map[string]string{"@acme/devops-plugin-b": "1.2.0"},
+			calls: []string{},
+		},
+		{
+			name:  "named pinned plugin",
+			names: []string{"@acme/devops-plugin-b"},
+			deps:  map[string]string{"@acme/devops-plugin-a": "^1.0.0", "@acme/devops-plugin-b": "1.2.0"},
+			calls: []string{"install @acme/devops-plugin-b@latest --save-exact"},
+		},
+		{
+			name:  "named pinned and ranged plugins",
+			names: []string{"@acme/devops-plugin-b", "@acme/devops-plugin-a"},
+			deps:  map[string]string{"@acme/devops-plugin-a": "^1.0.0", "@acme/devops-plugin-b": "1.2.0"},
+			calls: []string{"install @acme/devops-plugin-a@latest", "install @acme/devops-plugin-b@latest --save-exact"},
+		},
+		{
+			name:  "command name",
+			names: []string{"a"},
+			deps:  map[string]string{"@acme/devops-plugin-a": "^1.0.0"},
+			calls: []string{"install @acme/devops-plugin-a@latest"},
+		},
+		{
+			name:  "not installed",
+			names: []string{"@acme/devops-plugin-z"},
+			deps:  map[string]string{"@acme/devops-plugin-a": "^1.0.0"},
+			calls: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := testHome(t)
+			names := make([]string, 0, len(tt.deps))
+			for name := range tt.deps {
+				names = append(names, name)
+			}
+			installPackages(t, dir, names...)
+			pkg, _ := json.Marshal(map[string]interface{}{"dependencies": tt.deps})
+			if err := ioutil.WriteFile(filepath.Join(dir, "package.json"), pkg, 0644); err != nil {
+				t.Fatal(err)
+			}
+			inDir(t, t.TempDir())
+			calls := fakeNPM(t)
+
+			Update(tt.names, tt.all, false)
+
+			if got := calls(); !reflect.DeepEqual(got, tt.calls) {
+				t.Errorf("npm calls = %q, want %q", got, tt.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package devops
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// commandPrefixes are stripped from package names to form command names
+var commandPrefixes = []string{"godspeed-devops-", "devops-plugin-", "devops-plugins-", "plugins-"}
+
+// builtinCommands are the devops-plugin subcommands of the CLI itself
+var builtinCommands = []string{"install", "remove", "list", "update", "info", "help"}
+
+// Command is an installed devops plugin exposed as a devops-plugin subcommand.
+// Node plugins run their Entry script with node, native plugins are the
+// executable at Path. Origin tells which scope it comes from and Dir is the
+// plugins directory of a node plugin.
+type Command struct {
+	Name        string
+	Package     string
+	Version     string
+	Description string
+	Entry       string
+	Native      bool
+	Path        string
+	Describe    Description
+	Origin      string
+	Dir         string
+}
+
+// manifest holds the fields of a devops plugin's package.json the CLI reads
+type manifest struct {
+	Name        string          `json:"name"`
+	Version     string          `json:"version"`
+	Description string          `json:"description"`
+	Main        string          `json:"main"`
+	Bin         json.RawMessage `json:"bin"`
+}
+
+// PluginsDir returns the directory devops plugins are installed in
+func PluginsDir() string {
+	return filepath.Join(utils.GetGodspeedDir(), "devops-plugins")
+}
+
+// installedPackages returns the dependencies of the devops plugins package.json
+func installedPackages(dir string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	return pkg.Dependencies, nil
+}
+
+// Discover returns the installed devops plugins: the dependencies of the
+// devops plugins package.json of the project, then of the global one, then
+// the native godspeed-devops-<name> executables. A package installed in the
+// project shadows the same package installed globally. Package command names
+// that would clash with builtin commands or with each other fall back to the
+// unscoped package name, then to the package name with its scope, e.g.
+// "acme-deploy". Native executables whose name is taken are left out.
+func Discover() []Command {
+	taken := make(map[string]bool)
+	for _, name := range builtinCommands {
+		taken[name] = true
+	}
+
+	var commands []Command
+	installed := make(map[string]bool)
+	for _, s := range scopes() {
+		commands = append(commands, discoverPackages(s, taken, installed)...)
+	}
+	for _, command := range discoverNative() {
+		if taken[command.Name] {
+			continue
+		}
+		taken[command.Name] = true
+		command.Describe = describe(command.Path)
+		command.Description = command.Describe.Short
+		if command.Description == "" {
+			command.Description = fmt.Sprintf("Godspeed devops plugin %s", filepath.Base(command.Path))
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// discoverPackages returns the devops plugins installed as npm packages in a
+// scope, skipping packages already installed in a preceding scope and
+// marking their command names as taken
+func discoverPackages(s scope, taken, installed map[string]bool) []Command {
+	packages, err := installedPackages(s.Dir)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var commands []Command
+	for _, name := range names {
+		if installed[name] {
+			continue
+		}
+		command, err := loadCommand(s.Dir, name)
+		if err != nil {
+			continue
+		}
+		command.Origin = s.Origin
+		command.Dir = s.Dir
+		installed[name] = true
+
+		for _, candidate := range []string{unscoped(name), strings.ReplaceAll(strings.TrimPrefix(name, "@"), "/", "-")} {
+			if !taken[command.Name] {
+				break
+			}
+			command.Name = candidate
+		}
+		if taken[command.Name] {
+			continue
+		}
+		taken[command.Name] = true
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// loadCommand reads the manifest of an installed devops plugin package
+func loadCommand(dir, packageName string) (Command, error) {
+	packageDir := filepath.Join(dir, "node_modules", filepath.FromSlash(packageName))
+	data, err := ioutil.ReadFile(filepath.Join(packageDir, "package.json"))
+	if err != nil {
+		return Command{}, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Command{}, err
+	}
+
+	command := Command{
+		Name:        CommandName(packageName),
+		Package:     packageName,
+		Version:     m.Version,
+		Description: m.Description,
+		Entry:       filepath.Join(packageDir, entryPoint(m, packageName)),
+	}
+	if command.Description == "" {
+		command.Description = fmt.Sprintf("Godspeed devops plugin %s", packageName)
+	}
+	return command, nil
+}
+
+// entryPoint returns the script of a package to run: its bin, preferring the
+// one named after the command, then its main, then dist/index.js
+func entryPoint(m manifest, packageName string) string {
+	if len(m.Bin) > 0 {
+		var bin string
+		if json.Unmarshal(m.Bin, &bin) == nil && bin != "" {
+			return bin
+		}
+
+		var bins map[string]string
+		if json.Unmarshal(m.Bin, &bins) == nil && len(bins) > 0 {
+			for _, name := range []string{CommandName(packageName), unscoped(packageName)} {
+				if path, ok := bins[name]; ok {
+					return path
+				}
+			}
+			keys := make([]string, 0, len(bins))
+			for key := range bins {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return bins[keys[0]]
+		}
+	}
+	if m.Main != "" {
+		return m.Main
+	}
+	return filepath.Join("dist", "index.js")
+}
+
+// CommandName maps a package name to a command name, e.g.
+// "@godspeedsystems/devops-plugin-deployer" to "deployer"
+func CommandName(packageName string) string {
+	name := unscoped(packageName)
+	for _, prefix := range commandPrefixes {
+		if trimmed := strings.TrimPrefix(name, prefix); trimmed != name && trimmed != "" {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// unscoped returns a package name without its npm scope
+func unscoped(packageName string) string {
+	return packageName[strings.LastIndex(packageName, "/")+1:]
+}
+
+// Run runs the devops plugin with the given arguments, passing the project
+// context and CLI version through the environment. The returned error
+// carries the plugin's exit code.
+func (c Command) Run(args []string, cliVersion string) error {
+	var cmd *exec.Cmd
+	if c.Native {
+		cmd = exec.Command(c.Path, args...)
+	} else {
+		if !utils.FileExists(c.Entry) {
+			return fmt.Errorf("%s is not installed properly. Please make sure %s exists", c.Package, c.Entry)
+		}
+		cmd = exec.Command("node", append([]string{c.Entry}, args...)...)
+	}
+
+	cmd.Env = pluginEnv(c.Name, cliVersion)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	return utils.CommandError(c.Name, cmd.Run())
+}
@@ -0,0 +1,236 @@
+package devops
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		packageName string
+		want        string
+	}{
+		{"@godspeedsystems/devops-plugin-deployer", "deployer"},
+		{"@acme/godspeed-devops-lint", "lint"},
+		{"devops-plugins-backup", "backup"},
+		{"@acme/plugins-k8s", "k8s"},
+		{"deploy-tool", "deploy-tool"},
+		{"@acme/devops-plugin-", "devops-plugin-"},
+	}
+
+	for _, tt := range tests {
+		if got := CommandName(tt.packageName); got != tt.want {
+			t.Errorf("CommandName(%q) = %q, want %q", tt.packageName, got, tt.want)
+		}
+	}
+}
+
+func TestEntryPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		m    manifest
+		want string
+	}{
+		{"bin string", manifest{Bin: json.RawMessage(`"cli.js"`), Main: "index.js"}, "cli.js"},
+		{"bin named after the command", manifest{Bin: json.RawMessage(`{"aaa": "a.js", "deployer": "deployer.js"}`)}, "deployer.js"},
+		{"bin named after the package", manifest{Bin: json.RawMessage(`{"aaa": "a.js", "devops-plugin-deployer": "pkg.js"}`)}, "pkg.js"},
+		{"first bin by name", manifest{Bin: json.RawMessage(`{"zzz": "z.js", "aaa": "a.js"}`)}, "a.js"},
+		{"main", manifest{Main: "lib/main.js"}, "lib/main.js"},
+		{"empty bin falls back to main", manifest{Bin: json.RawMessage(`""`), Main: "lib/main.js"}, "lib/main.js"},
+		{"default", manifest{}, filepath.Join("dist", "index.js")},
+	}
+
+	for _, tt := range tests {
+		if got := entryPoint(tt.m, "@godspeedsystems/devops-plugin-deployer"); got != tt.want {
+			t.Errorf("%s: entryPoint = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// installPackages writes a devops plugins directory with the given packages
+// installed, each with a main script
+func installPackages(t *testing.T, dir string, packages ...string) {
+	t.Helper()
+	dependencies := make(map[string]string)
+	for _, name := range packages {
+		dependencies[name] = "^1.0.0"
+		packageDir := filepath.Join(dir, "node_modules", filepath.FromSlash(name))
+		if err := os.MkdirAll(packageDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		pkg, _ := json.Marshal(manifest{Name: name, Version: "1.0.0", Main: "index.js"})
+		if err := ioutil.WriteFile(filepath.Join(packageDir, "package.json"), pkg, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkg, _ := json.Marshal(map[string]interface{}{"dependencies": dependencies})
+	if err := ioutil.WriteFile(filepath.Join(dir, "package.json"), pkg, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// installNative writes native plugin executables named
+// godspeed-devops-<name> into dir
+func installNative(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		script := "#!/bin/sh\necho '{\"short\": \"Native " + name + "\"}'\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, NativePrefix+name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testHome points the home directory, and with it the global devops plugins
+// directory, at a temporary directory and empties PATH. It returns the
+// global devops plugins directory.
+func testHome(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("native plugins are shell scripts")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", "")
+	dir := PluginsDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// inDir makes dir the working directory for the rest of the test
+func inDir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// commandSummaries describes commands as "name package origin", with the
+// file name in place of the package of native commands
+func commandSummaries(commands []Command) []string {
+	summaries := []string{}
+	for _, command := range commands {
+		source := command.Package
+		if command.Native {
+			source = filepath.Base(command.Path)
+		}
+		summaries = append(summaries, command.Name+" "+source+" "+command.Origin)
+	}
+	return summaries
+}
+
+func TestDiscover(t *testing.T) {
+	global := testHome(t)
+	installPackages(t, global,
+		"@acme/devops-plugin-deploy",
+		"@other/devops-plugin-deploy",
+		"@third/devops-plugin-deploy",
+		"@acme/devops-plugin-list",
+		"@acme/devops-plugin-backup",
+	)
+	installNative(t, filepath.Join(global, "bin"), "deploy", "lint")
+	pathDir := t.TempDir()
+	installNative(t, pathDir, "lint", "scan")
+	t.Setenv("PATH", pathDir)
+
+	project := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(project, ".godspeed"), []byte(`{"devopsPlugins": "devops"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	local := filepath.Join(project, "devops")
+	if err := os.MkdirAll(local, 0755); err != nil {
+		t.Fatal(err)
+	}
+	installPackages(t, local, "@acme/devops-plugin-backup", "@acme/devops-plugin-migrate")
+	installNative(t, filepath.Join(local, "bin"), "scan")
+	src := filepath.Join(project, "src")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want []string
+	}{
+		{
+			name: "outside a project",
+			dir:  t.TempDir(),
+			want: []string{
+				"backup @acme/devops-plugin-backup global",
+				"deploy @acme/devops-plugin-deploy global",
+				"devops-plugin-list @acme/devops-plugin-list global",
+				"devops-plugin-deploy @other/devops-plugin-deploy global",
+				"third-devops-plugin-deploy @third/devops-plugin-deploy global",
+				"lint godspeed-devops-lint global",
+				"scan godspeed-devops-scan PATH",
+			},
+		},
+		{
+			name: "inside a project",
+			dir:  src,
+			want: []string{
+				"backup @acme/devops-plugin-backup project",
+				"migrate @acme/devops-plugin-migrate project",
+				"deploy @acme/devops-plugin-deploy global",
+				"devops-plugin-list @acme/devops-plugin-list global",
+				"devops-plugin-deploy @other/devops-plugin-deploy global",
+				"third-devops-plugin-deploy @third/devops-plugin-deploy global",
+				"lint godspeed-devops-lint global",
+				"scan godspeed-devops-scan project",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inDir(t, tt.dir)
+			commands := Discover()
+			if got := commandSummaries(commands); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Discover = %q, want %q", got, tt.want)
+			}
+			for _, command := range commands {
+				if command.Native && command.Description != "Native "+command.Name {
+					t.Errorf("description of %s = %q, want the one it describes itself with", command.Name, command.Description)
+				}
+			}
+		})
+	}
+}
+
+func TestNativeCommandName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("native plugins need an executable extension on Windows")
+	}
+	tests := []struct {
+		fileName string
+		want     string
+	}{
+		{"godspeed-devops-deploy", "deploy"},
+		{"godspeed-devops-", ""},
+		{"godspeed-deploy", ""},
+		{"deploy", ""},
+	}
+
+	for _, tt := range tests {
+		if got := nativeCommandName(tt.fileName); got != tt.want {
+			t.Errorf("nativeCommandName(%q) = %q, want %q", tt.fileName, got, tt.want)
+		}
+	}
+}
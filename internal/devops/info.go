@@ -0,0 +1,215 @@
+package devops
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+)
+
+// registryTimeout bounds each registry lookup of a latest version
+const registryTimeout = 30 * time.Second
+
+// ListedPlugin is a devops plugin in the JSON result of list and info.
+// Latest is empty when the registry can't be reached or the plugin is a
+// native executable.
+type ListedPlugin struct {
+	Name        string `json:"name"`
+	Command     string `json:"command,omitempty"`
+	Description string `json:"description"`
+	Installed   string `json:"installed,omitempty"`
+	Latest      string `json:"latest,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Native      bool   `json:"native"`
+	Origin      string `json:"origin,omitempty"`
+}
+
+// ListResult is the JSON result of devops-plugin list
+type ListResult struct {
+	Plugins []ListedPlugin `json:"plugins"`
+}
+
+// Info prints the details of an installed devops plugin, given by its
+// package or command name
+func Info(name string) error {
+	command, ok := findCommand(name)
+	if !ok {
+		return fmt.Errorf("plugin %s is not installed", name)
+	}
+
+	path := command.Entry
+	label := command.Package
+	if command.Native {
+		path = command.Path
+		label = filepath.Base(command.Path)
+	} else if command.Version != "" {
+		label += "@" + command.Version
+	}
+
+	latest := ""
+	if !command.Native {
+		latest = latestVersion(command.Package)
+	}
+	output.Set(ListedPlugin{
+		Name:        valueOr(command.Package, filepath.Base(command.Path)),
+		Command:     command.Name,
+		Description: command.Description,
+		Installed:   command.Version,
+		Latest:      latest,
+		Path:        path,
+		Native:      command.Native,
+		Origin:      command.Origin,
+	})
+
+	color.Cyan("%s", label)
+	if command.Description != "" {
+		fmt.Println(command.Description)
+	}
+	fmt.Println()
+	fmt.Printf("Command:      godspeed devops-plugin %s\n", command.Name)
+	fmt.Printf("Origin:       %s\n", command.Origin)
+	if command.Native {
+		fmt.Println("Kind:         native executable")
+	} else {
+		fmt.Println("Kind:         npm package")
+		fmt.Printf("Installed:    %s\n", valueOr(command.Version, "unknown"))
+		fmt.Printf("Latest:       %s\n", valueOr(latest, "unknown (registry unreachable)"))
+	}
+	fmt.Printf("Path:         %s\n", path)
+	if updated := lastUpdate(command); !updated.IsZero() {
+		fmt.Printf("Last updated: %s\n", updated.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// findCommand returns the installed devops plugin with a package or command name
+func findCommand(name string) (Command, bool) {
+	for _, command := range Discover() {
+		if command.Package == name || command.Name == name {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
+
+// lastUpdate returns when a devops plugin was installed or last updated
+func lastUpdate(command Command) time.Time {
+	path := command.Path
+	if !command.Native {
+		path = filepath.Join(command.Dir, "node_modules", filepath.FromSlash(command.Package), "package.json")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// listing returns the installed or available devops plugins with their
+// installed and latest versions
+func listing(installed bool) ([]ListedPlugin, error) {
+	var plugins []ListedPlugin
+	if installed {
+		plugins = installedListing()
+	} else {
+		available, err := searchDevopsPlugins()
+		if err != nil {
+			return nil, exitcode.New(exitcode.ToolFailure, "searching for devops plugins: %v", err)
+		}
+
+		commands := make(map[string]Command)
+		for _, command := range Discover() {
+			commands[command.Package] = command
+		}
+		for _, p := range available {
+			listed := ListedPlugin{Name: p.Name, Description: p.Description, Latest: p.Version}
+			if command, ok := commands[p.Name]; ok {
+				listed.Command = command.Name
+				listed.Installed = command.Version
+				listed.Path = command.Entry
+				listed.Origin = command.Origin
+			}
+			plugins = append(plugins, listed)
+		}
+	}
+
+	if plugins == nil {
+		plugins = []ListedPlugin{}
+	}
+	return plugins, nil
+}
+
+// installedListing returns the installed devops plugins with their latest
+// versions, looked up concurrently. Shadowed packages are left out.
+func installedListing() []ListedPlugin {
+	commands := Discover()
+
+	var plugins []ListedPlugin
+	for _, p := range installedPlugins(commands) {
+		if p.Shadowed {
+			continue
+		}
+		listed := ListedPlugin{Name: p.Package, Origin: p.Origin}
+		if p.Command != nil {
+			listed.Command = p.Command.Name
+			listed.Description = p.Command.Description
+			listed.Installed = p.Command.Version
+			listed.Path = p.Command.Entry
+		}
+		plugins = append(plugins, listed)
+	}
+
+	var wg sync.WaitGroup
+	for i := range plugins {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			plugins[i].Latest = latestVersion(plugins[i].Name)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, command := range commands {
+		if !command.Native {
+			continue
+		}
+		plugins = append(plugins, ListedPlugin{
+			Name:        filepath.Base(command.Path),
+			Command:     command.Name,
+			Description: command.Description,
+			Path:        command.Path,
+			Native:      true,
+			Origin:      command.Origin,
+		})
+	}
+	return plugins
+}
+
+// latestVersion returns the latest published version of a package, or "" if
+// the registry can't be reached
+func latestVersion(name string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "npm", "view", name, "version", "--fetch-retries=1").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// valueOr returns value, or fallback if value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,176 @@
+package devops
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// NativePrefix is the file name prefix of native devops plugin executables
+const NativePrefix = "godspeed-devops-"
+
+// DescribeFlag is passed to a native devops plugin to ask for its Description
+const DescribeFlag = "--godspeed-describe"
+
+// describeTimeout bounds how long a native plugin may take to describe itself
+const describeTimeout = 2 * time.Second
+
+// Environment variables passed to every devops plugin
+const (
+	EnvProjectRoot   = "GODSPEED_PROJECT_ROOT"
+	EnvProjectConfig = "GODSPEED_PROJECT_CONFIG"
+	EnvCLIVersion    = "GODSPEED_CLI_VERSION"
+	EnvGodspeedDir   = "GODSPEED_DIR"
+	EnvPluginName    = "GODSPEED_PLUGIN_NAME"
+)
+
+// Description is what a native devops plugin prints as JSON when run with
+// --godspeed-describe
+type Description struct {
+	Short       string   `json:"short"`
+	Long        string   `json:"long"`
+	Usage       string   `json:"usage"`
+	Completions []string `json:"completions"`
+}
+
+// describeCacheEntry is a Description cached for an executable, valid as long
+// as the executable is unchanged
+type describeCacheEntry struct {
+	Size        int64       `json:"size"`
+	ModTime     int64       `json:"modTime"`
+	Description Description `json:"description"`
+}
+
+// discoverNative returns the native devops plugin executables of the bin
+// directories of the scopes and of PATH. The first executable found for a
+// name wins.
+func discoverNative() []Command {
+	var dirs []scope
+	for _, s := range scopes() {
+		dirs = append(dirs, scope{Origin: s.Origin, Dir: filepath.Join(s.Dir, "bin")})
+	}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		dirs = append(dirs, scope{Origin: OriginPath, Dir: dir})
+	}
+
+	seen := make(map[string]bool)
+	var commands []Command
+	for _, dir := range dirs {
+		if dir.Dir == "" {
+			continue
+		}
+		entries, err := ioutil.ReadDir(dir.Dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := nativeCommandName(entry.Name())
+			if name == "" || seen[name] || entry.IsDir() || !isExecutable(entry) {
+				continue
+			}
+			seen[name] = true
+			commands = append(commands, Command{
+				Name:   name,
+				Path:   filepath.Join(dir.Dir, entry.Name()),
+				Native: true,
+				Origin: dir.Origin,
+			})
+		}
+	}
+
+	sort.SliceStable(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// nativeCommandName returns the command name of a native plugin file, e.g.
+// "deploy" for "godspeed-devops-deploy", or "" if the file isn't one
+func nativeCommandName(fileName string) string {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(fileName))
+		if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+			return ""
+		}
+		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+	if !strings.HasPrefix(fileName, NativePrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(fileName, NativePrefix)
+}
+
+// isExecutable reports whether a file can be executed. On Windows the
+// extension decides, which nativeCommandName already checked.
+func isExecutable(info os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return info.Mode().Perm()&0111 != 0
+}
+
+// describe asks a native plugin for its Description, using the cached one
+// while the executable is unchanged. Plugins that don't answer the handshake
+// get an empty Description.
+func describe(path string) Description {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Description{}
+	}
+
+	cacheFile := filepath.Join(utils.GetGodspeedDir(), "cache", "devops-plugins", cacheKey(path)+".json")
+	if data, err := ioutil.ReadFile(cacheFile); err == nil {
+		var entry describeCacheEntry
+		if json.Unmarshal(data, &entry) == nil && entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+			return entry.Description
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+	defer cancel()
+
+	var description Description
+	out, err := exec.CommandContext(ctx, path, DescribeFlag).Output()
+	if err == nil {
+		json.Unmarshal(out, &description)
+	}
+
+	entry := describeCacheEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Description: description}
+	if data, err := json.Marshal(entry); err == nil && utils.CreateDir(filepath.Dir(cacheFile)) == nil {
+		ioutil.WriteFile(cacheFile, data, 0644)
+	}
+	return description
+}
+
+// cacheKey returns the file name a path is cached under
+func cacheKey(path string) string {
+	sum := sha1.Sum([]byte(path))
+	return hex.EncodeToString(sum[:])
+}
+
+// pluginEnv returns the environment a devops plugin runs with
+func pluginEnv(name, cliVersion string) []string {
+	env := append(os.Environ(),
+		fmt.Sprintf("%s=%s", EnvCLIVersion, cliVersion),
+		fmt.Sprintf("%s=%s", EnvGodspeedDir, utils.GetGodspeedDir()),
+		fmt.Sprintf("%s=%s", EnvPluginName, name),
+	)
+
+	if root := projectRoot(); root != "" {
+		env = append(env, fmt.Sprintf("%s=%s", EnvProjectRoot, root))
+		if data, err := ioutil.ReadFile(filepath.Join(root, ".godspeed")); err == nil {
+			env = append(env, fmt.Sprintf("%s=%s", EnvProjectConfig, strings.TrimSpace(string(data))))
+		}
+	}
+	return env
+}
@@ -0,0 +1,145 @@
+package devops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/config"
+)
+
+// Origins of devops plugins, from highest to lowest precedence
+const (
+	OriginProject = "project"
+	OriginGlobal  = "global"
+	OriginPath    = "PATH"
+)
+
+// scope is a directory devops plugins are installed in
+type scope struct {
+	Origin string
+	Dir    string
+}
+
+// scopes returns the devops plugin directories in order of precedence: the
+// one declared by the enclosing project, if any, then the global one
+func scopes() []scope {
+	var result []scope
+	if dir := ProjectPluginsDir(); dir != "" {
+		result = append(result, scope{Origin: OriginProject, Dir: dir})
+	}
+	return append(result, scope{Origin: OriginGlobal, Dir: PluginsDir()})
+}
+
+// ProjectPluginsDir returns the devops plugins directory declared in the
+// .godspeed file of the enclosing project, or "" if there is none
+func ProjectPluginsDir() string {
+	root := projectRoot()
+	if root == "" {
+		return ""
+	}
+
+	dir := config.DevopsPluginsDir(filepath.Join(root, ".godspeed"))
+	if dir == "" {
+		return ""
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return dir
+}
+
+// scopeDir returns the project's devops plugins directory with project,
+// otherwise the global one
+func scopeDir(project bool) (string, error) {
+	if !project {
+		return PluginsDir(), nil
+	}
+	if projectRoot() == "" {
+		return "", fmt.Errorf("the current directory is not inside a godspeed project")
+	}
+	dir := ProjectPluginsDir()
+	if dir == "" {
+		return "", fmt.Errorf("the project declares no devops plugins directory. Install one with godspeed devops-plugin install --project")
+	}
+	return dir, nil
+}
+
+// declareProjectPluginsDir returns the project's devops plugins directory,
+// declaring the default one in .godspeed if the project has none
+func declareProjectPluginsDir() (string, error) {
+	root := projectRoot()
+	if root == "" {
+		return "", fmt.Errorf("the current directory is not inside a godspeed project")
+	}
+	if dir := ProjectPluginsDir(); dir != "" {
+		return dir, nil
+	}
+
+	if err := config.DeclareDevopsPluginsDir(filepath.Join(root, ".godspeed"), config.DefaultDevopsPluginsDir); err != nil {
+		return "", fmt.Errorf("declaring %s in .godspeed: %v", config.DefaultDevopsPluginsDir, err)
+	}
+	return filepath.Join(root, config.DefaultDevopsPluginsDir), nil
+}
+
+// projectRoot returns the nearest directory holding a .godspeed project
+// file, starting at the current directory, or "" outside a project
+func projectRoot() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".godspeed")); err == nil && !info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// installedPlugin is a package declared in the package.json of a scope.
+// Command is unset when the package isn't installed properly or is shadowed
+// by the same package in a preceding scope.
+type installedPlugin struct {
+	Package  string
+	Origin   string
+	Command  *Command
+	Shadowed bool
+}
+
+// installedPlugins returns the packages of every scope, in order of
+// precedence and by name within a scope
+func installedPlugins(commands []Command) []installedPlugin {
+	byPackage := make(map[string]Command)
+	for _, command := range commands {
+		if !command.Native {
+			byPackage[command.Origin+" "+command.Package] = command
+		}
+	}
+
+	var plugins []installedPlugin
+	seen := make(map[string]bool)
+	for _, s := range scopes() {
+		packages, _ := installedPackages(s.Dir)
+		names := make([]string, 0, len(packages))
+		for name := range packages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			plugin := installedPlugin{Package: name, Origin: s.Origin, Shadowed: seen[name]}
+			if command, ok := byPackage[s.Origin+" "+name]; ok {
+				plugin.Command = &command
+			}
+			seen[name] = true
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
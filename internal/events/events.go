@@ -0,0 +1,346 @@
+package events
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Event represents a single event definition from src/events
+type Event struct {
+	Key         string                   `yaml:"-"`
+	File        string                   `yaml:"-"`
+	Fn          string                   `yaml:"fn,omitempty"`
+	ID          string                   `yaml:"id,omitempty"`
+	Summary     string                   `yaml:"summary,omitempty"`
+	Description string                   `yaml:"description,omitempty"`
+	Body        map[string]interface{}   `yaml:"body,omitempty"`
+	Parameters  []map[string]interface{} `yaml:"parameters,omitempty"`
+	Params      []map[string]interface{} `yaml:"params,omitempty"`
+	Data        map[string]interface{}   `yaml:"data,omitempty"`
+	Responses   map[string]interface{}   `yaml:"responses,omitempty"`
+}
+
+// Load loads all events from the yaml files in dirPath and its subdirectories.
+// Event files hold one or more events keyed by "<eventsource>.<...>".
+func Load(dirPath string) (map[string]Event, error) {
+	result := make(map[string]Event)
+
+	if _, err := os.Stat(dirPath); err != nil {
+		return nil, err
+	}
+
+	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !isYamlFile(path) {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var fileEvents map[string]Event
+		if err := yaml.Unmarshal(data, &fileEvents); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		for key, event := range fileEvents {
+			if existing, ok := result[key]; ok {
+				return fmt.Errorf("event %s is defined in both %s and %s", key, existing.File, path)
+			}
+
+			event.Key = key
+			event.File = path
+			event.normalize()
+			result[key] = event
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// LoadEventSource reads the config of an eventsource from src/eventsources
+func LoadEventSource(name string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(filepath.Join("src", "eventsources", name+".yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+	return schema.Normalize(config).(map[string]interface{}), nil
+}
+
+// EventSourceNames returns the names of the eventsources configured in
+// src/eventsources, in a stable order
+func EventSourceNames() ([]string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join("src", "eventsources"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isYamlFile(entry.Name()) {
+			names = append(names, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// BasePath returns the normalized base path ("/api") an http eventsource
+// serves its events under, or "" if none is configured
+func BasePath(esConfig map[string]interface{}) string {
+	basePath := ""
+	for _, key := range []string{"base_url", "basePath", "base_path"} {
+		if value, ok := esConfig[key].(string); ok {
+			basePath = value
+			break
+		}
+	}
+
+	basePath = strings.TrimRight(basePath, "/")
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return basePath
+}
+
+// IsGraphQL reports whether an eventsource serves graphql rather than plain http
+func IsGraphQL(esConfig map[string]interface{}) bool {
+	typ, _ := esConfig["type"].(string)
+	typ = strings.ToLower(typ)
+	return strings.Contains(typ, "graphql") || strings.Contains(typ, "apollo")
+}
+
+// SortedKeys returns the event keys in a stable order
+func SortedKeys(events map[string]Event) []string {
+	keys := make([]string, 0, len(events))
+	for key := range events {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalize converts nested yaml maps so that they can be encoded as JSON
+func (e *Event) normalize() {
+	if e.Body != nil {
+		e.Body = schema.Normalize(e.Body).(map[string]interface{})
+	}
+	if e.Data != nil {
+		e.Data = schema.Normalize(e.Data).(map[string]interface{})
+	}
+	if e.Responses != nil {
+		e.Responses = schema.Normalize(e.Responses).(map[string]interface{})
+	}
+	for i, param := range e.Parameters {
+		e.Parameters[i] = schema.Normalize(param).(map[string]interface{})
+	}
+	for i, param := range e.Params {
+		e.Params[i] = schema.Normalize(param).(map[string]interface{})
+	}
+}
+
+// Sources returns the eventsources an event is bound to. A key such as
+// "http & graphql.get./users" binds the event to both http and graphql.
+func (e Event) Sources() []string {
+	parts := strings.SplitN(e.Key, ".", 2)
+	var sources []string
+	for _, source := range strings.Split(parts[0], "&") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// HasSource reports whether the event is bound to the given eventsource
+func (e Event) HasSource(eventSource string) bool {
+	for _, source := range e.Sources() {
+		if source == eventSource {
+			return true
+		}
+	}
+	return false
+}
+
+// Method returns the http method of an http style event ("http.get./users")
+func (e Event) Method() string {
+	parts := strings.SplitN(e.Key, ".", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return strings.ToLower(parts[1])
+}
+
+// Path returns the route of an http style event ("http.get./users/:id")
+func (e Event) Path() string {
+	parts := strings.SplitN(e.Key, ".", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+// httpMethods are the methods allowed in http style event keys
+var httpMethods = map[string]bool{
+	"get": true, "post": true, "put": true, "patch": true,
+	"delete": true, "head": true, "options": true,
+}
+
+// IsHTTP reports whether the event key has the http style
+// "<eventsource>.<method>./<path>" form
+func (e Event) IsHTTP() bool {
+	return httpMethods[e.Method()] && strings.HasPrefix(e.Path(), "/")
+}
+
+// RequestParams returns the declared parameters, preferring the OpenAPI
+// style "parameters" over Godspeed's "params" and the legacy data.schema
+func (e Event) RequestParams() []map[string]interface{} {
+	if len(e.Parameters) > 0 {
+		return e.Parameters
+	}
+	if len(e.Params) > 0 {
+		return e.Params
+	}
+	if schemaMap, ok := e.Data["schema"].(map[string]interface{}); ok {
+		if params, ok := schemaMap["params"].([]interface{}); ok {
+			var result []map[string]interface{}
+			for _, param := range params {
+				if paramMap, ok := param.(map[string]interface{}); ok {
+					result = append(result, paramMap)
+				}
+			}
+			return result
+		}
+	}
+	return nil
+}
+
+// RequestBody returns the declared request body, falling back to the legacy
+// data.schema.body location
+func (e Event) RequestBody() map[string]interface{} {
+	if e.Body != nil {
+		return e.Body
+	}
+	if schemaMap, ok := e.Data["schema"].(map[string]interface{}); ok {
+		if body, ok := schemaMap["body"].(map[string]interface{}); ok {
+			return body
+		}
+	}
+	return nil
+}
+
+// Channel returns the topic and consumer group of a message broker style
+// event ("kafka.<topic>.<group>")
+func (e Event) Channel() (topic, group string) {
+	parts := strings.SplitN(e.Key, ".", 3)
+	if len(parts) > 1 {
+		topic = parts[1]
+	}
+	if len(parts) > 2 {
+		group = parts[2]
+	}
+	return topic, group
+}
+
+// OpenAPIPath converts the express style path params of an http event
+// ("/users/:id") into the OpenAPI style ("/users/{id}")
+func (e Event) OpenAPIPath() string {
+	segments := strings.Split(e.Path(), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimPrefix(segment, ":") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// TypeName returns a stable identifier derived from the event key, e.g.
+// "http.get./users/:id" becomes "HttpGetUsersById"
+func (e Event) TypeName() string {
+	var name strings.Builder
+	for _, word := range strings.FieldsFunc(e.Key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ':'
+	}) {
+		if strings.HasPrefix(word, ":") {
+			name.WriteString("By")
+			word = strings.TrimLeft(word, ":")
+		}
+		for _, part := range strings.Split(word, ":") {
+			if part == "" {
+				continue
+			}
+			name.WriteString(capitalize(part))
+		}
+	}
+	return name.String()
+}
+
+// capitalize upper-cases the first letter of a word
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// ContentSchema returns the schema of a request body or response, preferring
+// application/json content over other media types
+func ContentSchema(s map[string]interface{}) interface{} {
+	content, ok := s["content"].(map[string]interface{})
+	if !ok {
+		return s["schema"]
+	}
+
+	if media, ok := content["application/json"].(map[string]interface{}); ok {
+		return media["schema"]
+	}
+
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	for _, mediaType := range mediaTypes {
+		if media, ok := content[mediaType].(map[string]interface{}); ok {
+			return media["schema"]
+		}
+	}
+
+	return nil
+}
+
+// isYamlFile checks whether the path has a yaml extension
+func isYamlFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
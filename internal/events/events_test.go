@@ -0,0 +1,24 @@
+package events
+
+import "testing"
+
+func TestTypeName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"http.get./users", "HttpGetUsers"},
+		{"http.get./users/:id", "HttpGetUsersById"},
+		{"http.post./users/:id/avatar", "HttpPostUsersByIdAvatar"},
+		{"http.get./user-list", "HttpGetUserList"},
+		{"kafka.orders.created", "KafkaOrdersCreated"},
+		{"http.get./éclairs/:ïd", "HttpGetÉclairsByÏd"},
+		{"http.get./日本", "HttpGet日本"},
+	}
+
+	for _, tt := range tests {
+		if got := (Event{Key: tt.key}).TypeName(); got != tt.want {
+			t.Errorf("TypeName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
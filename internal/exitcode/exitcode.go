@@ -0,0 +1,64 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes of the CLI. Commands that run another program, e.g. godspeed
+// dev, exit with that program's exit code instead.
+const (
+	Success           = 0
+	Failure           = 1
+	Usage             = 2
+	NotProject        = 3
+	ToolFailure       = 4
+	ValidationFailure = 5
+)
+
+// Error is an error with the exit code the CLI exits with when it fails a
+// command
+type Error struct {
+	Code int
+	Err  error
+}
+
+// Error returns the message of the wrapped error
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with an exit code and a formatted message
+func New(code int, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap gives err an exit code, keeping the code err already has
+func Wrap(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Of returns the exit code for err: Success for nil, the code of an Error,
+// otherwise Failure
+func Of(err error) int {
+	if err == nil {
+		return Success
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return Failure
+}
@@ -0,0 +1,46 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, Success},
+		{"plain error", errors.New("boom"), Failure},
+		{"coded error", New(Usage, "missing %s", "name"), Usage},
+		{"wrapped coded error", fmt.Errorf("adding plugin: %w", New(ToolFailure, "npm failed")), ToolFailure},
+		{"plain error given a code", Wrap(NotProject, errors.New("no .godspeed")), NotProject},
+		{"Wrap keeps the existing code", Wrap(Failure, New(ValidationFailure, "invalid")), ValidationFailure},
+		{"Wrap keeps a wrapped code", Wrap(Failure, fmt.Errorf("checking: %w", New(Usage, "bad flag"))), Usage},
+	}
+
+	for _, tt := range tests {
+		if got := Of(tt.err); got != tt.want {
+			t.Errorf("%s: Of = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("npm failed")
+	err := Wrap(ToolFailure, cause)
+	if err.Error() != "npm failed" {
+		t.Errorf("Error() = %q, want the message of the wrapped error", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(err, cause) = false, want the wrapped error to unwrap")
+	}
+	if New(Usage, "missing %s", "name").Error() != "missing name" {
+		t.Errorf("New does not format its message")
+	}
+	if Wrap(Usage, nil) != nil {
+		t.Errorf("Wrap(nil) is not nil")
+	}
+}
@@ -0,0 +1,493 @@
+package export
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/config"
+	"github.com/godspeedsystems/godspeed-cli/internal/events"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// Supported collection formats
+const (
+	FormatPostman  = "postman"
+	FormatInsomnia = "insomnia"
+)
+
+// Result is the JSON result of export postman
+type Result struct {
+	Format       string   `json:"format"`
+	Path         string   `json:"path"`
+	EventSources []string `json:"eventsources"`
+	Requests     int      `json:"requests"`
+}
+
+// request is a single http call built from an event definition
+type request struct {
+	Key         string
+	Name        string
+	Method      string
+	Path        string
+	Description string
+	PathParams  []param
+	Query       []param
+	Headers     []param
+	Body        interface{}
+}
+
+// param is a path, query or header parameter with an example value
+type param struct {
+	Name        string
+	Value       string
+	Description string
+}
+
+// folder groups the requests of an eventsource by path
+type folder struct {
+	EventSource string
+	BaseURLVar  string
+	BaseURL     string
+	Paths       []string
+	Requests    map[string][]request
+}
+
+// Collection exports the project's http events as a Postman or Insomnia collection
+func Collection(format, outputPath, eventSource string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	if format != FormatPostman && format != FormatInsomnia {
+		return exitcode.New(exitcode.Usage, "unsupported format %s. Use %s or %s", format, FormatPostman, FormatInsomnia)
+	}
+
+	projectName := config.ProjectName()
+
+	folders, err := buildFolders(eventSource)
+	if err != nil {
+		return fmt.Errorf("reading events: %w", err)
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no http events found to export")
+	}
+
+	var document interface{}
+	if format == FormatPostman {
+		document = postmanCollection(projectName, folders)
+	} else {
+		document = insomniaExport(projectName, folders)
+	}
+
+	if outputPath == "" {
+		outputPath = fmt.Sprintf("%s.%s_collection.json", projectName, format)
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("creating JSON: %w", err)
+	}
+
+	if err := ioutil.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", outputPath, err)
+	}
+
+	result := Result{Format: format, Path: outputPath, EventSources: []string{}}
+	for _, f := range folders {
+		result.EventSources = append(result.EventSources, f.EventSource)
+		for _, requests := range f.Requests {
+			result.Requests += len(requests)
+		}
+	}
+	output.Set(result)
+
+	color.Green("Exported %s collection to %s", format, outputPath)
+	return nil
+}
+
+// buildFolders collects the http events per eventsource and path
+func buildFolders(onlyEventSource string) ([]*folder, error) {
+	allEvents, err := events.Load(filepath.Join("src", "events"))
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := schema.LoadDefinitions(filepath.Join("src", "definitions"))
+	if err != nil {
+		return nil, err
+	}
+
+	port := config.ServicePort()
+	folders := make(map[string]*folder)
+	skipped := make(map[string]bool)
+
+	for _, key := range events.SortedKeys(allEvents) {
+		event := allEvents[key]
+		if !event.IsHTTP() {
+			continue
+		}
+
+		for _, source := range event.Sources() {
+			if onlyEventSource != "" && source != onlyEventSource || skipped[source] {
+				continue
+			}
+
+			f, ok := folders[source]
+			if !ok {
+				esConfig, _ := events.LoadEventSource(source)
+				if events.IsGraphQL(esConfig) {
+					skipped[source] = true
+					continue
+				}
+
+				f = &folder{
+					EventSource: source,
+					BaseURLVar:  variableName(source),
+					BaseURL:     baseURL(esConfig, port),
+					Requests:    make(map[string][]request),
+				}
+				folders[source] = f
+			}
+
+			if _, ok := f.Requests[event.Path()]; !ok {
+				f.Paths = append(f.Paths, event.Path())
+			}
+			f.Requests[event.Path()] = append(f.Requests[event.Path()], buildRequest(event, definitions))
+		}
+	}
+
+	var result []*folder
+	for _, name := range sortedFolderNames(folders) {
+		sort.Strings(folders[name].Paths)
+		result = append(result, folders[name])
+	}
+	return result, nil
+}
+
+// buildRequest builds a request with example values for an http event
+func buildRequest(event events.Event, definitions *schema.Definitions) request {
+	req := request{
+		Key:         event.Key,
+		Method:      strings.ToUpper(event.Method()),
+		Path:        event.Path(),
+		Description: event.Description,
+	}
+
+	req.Name = event.Summary
+	if req.Name == "" {
+		req.Name = fmt.Sprintf("%s %s", req.Method, req.Path)
+	}
+
+	for _, p := range event.RequestParams() {
+		name, _ := p["name"].(string)
+		if name == "" {
+			continue
+		}
+
+		description, _ := p["description"].(string)
+		value := p["example"]
+		if value == nil {
+			value = definitions.Example(p["schema"])
+		}
+
+		entry := param{Name: name, Value: stringify(value), Description: description}
+		switch p["in"] {
+		case "path":
+			req.PathParams = append(req.PathParams, entry)
+		case "query":
+			req.Query = append(req.Query, entry)
+		case "header":
+			req.Headers = append(req.Headers, entry)
+		}
+	}
+
+	if body := event.RequestBody(); body != nil {
+		if content, ok := body["content"].(map[string]interface{}); ok {
+			if media, ok := content["application/json"].(map[string]interface{}); ok {
+				req.Body = media["example"]
+				if req.Body == nil {
+					req.Body = definitions.Example(media["schema"])
+				}
+			}
+		} else if bodySchema, ok := body["schema"]; ok {
+			req.Body = definitions.Example(bodySchema)
+		}
+	}
+
+	return req
+}
+
+// postmanCollection renders the folders as a Postman v2.1 collection
+func postmanCollection(projectName string, folders []*folder) map[string]interface{} {
+	var items []interface{}
+	var variables []interface{}
+
+	for _, f := range folders {
+		variables = append(variables, map[string]interface{}{
+			"key":   f.BaseURLVar,
+			"value": f.BaseURL,
+			"type":  "string",
+		})
+
+		var pathItems []interface{}
+		for _, path := range f.Paths {
+			var requestItems []interface{}
+			for _, req := range f.Requests[path] {
+				requestItems = append(requestItems, postmanItem(f, req))
+			}
+			pathItems = append(pathItems, map[string]interface{}{
+				"name": path,
+				"item": requestItems,
+			})
+		}
+
+		items = append(items, map[string]interface{}{
+			"name": f.EventSource,
+			"item": pathItems,
+		})
+	}
+
+	return map[string]interface{}{
+		"info": map[string]interface{}{
+			"_postman_id": stableID("", projectName),
+			"name":        projectName,
+			"description": "Generated by godspeed export from src/events",
+			"schema":      "https://schema.getpostman.com/json/collection/v2.1.0/collection.json",
+		},
+		"item":     items,
+		"variable": variables,
+	}
+}
+
+// postmanItem renders a single request as a Postman item
+func postmanItem(f *folder, req request) map[string]interface{} {
+	segments := strings.Split(strings.Trim(req.Path, "/"), "/")
+
+	raw := fmt.Sprintf("{{%s}}%s", f.BaseURLVar, req.Path)
+	var query []interface{}
+	var queryParts []string
+	for _, q := range req.Query {
+		query = append(query, map[string]interface{}{"key": q.Name, "value": q.Value, "description": q.Description})
+		queryParts = append(queryParts, fmt.Sprintf("%s=%s", url.QueryEscape(q.Name), url.QueryEscape(q.Value)))
+	}
+	if len(queryParts) > 0 {
+		raw += "?" + strings.Join(queryParts, "&")
+	}
+
+	var variables []interface{}
+	for _, p := range req.PathParams {
+		variables = append(variables, map[string]interface{}{"key": p.Name, "value": p.Value, "description": p.Description})
+	}
+
+	headers := []interface{}{}
+	if req.Body != nil {
+		headers = append(headers, map[string]interface{}{"key": "Content-Type", "value": "application/json"})
+	}
+	for _, h := range req.Headers {
+		headers = append(headers, map[string]interface{}{"key": h.Name, "value": h.Value, "description": h.Description})
+	}
+
+	urlObject := map[string]interface{}{
+		"raw":  raw,
+		"host": []string{fmt.Sprintf("{{%s}}", f.BaseURLVar)},
+		"path": segments,
+	}
+	if query != nil {
+		urlObject["query"] = query
+	}
+	if variables != nil {
+		urlObject["variable"] = variables
+	}
+
+	request := map[string]interface{}{
+		"method":      req.Method,
+		"header":      headers,
+		"url":         urlObject,
+		"description": req.Description,
+	}
+
+	if req.Body != nil {
+		body, _ := json.MarshalIndent(req.Body, "", "  ")
+		request["body"] = map[string]interface{}{
+			"mode": "raw",
+			"raw":  string(body),
+			"options": map[string]interface{}{
+				"raw": map[string]interface{}{"language": "json"},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"name":    req.Name,
+		"request": request,
+	}
+}
+
+// insomniaExport renders the folders as an Insomnia v4 export
+func insomniaExport(projectName string, folders []*folder) map[string]interface{} {
+	workspaceID := stableID("wrk_", projectName)
+	environment := make(map[string]interface{})
+	var resources []interface{}
+
+	resources = append(resources, map[string]interface{}{
+		"_id":         workspaceID,
+		"_type":       "workspace",
+		"name":        projectName,
+		"description": "Generated by godspeed export from src/events",
+		"scope":       "collection",
+	})
+
+	for _, f := range folders {
+		environment[f.BaseURLVar] = f.BaseURL
+
+		sourceID := stableID("fld_", f.EventSource)
+		resources = append(resources, map[string]interface{}{
+			"_id":      sourceID,
+			"_type":    "request_group",
+			"parentId": workspaceID,
+			"name":     f.EventSource,
+		})
+
+		for _, path := range f.Paths {
+			pathID := stableID("fld_", f.EventSource+path)
+			resources = append(resources, map[string]interface{}{
+				"_id":      pathID,
+				"_type":    "request_group",
+				"parentId": sourceID,
+				"name":     path,
+			})
+
+			for _, req := range f.Requests[path] {
+				resources = append(resources, insomniaRequest(f, req, pathID))
+			}
+		}
+	}
+
+	resources = append(resources, map[string]interface{}{
+		"_id":      stableID("env_", projectName),
+		"_type":    "environment",
+		"parentId": workspaceID,
+		"name":     "Base Environment",
+		"data":     environment,
+	})
+
+	return map[string]interface{}{
+		"_type":           "export",
+		"__export_format": 4,
+		"__export_date":   time.Now().UTC().Format(time.RFC3339),
+		"__export_source": "godspeed.cli",
+		"resources":       resources,
+	}
+}
+
+// insomniaRequest renders a single request as an Insomnia request resource.
+// Insomnia has no path variables, so the example values are filled in.
+func insomniaRequest(f *folder, req request, parentID string) map[string]interface{} {
+	values := make(map[string]string)
+	for _, p := range req.PathParams {
+		values[":"+p.Name] = p.Value
+	}
+	segments := strings.Split(req.Path, "/")
+	for i, segment := range segments {
+		if value, ok := values[segment]; ok {
+			segments[i] = value
+		}
+	}
+	path := strings.Join(segments, "/")
+
+	parameters := []interface{}{}
+	for _, q := range req.Query {
+		parameters = append(parameters, map[string]interface{}{"name": q.Name, "value": q.Value, "description": q.Description})
+	}
+
+	headers := []interface{}{}
+	body := map[string]interface{}{}
+	if req.Body != nil {
+		text, _ := json.MarshalIndent(req.Body, "", "  ")
+		body = map[string]interface{}{"mimeType": "application/json", "text": string(text)}
+		headers = append(headers, map[string]interface{}{"name": "Content-Type", "value": "application/json"})
+	}
+	for _, h := range req.Headers {
+		headers = append(headers, map[string]interface{}{"name": h.Name, "value": h.Value, "description": h.Description})
+	}
+
+	return map[string]interface{}{
+		"_id":         stableID("req_", f.EventSource+req.Key),
+		"_type":       "request",
+		"parentId":    parentID,
+		"name":        req.Name,
+		"description": req.Description,
+		"method":      req.Method,
+		"url":         fmt.Sprintf("{{ _.%s }}%s", f.BaseURLVar, path),
+		"parameters":  parameters,
+		"headers":     headers,
+		"body":        body,
+	}
+}
+
+// baseURL builds the base url of an eventsource from the service port and
+// the eventsource's base path
+func baseURL(esConfig map[string]interface{}, port int) string {
+	if port == 0 {
+		if esPort, ok := esConfig["port"].(int); ok {
+			port = esPort
+		} else {
+			port = 3000
+		}
+	}
+
+	return fmt.Sprintf("http://localhost:%d%s", port, events.BasePath(esConfig))
+}
+
+// variableName returns the base url variable name of an eventsource
+func variableName(eventSource string) string {
+	var name strings.Builder
+	for _, r := range eventSource {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			name.WriteRune(r)
+		}
+	}
+	return name.String() + "BaseUrl"
+}
+
+// stableID derives a deterministic id so re-exports produce the same ids
+func stableID(prefix, value string) string {
+	return fmt.Sprintf("%s%x", prefix, sha1.Sum([]byte(value)))[:len(prefix)+24]
+}
+
+// stringify renders an example value for use in a url or header
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, int, bool:
+		return fmt.Sprintf("%v", v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// sortedFolderNames returns the eventsource names in a stable order
+func sortedFolderNames(folders map[string]*folder) []string {
+	names := make([]string, 0, len(folders))
+	for name := range folders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
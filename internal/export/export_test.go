@@ -0,0 +1,248 @@
+package export
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// inProject writes files into a temporary project and makes it the working
+// directory for the rest of the test
+func inProject(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+var testProject = map[string]string{
+	".godspeed":                  `{"projectName": "shop"}`,
+	"src/eventsources/http.yaml": "type: express\nport: 4000\nbase_url: api/\n",
+	"src/eventsources/gql.yaml":  "type: apollo-graphql\nport: 4001\n",
+	"src/events/users.yaml": `
+http.get./users/:id:
+  fn: get_user
+  summary: Get a user
+  params:
+    - name: id
+      in: path
+      example: u1
+    - name: fields
+      in: query
+      schema: {type: string, example: name email}
+    - name: x-tenant
+      in: header
+      description: tenant id
+      example: acme
+http.post./users:
+  fn: create_user
+  body:
+    content:
+      application/json:
+        schema:
+          type: object
+          properties:
+            name: {type: string, example: Ada}
+http & gql.get./users:
+  fn: list_users
+kafka.orders.billing:
+  fn: on_order
+`,
+}
+
+func TestBuildFolders(t *testing.T) {
+	inProject(t, testProject)
+
+	folders, err := buildFolders("")
+	if err != nil {
+		t.Fatalf("buildFolders: %v", err)
+	}
+	if len(folders) != 1 {
+		t.Fatalf("buildFolders returned %d folders, want only http", len(folders))
+	}
+
+	f := folders[0]
+	if f.EventSource != "http" || f.BaseURLVar != "httpBaseUrl" || f.BaseURL != "http://localhost:4000/api" {
+		t.Errorf("folder = %s %s %s, want http httpBaseUrl http://localhost:4000/api", f.EventSource, f.BaseURLVar, f.BaseURL)
+	}
+	if want := []string{"/users", "/users/:id"}; !reflect.DeepEqual(f.Paths, want) {
+		t.Errorf("Paths = %v, want %v", f.Paths, want)
+	}
+
+	var names []string
+	for _, req := range f.Requests["/users"] {
+		names = append(names, req.Name)
+	}
+	if want := []string{"GET /users", "POST /users"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("requests of /users = %v, want %v", names, want)
+	}
+
+	get := f.Requests["/users/:id"][0]
+	wantGet := request{
+		Key:        "http.get./users/:id",
+		Name:       "Get a user",
+		Method:     "GET",
+		Path:       "/users/:id",
+		PathParams: []param{{Name: "id", Value: "u1"}},
+		Query:      []param{{Name: "fields", Value: "name email"}},
+		Headers:    []param{{Name: "x-tenant", Value: "acme", Description: "tenant id"}},
+	}
+	if !reflect.DeepEqual(get, wantGet) {
+		t.Errorf("request = %+v, want %+v", get, wantGet)
+	}
+
+	post := f.Requests["/users"][1]
+	if want := map[string]interface{}{"name": "Ada"}; !reflect.DeepEqual(post.Body, want) {
+		t.Errorf("body = %v, want %v", post.Body, want)
+	}
+
+	if folders, _ := buildFolders("gql"); len(folders) != 0 {
+		t.Errorf("buildFolders(gql) = %d folders, want graphql eventsources skipped", len(folders))
+	}
+}
+
+func TestPostmanCollection(t *testing.T) {
+	inProject(t, testProject)
+	folders, err := buildFolders("")
+	if err != nil {
+		t.Fatalf("buildFolders: %v", err)
+	}
+
+	collection := postmanCollection("shop", folders)
+	if name := collection["info"].(map[string]interface{})["name"]; name != "shop" {
+		t.Errorf("info.name = %v, want shop", name)
+	}
+	wantVariables := []interface{}{map[string]interface{}{"key": "httpBaseUrl", "value": "http://localhost:4000/api", "type": "string"}}
+	if !reflect.DeepEqual(collection["variable"], wantVariables) {
+		t.Errorf("variable = %v, want %v", collection["variable"], wantVariables)
+	}
+
+	source := collection["item"].([]interface{})[0].(map[string]interface{})
+	paths := source["item"].([]interface{})
+	if source["name"] != "http" || len(paths) != 2 {
+		t.Fatalf("eventsource item = %v, want http with two paths", source)
+	}
+
+	item := paths[1].(map[string]interface{})["item"].([]interface{})[0].(map[string]interface{})
+	req := item["request"].(map[string]interface{})
+	url := req["url"].(map[string]interface{})
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"name", item["name"], "Get a user"},
+		{"method", req["method"], "GET"},
+		{"raw url", url["raw"], "{{httpBaseUrl}}/users/:id?fields=name+email"},
+		{"host", url["host"], []string{"{{httpBaseUrl}}"}},
+		{"path", url["path"], []string{"users", ":id"}},
+		{"path variables", url["variable"], []interface{}{map[string]interface{}{"key": "id", "value": "u1", "description": ""}}},
+		{"query", url["query"], []interface{}{map[string]interface{}{"key": "fields", "value": "name email", "description": ""}}},
+		{"headers", req["header"], []interface{}{map[string]interface{}{"key": "x-tenant", "value": "acme", "description": "tenant id"}}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	post := paths[0].(map[string]interface{})["item"].([]interface{})[1].(map[string]interface{})["request"].(map[string]interface{})
+	wantBody := map[string]interface{}{
+		"mode":    "raw",
+		"raw":     "{\n  \"name\": \"Ada\"\n}",
+		"options": map[string]interface{}{"raw": map[string]interface{}{"language": "json"}},
+	}
+	if !reflect.DeepEqual(post["body"], wantBody) {
+		t.Errorf("body = %#v, want %#v", post["body"], wantBody)
+	}
+	wantHeaders := []interface{}{map[string]interface{}{"key": "Content-Type", "value": "application/json"}}
+	if !reflect.DeepEqual(post["header"], wantHeaders) {
+		t.Errorf("headers = %#v, want %#v", post["header"], wantHeaders)
+	}
+}
+
+func TestInsomniaExport(t *testing.T) {
+	inProject(t, testProject)
+	folders, err := buildFolders("")
+	if err != nil {
+		t.Fatalf("buildFolders: %v", err)
+	}
+
+	document := insomniaExport("shop", folders)
+	resources := document["resources"].([]interface{})
+
+	var types []string
+	parents := make(map[string]interface{})
+	for _, resource := range resources {
+		r := resource.(map[string]interface{})
+		types = append(types, r["_type"].(string))
+		parents[r["_id"].(string)] = r["parentId"]
+	}
+	want := []string{"workspace", "request_group", "request_group", "request", "request", "request_group", "request", "environment"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("resource types = %v, want %v", types, want)
+	}
+
+	// Every resource but the workspace hangs off an existing resource
+	for id, parent := range parents {
+		if parent == nil {
+			continue
+		}
+		if _, ok := parents[parent.(string)]; !ok {
+			t.Errorf("resource %s has unknown parent %v", id, parent)
+		}
+	}
+
+	environment := resources[len(resources)-1].(map[string]interface{})
+	if data := environment["data"]; !reflect.DeepEqual(data, map[string]interface{}{"httpBaseUrl": "http://localhost:4000/api"}) {
+		t.Errorf("environment data = %v", data)
+	}
+
+	again := insomniaExport("shop", folders)["resources"].([]interface{})
+	for i := range resources {
+		if resources[i].(map[string]interface{})["_id"] != again[i].(map[string]interface{})["_id"] {
+			t.Errorf("resource %d has a different id on re-export", i)
+		}
+	}
+}
+
+func TestInsomniaRequestURL(t *testing.T) {
+	f := &folder{EventSource: "http", BaseURLVar: "httpBaseUrl"}
+
+	tests := []struct {
+		path   string
+		params []param
+		want   string
+	}{
+		{"/users", nil, "{{ _.httpBaseUrl }}/users"},
+		{"/users/:id", []param{{Name: "id", Value: "u1"}}, "{{ _.httpBaseUrl }}/users/u1"},
+		{"/users/:id/posts/:post", []param{{Name: "post", Value: "7"}, {Name: "id", Value: "u1"}}, "{{ _.httpBaseUrl }}/users/u1/posts/7"},
+		{"/items/:id/:idx", []param{{Name: "id", Value: "A"}, {Name: "idx", Value: "B"}}, "{{ _.httpBaseUrl }}/items/A/B"},
+		{"/files/:id.json", []param{{Name: "id", Value: "A"}}, "{{ _.httpBaseUrl }}/files/:id.json"},
+	}
+
+	for _, tt := range tests {
+		got := insomniaRequest(f, request{Key: "http.get." + tt.path, Path: tt.path, PathParams: tt.params}, "fld_")
+		if got["url"] != tt.want {
+			t.Errorf("url of %s = %v, want %s", tt.path, got["url"], tt.want)
+		}
+	}
+}
@@ -0,0 +1,600 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/asyncapi"
+	"github.com/godspeedsystems/godspeed-cli/internal/events"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/prompt"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Function languages
+const (
+	LanguageTS   = "ts"
+	LanguageYAML = "yaml"
+)
+
+// methods are the http methods offered for new events
+var methods = []string{"get", "post", "put", "patch", "delete"}
+
+// fnPattern matches dotted function names such as "users.get"
+var fnPattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+
+// definitionPattern matches definition names such as "billing/Invoice"
+var definitionPattern = regexp.MustCompile(`^[A-Za-z_][\w-]*(/[A-Za-z_][\w-]*)*$`)
+
+// functionExtensions are the file types a function can be implemented in
+var functionExtensions = []string{".ts", ".js", ".yaml", ".yml"}
+
+// EventOptions holds the values of a new event. Empty values are prompted for.
+type EventOptions struct {
+	EventSource string
+	Method      string
+	Path        string
+	Topic       string
+	Group       string
+	Fn          string
+	Summary     string
+	Language    string
+	File        string
+}
+
+// Result is the JSON result of the generate commands. Kind is event,
+// workflow, function or definition, and Files are the files written, e.g. the
+// events file and the new function of an event.
+type Result struct {
+	Kind  string   `json:"kind"`
+	Name  string   `json:"name"`
+	Files []string `json:"files"`
+}
+
+// event is a generated event in the order its keys are written
+type event struct {
+	Fn        string                 `yaml:"fn"`
+	Summary   string                 `yaml:"summary,omitempty"`
+	Body      map[string]interface{} `yaml:"body,omitempty"`
+	Params    []param                `yaml:"params,omitempty"`
+	Responses map[string]interface{} `yaml:"responses,omitempty"`
+}
+
+// param is a generated event parameter
+type param struct {
+	Name     string                 `yaml:"name"`
+	In       string                 `yaml:"in"`
+	Required bool                   `yaml:"required"`
+	Schema   map[string]interface{} `yaml:"schema"`
+}
+
+// workflow is a generated YAML workflow
+type workflow struct {
+	Summary string `yaml:"summary,omitempty"`
+	Tasks   []task `yaml:"tasks"`
+}
+
+// task is a single workflow task
+type task struct {
+	ID   string                 `yaml:"id"`
+	Fn   string                 `yaml:"fn"`
+	Args map[string]interface{} `yaml:"args"`
+}
+
+// functionTemplate is the body of a generated TypeScript function
+const functionTemplate = `import { GSContext, GSStatus, PlainObject } from "@godspeedsystems/core";
+
+/**
+ * %s
+ */
+export default function (ctx: GSContext, args: PlainObject) {
+  const {
+    inputs: {
+      data: { params, query, body, headers },
+    },
+    logger,
+  } = ctx;
+
+  return new GSStatus(true, 200, undefined, { message: "%s is not implemented yet" });
+}
+`
+
+// Event adds an event to src/events and generates its function if it does not exist yet
+func Event(opts EventOptions) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	message, err := completeEventOptions(&opts)
+	if err != nil {
+		return err
+	}
+
+	key, ev, err := buildEvent(opts, message)
+	if err != nil {
+		return err
+	}
+
+	// A project without src/events has no events yet
+	eventsPath := filepath.Join("src", "events")
+	allEvents := map[string]events.Event{}
+	if utils.DirExists(eventsPath) {
+		if allEvents, err = events.Load(eventsPath); err != nil {
+			return fmt.Errorf("loading events: %w", err)
+		}
+	}
+	if existing, ok := allEvents[key]; ok {
+		return fmt.Errorf("event %s already exists in %s", key, existing.File)
+	}
+
+	if err := appendEvent(opts.File, key, ev); err != nil {
+		return fmt.Errorf("writing event: %w", err)
+	}
+	color.Green("Added event %s to %s", key, opts.File)
+
+	result := Result{Kind: "event", Name: key, Files: []string{opts.File}}
+	defer output.Set(&result)
+
+	if path := FunctionPath(opts.Fn); path != "" {
+		fmt.Printf("Function %s already exists at %s\n", opts.Fn, path)
+		return nil
+	}
+	path, err := writeFunction(opts.Fn, opts.Language, opts.Summary)
+	if err != nil {
+		return fmt.Errorf("generating function %s: %w", opts.Fn, err)
+	}
+	result.Files = append(result.Files, path)
+	return nil
+}
+
+// Workflow generates a YAML workflow in src/functions
+func Workflow(name, summary string) error {
+	return generateFunction(name, LanguageYAML, summary, "workflow")
+}
+
+// Function generates a TypeScript function in src/functions
+func Function(name, summary string) error {
+	return generateFunction(name, LanguageTS, summary, "function")
+}
+
+// generateFunction prompts for a missing name and writes the function file
+func generateFunction(name, language, summary, kind string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	if name == "" {
+		if err := prompt.Ask(&survey.Input{
+			Message: fmt.Sprintf("Name of the %s (e.g. users.create):", kind),
+		}, &name, "<name>", survey.WithValidator(survey.Required), survey.WithValidator(fnValidator)); err != nil {
+			return err
+		}
+	}
+
+	if !fnPattern.MatchString(name) {
+		return exitcode.New(exitcode.Usage, "invalid %s name %q. Use dot separated identifiers, e.g. users.create", kind, name)
+	}
+
+	if path := FunctionPath(name); path != "" {
+		return fmt.Errorf("function %s already exists at %s", name, path)
+	}
+
+	path, err := writeFunction(name, language, summary)
+	if err != nil {
+		return fmt.Errorf("generating %s: %w", kind, err)
+	}
+	output.Set(Result{Kind: kind, Name: name, Files: []string{path}})
+	return nil
+}
+
+// Definition adds a definition to src/definitions. Names may be namespaced
+// with directories, e.g. "billing/Invoice".
+func Definition(name, typ string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	if name == "" {
+		if err := prompt.Ask(&survey.Input{
+			Message: "Name of the definition (e.g. User or billing/Invoice):",
+		}, &name, "<name>", survey.WithValidator(survey.Required), survey.WithValidator(definitionValidator)); err != nil {
+			return err
+		}
+	}
+
+	if !definitionPattern.MatchString(name) {
+		return exitcode.New(exitcode.Usage, "invalid definition name %q. Use a name such as User or billing/Invoice", name)
+	}
+
+	if typ == "" {
+		typ = "object"
+	}
+
+	path := filepath.Join("src", "definitions", filepath.FromSlash(name)+".yaml")
+	if utils.FileExists(path) {
+		return fmt.Errorf("definition file %s already exists", path)
+	}
+
+	definition := map[string]interface{}{"type": typ}
+	if typ == "object" {
+		definition["properties"] = map[string]interface{}{}
+	}
+
+	if err := writeYaml(path, map[string]interface{}{filepath.Base(filepath.FromSlash(name)): definition}); err != nil {
+		return fmt.Errorf("writing definition: %w", err)
+	}
+	output.Set(Result{Kind: "definition", Name: name, Files: []string{path}})
+	color.Green("Created definition %s at %s", name, path)
+	fmt.Printf("Reference it from events with $ref: '#/definitions/%s'\n", name)
+	return nil
+}
+
+// FunctionPath returns the file that implements fn, or "" if there is none
+func FunctionPath(fn string) string {
+	base := filepath.Join(append([]string{"src", "functions"}, strings.Split(fn, ".")...)...)
+	for _, ext := range functionExtensions {
+		if utils.FileExists(base + ext) {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// completeEventOptions prompts for every event option that was not given. It
+// reports whether the eventsource consumes from a message broker.
+func completeEventOptions(opts *EventOptions) (bool, error) {
+	if opts.File != "" {
+		file, err := eventFile(opts.File)
+		if err != nil {
+			return false, err
+		}
+		opts.File = file
+	}
+
+	if opts.EventSource == "" {
+		names, err := events.EventSourceNames()
+		if err != nil {
+			return false, err
+		}
+		if len(names) == 0 {
+			return false, fmt.Errorf("no eventsources found in src/eventsources. Add one with godspeed plugin add")
+		}
+		if len(names) == 1 {
+			opts.EventSource = names[0]
+		} else if err := prompt.Ask(&survey.Select{
+			Message: "Which eventsource should trigger the event?",
+			Options: names,
+		}, &opts.EventSource, "--eventsource"); err != nil {
+			return false, err
+		}
+	}
+
+	esConfig, err := events.LoadEventSource(opts.EventSource)
+	if err != nil {
+		return false, fmt.Errorf("eventsource %s not found in src/eventsources", opts.EventSource)
+	}
+
+	message := asyncapi.IsMessageEventSource(opts.EventSource, esConfig)
+	if err := checkEventFlags(*opts, message); err != nil {
+		return false, err
+	}
+
+	if message {
+		if opts.Topic == "" {
+			if err := prompt.Ask(&survey.Input{
+				Message: "Topic:",
+			}, &opts.Topic, "--topic", survey.WithValidator(survey.Required)); err != nil {
+				return false, err
+			}
+		}
+		if opts.Group == "" {
+			if err := prompt.Ask(&survey.Input{
+				Message: "Consumer group:",
+				Default: opts.Topic + "_group",
+			}, &opts.Group, "--group", survey.WithValidator(survey.Required)); err != nil {
+				return false, err
+			}
+		}
+	} else {
+		if opts.Method == "" {
+			if err := prompt.Ask(&survey.Select{
+				Message: "Method:",
+				Options: methods,
+				Default: "get",
+			}, &opts.Method, "--method"); err != nil {
+				return false, err
+			}
+		}
+		if opts.Path == "" {
+			if err := prompt.Ask(&survey.Input{
+				Message: "Path (e.g. /users/:id):",
+			}, &opts.Path, "--path", survey.WithValidator(survey.Required)); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// The language is only asked for along with the function, so that an
+	// event given entirely by flags never prompts
+	askLanguage := opts.Fn == "" && opts.Language == ""
+	if opts.Fn == "" {
+		if err := prompt.Ask(&survey.Input{
+			Message: "Function to call (created if it does not exist):",
+			Default: defaultFunctionName(*opts),
+		}, &opts.Fn, "--fn", survey.WithValidator(survey.Required), survey.WithValidator(fnValidator)); err != nil {
+			return false, err
+		}
+	}
+
+	if opts.Language == "" {
+		opts.Language = LanguageTS
+		if askLanguage && FunctionPath(opts.Fn) == "" {
+			if err := prompt.Ask(&survey.Select{
+				Message: fmt.Sprintf("Function %s does not exist yet. Generate it as:", opts.Fn),
+				Options: []string{LanguageTS, LanguageYAML},
+				Default: LanguageTS,
+			}, &opts.Language, "--lang"); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if opts.File == "" {
+		opts.File = defaultEventFile(*opts)
+	}
+
+	return message, nil
+}
+
+// eventFile resolves --file inside src/events, refusing paths that leave it
+func eventFile(file string) (string, error) {
+	cleaned := filepath.Clean(file)
+	if filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", exitcode.New(exitcode.Usage, "invalid --file %q. Use a path inside src/events", file)
+	}
+	return filepath.Join("src", "events", cleaned), nil
+}
+
+// checkEventFlags rejects the flags that do not apply to the eventsource:
+// --topic and --group for http-like eventsources, --method and --path for
+// message brokers
+func checkEventFlags(opts EventOptions, message bool) error {
+	if message && (opts.Method != "" || opts.Path != "") {
+		return exitcode.New(exitcode.Usage, "%s is a message broker eventsource. Use --topic and --group instead of --method and --path", opts.EventSource)
+	}
+	if !message && (opts.Topic != "" || opts.Group != "") {
+		return exitcode.New(exitcode.Usage, "%s is not a message broker eventsource. Use --method and --path instead of --topic and --group", opts.EventSource)
+	}
+	return nil
+}
+
+// buildEvent validates opts and returns the event key and definition. Events
+// of message broker eventsources are keyed by topic and group, others by
+// method and path.
+func buildEvent(opts EventOptions, message bool) (string, event, error) {
+	if err := checkEventFlags(opts, message); err != nil {
+		return "", event{}, err
+	}
+	if !fnPattern.MatchString(opts.Fn) {
+		return "", event{}, fmt.Errorf("invalid function name %q. Use dot separated identifiers, e.g. users.create", opts.Fn)
+	}
+	if opts.Language != LanguageTS && opts.Language != LanguageYAML {
+		return "", event{}, fmt.Errorf("invalid language %q. Use %s or %s", opts.Language, LanguageTS, LanguageYAML)
+	}
+
+	ev := event{Fn: opts.Fn, Summary: opts.Summary}
+
+	if message {
+		ev.Body = jsonContent(map[string]interface{}{"type": "object"})
+		return fmt.Sprintf("%s.%s.%s", opts.EventSource, opts.Topic, opts.Group), ev, nil
+	}
+
+	method := strings.ToLower(opts.Method)
+	if !contains(methods, method) {
+		return "", event{}, fmt.Errorf("invalid method %q. Use one of %s", opts.Method, strings.Join(methods, ", "))
+	}
+
+	path := "/" + strings.Trim(opts.Path, "/")
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			ev.Params = append(ev.Params, param{
+				Name:     strings.TrimPrefix(segment, ":"),
+				In:       "path",
+				Required: true,
+				Schema:   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+
+	if method == "post" || method == "put" || method == "patch" {
+		ev.Body = jsonContent(map[string]interface{}{"type": "object"})
+	}
+
+	ev.Responses = map[string]interface{}{
+		"200": jsonContent(map[string]interface{}{"type": "object"}),
+	}
+
+	return fmt.Sprintf("%s.%s.%s", opts.EventSource, method, path), ev, nil
+}
+
+// appendEvent adds the event to the end of file, leaving its existing content untouched
+func appendEvent(file, key string, ev event) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string]event{key: ev}); err != nil {
+		return err
+	}
+
+	existing, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		existing = append(existing, '\n')
+	}
+	if len(existing) > 0 {
+		existing = append(existing, '\n')
+	}
+
+	if err := utils.CreateDir(filepath.Dir(file)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(existing, buf.Bytes()...), 0644)
+}
+
+// writeFunction writes a TypeScript function or YAML workflow named fn and
+// returns its path
+func writeFunction(fn, language, summary string) (string, error) {
+	base := filepath.Join(append([]string{"src", "functions"}, strings.Split(fn, ".")...)...)
+	if summary == "" {
+		summary = fn
+	}
+
+	var path string
+	var err error
+	switch language {
+	case LanguageYAML:
+		path = base + ".yaml"
+		err = writeYaml(path, workflow{
+			Summary: summary,
+			Tasks: []task{{
+				ID:   strings.ReplaceAll(fn, ".", "_") + "_step1",
+				Fn:   "com.gs.return",
+				Args: map[string]interface{}{"message": fn + " is not implemented yet"},
+			}},
+		})
+	default:
+		path = base + ".ts"
+		if err = utils.CreateDir(filepath.Dir(path)); err == nil {
+			err = ioutil.WriteFile(path, []byte(fmt.Sprintf(functionTemplate, summary, fn)), 0644)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	color.Green("Created function %s at %s", fn, path)
+	return path, nil
+}
+
+// writeYaml encodes value as YAML to path
+func writeYaml(path string, value interface{}) error {
+	if err := utils.CreateDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// defaultFunctionName suggests a function name such as "users.get" for an event
+func defaultFunctionName(opts EventOptions) string {
+	if opts.Topic != "" {
+		return identifier(opts.Topic) + ".consume"
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(opts.Path, "/") {
+		if segment != "" && !strings.HasPrefix(segment, ":") {
+			segments = append(segments, identifier(segment))
+		}
+	}
+	if len(segments) == 0 {
+		segments = []string{"root"}
+	}
+	return strings.Join(append(segments, strings.ToLower(opts.Method)), ".")
+}
+
+// defaultEventFile returns src/events/<first path segment or topic>.yaml
+func defaultEventFile(opts EventOptions) string {
+	name := identifier(opts.Topic)
+	if opts.Topic == "" {
+		name = "root"
+		for _, segment := range strings.Split(opts.Path, "/") {
+			if segment != "" && !strings.HasPrefix(segment, ":") {
+				name = identifier(segment)
+				break
+			}
+		}
+	}
+
+	for _, ext := range []string{".yaml", ".yml"} {
+		path := filepath.Join("src", "events", name+ext)
+		if utils.FileExists(path) {
+			return path
+		}
+	}
+	return filepath.Join("src", "events", name+".yaml")
+}
+
+// identifier turns a path segment or topic into an identifier
+func identifier(name string) string {
+	var result strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9' && result.Len() > 0:
+			if upper {
+				r = []rune(strings.ToUpper(string(r)))[0]
+				upper = false
+			}
+			result.WriteRune(r)
+		default:
+			upper = result.Len() > 0
+		}
+	}
+	if result.Len() == 0 {
+		return "root"
+	}
+	return result.String()
+}
+
+// jsonContent wraps a schema in an application/json content block
+func jsonContent(s map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": s},
+		},
+	}
+}
+
+// fnValidator validates function names in prompts
+func fnValidator(val interface{}) error {
+	if str, ok := val.(string); ok && !fnPattern.MatchString(str) {
+		return fmt.Errorf("use dot separated identifiers, e.g. users.create")
+	}
+	return nil
+}
+
+// definitionValidator validates definition names in prompts
+func definitionValidator(val interface{}) error {
+	if str, ok := val.(string); ok && !definitionPattern.MatchString(str) {
+		return fmt.Errorf("use a name such as User or billing/Invoice")
+	}
+	return nil
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package generate
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+)
+
+func TestBuildEvent(t *testing.T) {
+	object := jsonContent(map[string]interface{}{"type": "object"})
+	idParam := param{Name: "id", In: "path", Required: true, Schema: map[string]interface{}{"type": "string"}}
+
+	tests := []struct {
+		name    string
+		opts    EventOptions
+		message bool
+		key     string
+		event   event
+		wantErr string
+	}{
+		{
+			name:  "get without params",
+			opts:  EventOptions{EventSource: "http", Method: "get", Path: "/users", Fn: "users.list", Language: LanguageTS},
+			key:   "http.get./users",
+			event: event{Fn: "users.list", Responses: map[string]interface{}{"200": object}},
+		},
+		{
+			name: "path params and a body",
+			opts: EventOptions{EventSource: "http", Method: "PUT", Path: "users/:id/", Fn: "users.update", Summary: "Update a user", Language: LanguageYAML},
+			key:  "http.put./users/:id",
+			event: event{
+				Fn:        "users.update",
+				Summary:   "Update a user",
+				Body:      object,
+				Params:    []param{idParam},
+				Responses: map[string]interface{}{"200": object},
+			},
+		},
+		{
+			name: "root path",
+			opts: EventOptions{EventSource: "http", Method: "post", Path: "/", Fn: "root.post", Language: LanguageTS},
+			key:  "http.post./",
+			event: event{
+				Fn:        "root.post",
+				Body:      object,
+				Responses: map[string]interface{}{"200": object},
+			},
+		},
+		{
+			name:    "topic",
+			opts:    EventOptions{EventSource: "kafka", Topic: "orders", Group: "billing", Fn: "orders.consume", Language: LanguageTS},
+			message: true,
+			key:     "kafka.orders.billing",
+			event:   event{Fn: "orders.consume", Body: object},
+		},
+		{
+			name:    "topic on an http eventsource",
+			opts:    EventOptions{EventSource: "http", Method: "get", Path: "/users", Topic: "users", Fn: "users.list", Language: LanguageTS},
+			wantErr: "http is not a message broker eventsource",
+		},
+		{
+			name:    "path on a message broker",
+			opts:    EventOptions{EventSource: "kafka", Path: "/orders", Topic: "orders", Group: "billing", Fn: "orders.consume", Language: LanguageTS},
+			message: true,
+			wantErr: "kafka is a message broker eventsource",
+		},
+		{
+			name:    "invalid function name",
+			opts:    EventOptions{EventSource: "http", Method: "get", Path: "/users", Fn: "users-list", Language: LanguageTS},
+			wantErr: `invalid function name "users-list"`,
+		},
+		{
+			name:    "invalid language",
+			opts:    EventOptions{EventSource: "http", Method: "get", Path: "/users", Fn: "users.list", Language: "py"},
+			wantErr: `invalid language "py"`,
+		},
+		{
+			name:    "invalid method",
+			opts:    EventOptions{EventSource: "http", Method: "fetch", Path: "/users", Fn: "users.list", Language: LanguageTS},
+			wantErr: `invalid method "fetch"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ev, err := buildEvent(tt.opts, tt.message)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildEvent error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildEvent: %v", err)
+			}
+			if key != tt.key {
+				t.Errorf("key = %q, want %q", key, tt.key)
+			}
+			if !reflect.DeepEqual(ev, tt.event) {
+				t.Errorf("event = %+v, want %+v", ev, tt.event)
+			}
+		})
+	}
+}
+
+func TestDefaultFunctionName(t *testing.T) {
+	tests := []struct {
+		opts EventOptions
+		want string
+	}{
+		{EventOptions{Method: "get", Path: "/users/:id"}, "users.get"},
+		{EventOptions{Method: "POST", Path: "/billing/invoice-lines"}, "billing.invoiceLines.post"},
+		{EventOptions{Method: "get", Path: "/"}, "root.get"},
+		{EventOptions{Topic: "order-events"}, "orderEvents.consume"},
+	}
+
+	for _, tt := range tests {
+		if got := defaultFunctionName(tt.opts); got != tt.want {
+			t.Errorf("defaultFunctionName(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestEventFile(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"users.yaml", filepath.Join("src", "events", "users.yaml")},
+		{"admin/users.yaml", filepath.Join("src", "events", "admin", "users.yaml")},
+		{"admin/../users.yaml", filepath.Join("src", "events", "users.yaml")},
+		{"../functions/users.yaml", ""},
+		{"..", ""},
+		{".", ""},
+		{"/etc/users.yaml", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := eventFile(tt.file)
+		if tt.want == "" {
+			if exitcode.Of(err) != exitcode.Usage {
+				t.Errorf("eventFile(%q) error = %v, want a usage error", tt.file, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("eventFile(%q) = %q, %v, want %q", tt.file, got, err, tt.want)
+		}
+	}
+}
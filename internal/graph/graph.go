@@ -0,0 +1,552 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/events"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	FormatMermaid = "mermaid"
+	FormatDot     = "dot"
+	FormatJSON    = "json"
+)
+
+// Node types
+const (
+	TypeEventSource = "eventsource"
+	TypeEvent       = "event"
+	TypeFunction    = "function"
+	TypeDatasource  = "datasource"
+)
+
+// builtinPrefix marks the framework's own functions, which are not drawn
+const builtinPrefix = "com.gs."
+
+// datasourcePrefix marks a workflow task that calls a datasource
+const datasourcePrefix = "datasource."
+
+// tsDatasourcePatterns find datasource calls in TypeScript functions
+var tsDatasourcePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`datasources\.([A-Za-z_$][\w$]*)`),
+	regexp.MustCompile(`datasources\[\s*['"\x60]([^'"\x60]+)['"\x60]\s*\]`),
+}
+
+// tsFunctionPatterns find calls to other functions in TypeScript functions
+var tsFunctionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`functions\[\s*['"\x60]([^'"\x60]+)['"\x60]\s*\]`),
+}
+
+// Node is a single eventsource, event, function or datasource
+type Node struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Label   string `json:"label"`
+	File    string `json:"file,omitempty"`
+	Dead    bool   `json:"dead,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// Edge connects two nodes
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the dependency graph of a project
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+
+	index map[string]*Node
+	edges map[Edge]bool
+}
+
+// Result is the JSON result of graph. Path is empty when the graph was
+// printed rather than written to a file.
+type Result struct {
+	Format string `json:"format"`
+	Path   string `json:"path,omitempty"`
+	Graph  *Graph `json:"graph"`
+}
+
+// Export prints the dependency graph of the project in the given format, or
+// writes it to outputPath. The graph can be narrowed to what is reachable from
+// an eventsource and to what reaches a datasource.
+func Export(format, outputPath, eventSource, datasource string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	if format == "" {
+		format = FormatMermaid
+	}
+	if format != FormatMermaid && format != FormatDot && format != FormatJSON {
+		return exitcode.New(exitcode.Usage, "unknown format %q. Use %s, %s or %s", format, FormatMermaid, FormatDot, FormatJSON)
+	}
+
+	g, err := Build()
+	if err != nil {
+		return fmt.Errorf("building graph: %w", err)
+	}
+
+	if eventSource != "" {
+		id := nodeID(TypeEventSource, eventSource)
+		if g.index[id] == nil {
+			return fmt.Errorf("eventsource %s not found", eventSource)
+		}
+		g = g.filter(g.reachable(id, true))
+	}
+	if datasource != "" {
+		id := nodeID(TypeDatasource, datasource)
+		if g.index[id] == nil {
+			return fmt.Errorf("datasource %s not found", datasource)
+		}
+		g = g.filter(g.reachable(id, false))
+	}
+
+	var document string
+	switch format {
+	case FormatDot:
+		document = g.Dot()
+	case FormatJSON:
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding graph: %w", err)
+		}
+		document = string(data) + "\n"
+	default:
+		document = g.Mermaid()
+	}
+
+	if outputPath == "" {
+		fmt.Print(document)
+	} else {
+		if err := ioutil.WriteFile(outputPath, []byte(document), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", outputPath, err)
+		}
+		color.Green("Graph written to %s", outputPath)
+	}
+	output.Set(Result{Format: format, Path: outputPath, Graph: g})
+
+	var dead []string
+	for _, node := range g.Nodes {
+		if node.Dead {
+			dead = append(dead, fmt.Sprintf("%s %s", node.Type, node.Label))
+		}
+	}
+	if len(dead) > 0 {
+		// stderr keeps the graph on stdout intact when it is piped to a file
+		fmt.Fprintln(os.Stderr, color.YellowString("Unreferenced: %s", strings.Join(dead, ", ")))
+	}
+	return nil
+}
+
+// Build parses the events, functions and datasources of the project in the
+// current directory into a graph
+func Build() (*Graph, error) {
+	g := &Graph{index: make(map[string]*Node), edges: make(map[Edge]bool)}
+
+	eventSources, err := events.EventSourceNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range eventSources {
+		g.addNode(TypeEventSource, name, filepath.Join("src", "eventsources", name+".yaml"))
+	}
+
+	datasources, err := datasourceFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(datasources) {
+		g.addNode(TypeDatasource, name, datasources[name])
+	}
+
+	functions, err := functionFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedKeys(functions) {
+		g.addNode(TypeFunction, name, functions[name])
+	}
+
+	eventsDir := filepath.Join("src", "events")
+	if utils.DirExists(eventsDir) {
+		allEvents, err := events.Load(eventsDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range events.SortedKeys(allEvents) {
+			event := allEvents[key]
+			eventID := g.addNode(TypeEvent, key, event.File)
+			for _, source := range event.Sources() {
+				g.addEdge(g.addNode(TypeEventSource, source, ""), eventID)
+			}
+			if event.Fn != "" && !strings.HasPrefix(event.Fn, builtinPrefix) {
+				g.addEdge(eventID, g.addNode(TypeFunction, event.Fn, ""))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(functions) {
+		calls, err := functionCalls(functions[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", functions[name], err)
+		}
+		from := nodeID(TypeFunction, name)
+		for _, call := range calls {
+			if strings.HasPrefix(call, datasourcePrefix) {
+				ds := strings.SplitN(strings.TrimPrefix(call, datasourcePrefix), ".", 2)[0]
+				g.addEdge(from, g.addNode(TypeDatasource, ds, ""))
+			} else if !strings.HasPrefix(call, builtinPrefix) && call != name {
+				g.addEdge(from, g.addNode(TypeFunction, call, ""))
+			}
+		}
+	}
+
+	g.markDead()
+	return g, nil
+}
+
+// Mermaid renders the graph as a Mermaid flowchart
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	ids := g.shortIDs()
+	for _, node := range g.Nodes {
+		label := strings.ReplaceAll(node.Label, `"`, "#quot;")
+		var shape string
+		switch node.Type {
+		case TypeEventSource:
+			shape = fmt.Sprintf(`(["%s"])`, label)
+		case TypeEvent:
+			shape = fmt.Sprintf(`["%s"]`, label)
+		case TypeFunction:
+			shape = fmt.Sprintf(`[["%s"]]`, label)
+		case TypeDatasource:
+			shape = fmt.Sprintf(`[("%s")]`, label)
+		}
+		fmt.Fprintf(&b, "  %s%s\n", ids[node.ID], shape)
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+	}
+
+	var dead, missing []string
+	for _, node := range g.Nodes {
+		if node.Dead {
+			dead = append(dead, ids[node.ID])
+		}
+		if node.Missing {
+			missing = append(missing, ids[node.ID])
+		}
+	}
+	if len(dead) > 0 {
+		b.WriteString("  classDef dead fill:#fde2e2,stroke:#d33,stroke-dasharray:5 5\n")
+		fmt.Fprintf(&b, "  class %s dead\n", strings.Join(dead, ","))
+	}
+	if len(missing) > 0 {
+		b.WriteString("  classDef missing fill:#fff4d6,stroke:#d90\n")
+		fmt.Fprintf(&b, "  class %s missing\n", strings.Join(missing, ","))
+	}
+	return b.String()
+}
+
+// Dot renders the graph in the Graphviz DOT language
+func (g *Graph) Dot() string {
+	var b strings.Builder
+	b.WriteString("digraph godspeed {\n  rankdir=LR;\n  node [fontname=\"Helvetica\"];\n")
+
+	shapes := map[string]string{
+		TypeEventSource: "ellipse",
+		TypeEvent:       "box",
+		TypeFunction:    "component",
+		TypeDatasource:  "cylinder",
+	}
+
+	for _, node := range g.Nodes {
+		attrs := fmt.Sprintf("label=%s, shape=%s", quote(node.Label), shapes[node.Type])
+		if node.Dead {
+			attrs += `, style="dashed,filled", color="#d33", fillcolor="#fde2e2"`
+		} else if node.Missing {
+			attrs += `, style=filled, color="#d90", fillcolor="#fff4d6"`
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", quote(node.ID), attrs)
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", quote(edge.From), quote(edge.To))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// addNode adds a node unless it exists and returns its id. A node that is
+// referenced without a file is marked as missing until its file is known.
+func (g *Graph) addNode(typ, label, file string) string {
+	id := nodeID(typ, label)
+	if node, ok := g.index[id]; ok {
+		if file != "" && node.File == "" {
+			node.File = file
+			node.Missing = false
+		}
+		return id
+	}
+
+	node := &Node{ID: id, Type: typ, Label: label, File: file, Missing: file == ""}
+	g.index[id] = node
+	g.Nodes = append(g.Nodes, node)
+	return id
+}
+
+// addEdge adds an edge unless it exists
+func (g *Graph) addEdge(from, to string) {
+	edge := Edge{From: from, To: to}
+	if !g.edges[edge] {
+		g.edges[edge] = true
+		g.Edges = append(g.Edges, edge)
+	}
+}
+
+// markDead marks the functions no event can reach and the datasources no
+// function calls
+func (g *Graph) markDead() {
+	reached := make(map[string]bool)
+	for _, node := range g.Nodes {
+		if node.Type == TypeEvent {
+			for id := range g.reachable(node.ID, true) {
+				reached[id] = true
+			}
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if node.Type == TypeFunction || node.Type == TypeDatasource {
+			node.Dead = !reached[node.ID]
+		}
+	}
+}
+
+// Referenced reports whether the node of a type and name has an edge, e.g.
+// an eventsource with events or a datasource a function calls
+func (g *Graph) Referenced(typ, name string) bool {
+	id := nodeID(typ, name)
+	for _, edge := range g.Edges {
+		if edge.From == id || edge.To == id {
+			return true
+		}
+	}
+	return false
+}
+
+// reachable returns the ids reachable from id following edges forwards, or
+// the ids that reach id when forward is false, including id itself
+func (g *Graph) reachable(id string, forward bool) map[string]bool {
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.Edges {
+			from, to := edge.From, edge.To
+			if !forward {
+				from, to = to, from
+			}
+			if from == current && !seen[to] {
+				seen[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	return seen
+}
+
+// filter returns the subgraph made of the given nodes
+func (g *Graph) filter(keep map[string]bool) *Graph {
+	result := &Graph{index: make(map[string]*Node), edges: make(map[Edge]bool)}
+	for _, node := range g.Nodes {
+		if keep[node.ID] {
+			result.index[node.ID] = node
+			result.Nodes = append(result.Nodes, node)
+		}
+	}
+	for _, edge := range g.Edges {
+		if keep[edge.From] && keep[edge.To] {
+			result.addEdge(edge.From, edge.To)
+		}
+	}
+	return result
+}
+
+// shortIDs assigns mermaid-safe ids to the nodes
+func (g *Graph) shortIDs() map[string]string {
+	ids := make(map[string]string, len(g.Nodes))
+	for i, node := range g.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+	}
+	return ids
+}
+
+// functionFiles maps the function names of src/functions ("users.get") to their files
+func functionFiles() (map[string]string, error) {
+	result := make(map[string]string)
+	dir := filepath.Join("src", "functions")
+	if !utils.DirExists(dir) {
+		return result, nil
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if info.IsDir() || strings.HasSuffix(path, ".d.ts") {
+			return nil
+		}
+		if ext != ".ts" && ext != ".js" && ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, strings.TrimSuffix(path, ext))
+		if err != nil {
+			return err
+		}
+		name := strings.ReplaceAll(filepath.ToSlash(rel), "/", ".")
+		if _, ok := result[name]; !ok {
+			result[name] = path
+		}
+		return nil
+	})
+	return result, err
+}
+
+// datasourceFiles maps the datasources of src/datasources to their config
+// files, including prisma schemas
+func datasourceFiles() (map[string]string, error) {
+	result := make(map[string]string)
+	entries, err := ioutil.ReadDir(filepath.Join("src", "datasources"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".prisma") {
+			continue
+		}
+		result[strings.TrimSuffix(entry.Name(), ext)] = filepath.Join("src", "datasources", entry.Name())
+	}
+	return result, nil
+}
+
+// functionCalls returns the fns called by a workflow, or the datasources
+// ("datasource.<name>") and functions used by a TypeScript function
+func functionCalls(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []string
+	ext := filepath.Ext(path)
+	if ext == ".yaml" || ext == ".yml" {
+		var workflow interface{}
+		if err := yaml.Unmarshal(data, &workflow); err != nil {
+			return nil, err
+		}
+		collectFns(schema.Normalize(workflow), &calls)
+		return calls, nil
+	}
+
+	source := string(data)
+	for _, pattern := range tsDatasourcePatterns {
+		for _, match := range pattern.FindAllStringSubmatch(source, -1) {
+			calls = appendUnique(calls, datasourcePrefix+match[1])
+		}
+	}
+	for _, pattern := range tsFunctionPatterns {
+		for _, match := range pattern.FindAllStringSubmatch(source, -1) {
+			calls = appendUnique(calls, match[1])
+		}
+	}
+	return calls, nil
+}
+
+// collectFns appends every "fn" value found in a workflow, including the
+// tasks nested in control flow functions such as com.gs.parallel
+func collectFns(node interface{}, calls *[]string) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if fn, ok := value["fn"].(string); ok {
+			*calls = appendUnique(*calls, fn)
+		}
+		for _, key := range sortedAnyKeys(value) {
+			collectFns(value[key], calls)
+		}
+	case []interface{}:
+		for _, item := range value {
+			collectFns(item, calls)
+		}
+	}
+}
+
+// nodeID returns the unique id of a node
+func nodeID(typ, label string) string {
+	return typ + ":" + label
+}
+
+// quote quotes a DOT identifier
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+// appendUnique appends value to list unless it is already present
+func appendUnique(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// sortedKeys returns the keys of m in a stable order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedAnyKeys returns the keys of m in a stable order
+func sortedAnyKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
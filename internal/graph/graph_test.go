@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// inProject writes files into a temporary project and makes it the working
+// directory for the rest of the test
+func inProject(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestFunctionCalls(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "datasource property access",
+			file:   "get.ts",
+			source: "const { datasources } = ctx;\nawait datasources.schema.client.user.findMany();\nawait datasources.schema.client.post.count();\n",
+			want:   []string{"datasource.schema"},
+		},
+		{
+			name:   "datasource index access",
+			file:   "get.ts",
+			source: "await ctx.datasources['api'].execute(ctx, args);\nawait datasources[ \"cache\" ].get(key);\nawait datasources[`mongo`].find();\n",
+			want:   []string{"datasource.api", "datasource.cache", "datasource.mongo"},
+		},
+		{
+			name:   "function calls",
+			file:   "create.ts",
+			source: "const validate = ctx.functions['users.validate'];\nawait functions[\"notify\"](ctx);\nawait functions['users.validate'](ctx);\n",
+			want:   []string{"users.validate", "notify"},
+		},
+		{
+			name:   "dynamic references are ignored",
+			file:   "dynamic.js",
+			source: "await datasources[name].execute();\nawait functions[fn](ctx);\n",
+		},
+		{
+			name: "workflow tasks",
+			file: "flow.yaml",
+			source: `summary: create
+tasks:
+  - id: parallel
+    fn: com.gs.parallel
+    tasks:
+      - id: a
+        fn: datasource.api.post./orders
+      - id: b
+        fn: users.notify
+  - id: c
+    fn: users.notify
+`,
+			want: []string{"com.gs.parallel", "datasource.api.post./orders", "users.notify"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := ioutil.WriteFile(path, []byte(tt.source), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := functionCalls(path)
+			if err != nil {
+				t.Fatalf("functionCalls: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("functionCalls = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	inProject(t, map[string]string{
+		"src/eventsources/http.yaml": "type: express\n",
+		"src/datasources/api.yaml":   "type: axios\n",
+		"src/datasources/cache.yaml": "type: redis\n",
+		"src/events/users.yaml":      "http.get./users:\n  fn: users.list\n",
+		"src/functions/users/list.ts": "export default async function (ctx) {\n" +
+			"  await ctx.functions['users.format'](ctx);\n" +
+			"  return ctx.datasources.api.execute(ctx, {});\n}\n",
+		"src/functions/users/format.ts": "export default async function (ctx) {}\n",
+		"src/functions/unused.ts":       "export default async function (ctx) { return ctx.datasources.cache.get('x'); }\n",
+	})
+
+	g, err := Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	wantEdges := map[Edge]bool{
+		{From: "eventsource:http", To: "event:http.get./users"}:    true,
+		{From: "event:http.get./users", To: "function:users.list"}: true,
+		{From: "function:unused", To: "datasource:cache"}:          true,
+		{From: "function:users.list", To: "datasource:api"}:        true,
+		{From: "function:users.list", To: "function:users.format"}: true,
+	}
+	gotEdges := make(map[Edge]bool)
+	for _, edge := range g.Edges {
+		gotEdges[edge] = true
+	}
+	if !reflect.DeepEqual(gotEdges, wantEdges) {
+		t.Errorf("edges = %v, want %v", g.Edges, wantEdges)
+	}
+
+	dead := make(map[string]bool)
+	for _, node := range g.Nodes {
+		if node.Dead {
+			dead[node.ID] = true
+		}
+	}
+	if want := map[string]bool{"function:unused": true, "datasource:cache": true}; !reflect.DeepEqual(dead, want) {
+		t.Errorf("dead nodes = %v, want %v", dead, want)
+	}
+}
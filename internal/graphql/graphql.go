@@ -11,125 +11,85 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/events"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/prompt"
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
 	"github.com/godspeedsystems/godspeed-cli/internal/utils"
 	"gopkg.in/yaml.v3"
 )
 
-// Definition represents a schema definition
-type Definition struct {
-	// Definitions can be various complex structures
-	// This is a simplified representation
-	Properties map[string]interface{} `yaml:"properties,omitempty"`
-	Type       string                 `yaml:"type,omitempty"`
-	Items      map[string]interface{} `yaml:"items,omitempty"`
-	Ref        string                 `yaml:"$ref,omitempty"`
+// SchemaResult is an eventsource in the JSON result of gen-graphql-schema
+type SchemaResult struct {
+	EventSource string `json:"eventsource"`
+	Path        string `json:"path"`
+	OK          bool   `json:"ok"`
+	Error       string `json:"error,omitempty"`
 }
 
-// EventSchema represents an event schema
-type EventSchema struct {
-	Summary     string                   `yaml:"summary,omitempty"`
-	Description string                   `yaml:"description,omitempty"`
-	Body        map[string]interface{}   `yaml:"body,omitempty"`
-	Parameters  []map[string]interface{} `yaml:"parameters,omitempty"`
-	Params      []map[string]interface{} `yaml:"params,omitempty"`
-	Data        map[string]interface{}   `yaml:"data,omitempty"`
-	Responses   map[string]interface{}   `yaml:"responses,omitempty"`
+// Result is the JSON result of gen-graphql-schema
+type Result struct {
+	Schemas []SchemaResult `json:"schemas"`
 }
 
-// LoadYaml loads yaml files from a directory
-func LoadYaml(dirPath string, recursive bool) (map[string]EventSchema, error) {
-	result := make(map[string]EventSchema)
-
-	files, err := filepath.Glob(filepath.Join(dirPath, "*.yaml"))
-	if err != nil {
-		return nil, err
-	}
-
-	// Load all yaml files in the directory
-	for _, file := range files {
-		data, err := ioutil.ReadFile(file)
-		if err != nil {
-			return nil, err
-		}
-
-		var schema EventSchema
-		if err := yaml.Unmarshal(data, &schema); err != nil {
-			return nil, err
-		}
-
-		// Use the filename without extension as the key
-		basename := filepath.Base(file)
-		key := strings.TrimSuffix(basename, filepath.Ext(basename))
-		result[key] = schema
-	}
-
-	// If recursive, load from subdirectories
-	if recursive {
-		subdirs, err := ioutil.ReadDir(dirPath)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, subdir := range subdirs {
-			if subdir.IsDir() {
-				subPath := filepath.Join(dirPath, subdir.Name())
-				subResults, err := LoadYaml(subPath, recursive)
-				if err != nil {
-					return nil, err
-				}
-
-				// Add subresults to main results
-				for k, v := range subResults {
-					result[k] = v
-				}
-			}
-		}
-	}
-
-	return result, nil
-}
-
-// GenerateSchema generates GraphQL schema from events definitions
-func GenerateSchema() {
-	if !utils.IsGodspeedProject() {
-		return
+// GenerateSchema generates GraphQL schema from events definitions for the
+// given GraphQL eventsources, or the ones the user selects if none are given
+func GenerateSchema(selectedSources []string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
 	}
 
 	// Check for GraphQL event sources
 	eventsources, err := findGraphQLEventSources()
 	if err != nil {
-		color.Red("Error finding GraphQL event sources: %v", err)
-		return
+		return fmt.Errorf("finding GraphQL event sources: %w", err)
 	}
 
 	if len(eventsources) == 0 {
-		color.Red("No GraphQL event sources found.")
-		return
+		return fmt.Errorf("no GraphQL event sources found")
 	}
 
 	// Prompt user to select event sources
-	var selectedSources []string
-	prompt := &survey.MultiSelect{
-		Message: "Please select the Graphql Event Sources for which you wish to generate the Graphql schema from Godspeed event defs:",
-		Options: eventsources,
-	}
+	if len(selectedSources) == 0 {
+		question := &survey.MultiSelect{
+			Message: "Please select the Graphql Event Sources for which you wish to generate the Graphql schema from Godspeed event defs:",
+			Options: eventsources,
+		}
 
-	if err := survey.AskOne(prompt, &selectedSources); err != nil {
-		color.Red("Error: %v", err)
-		return
+		if err := prompt.Ask(question, &selectedSources, "--eventsource"); err != nil {
+			return err
+		}
+	}
+	for _, eventSource := range selectedSources {
+		if !contains(eventsources, eventSource) {
+			return exitcode.New(exitcode.Usage, "%s is not a GraphQL eventsource. Use one of: %s", eventSource, strings.Join(eventsources, ", "))
+		}
 	}
 
 	if len(selectedSources) == 0 {
-		color.Red("Please select at least one GraphQL eventsource")
-		return
+		return fmt.Errorf("please select at least one GraphQL eventsource")
 	}
 
 	// Create Swagger schema and then convert to GraphQL
+	result := Result{Schemas: []SchemaResult{}}
+	defer output.Set(&result)
+
+	failed := 0
 	for _, eventSource := range selectedSources {
+		generated := SchemaResult{EventSource: eventSource, Path: schemaPath(eventSource), OK: true}
 		if err := createGraphQLSchema(eventSource); err != nil {
 			color.Red("Error creating GraphQL schema for %s: %v", eventSource, err)
+			generated.OK = false
+			generated.Error = err.Error()
+			failed++
 		}
+		result.Schemas = append(result.Schemas, generated)
 	}
+	if failed > 0 {
+		return fmt.Errorf("creating the GraphQL schema failed for %d of %d eventsources", failed, len(selectedSources))
+	}
+	return nil
 }
 
 // findGraphQLEventSources finds all GraphQL event sources in the project
@@ -178,37 +138,21 @@ func createGraphQLSchema(eventSourceName string) error {
 	eventPath := filepath.Join("src", "events")
 	definitionsPath := filepath.Join("src", "definitions")
 
-	allEventsSchema, err := LoadYaml(eventPath, true)
+	allEvents, err := events.Load(eventPath)
 	if err != nil {
 		return err
 	}
 
-	definitions, err := LoadYaml(definitionsPath, false)
+	definitions, err := schema.LoadDefinitions(definitionsPath)
 	if err != nil {
-		// Definitions might not exist, so we can continue
-		definitions = make(map[string]EventSchema)
+		return err
 	}
 
-	// Filter events for this event source
-	eventSchemas := make(map[string]EventSchema)
-	for key, schema := range allEventsSchema {
-		parts := strings.Split(key, ".")
-		if len(parts) > 0 {
-			eventSourceKey := parts[0]
-
-			if eventSourceKey == eventSourceName {
-				eventSchemas[key] = schema
-				continue
-			}
-
-			// Check for multiple event sources ("http & graphql")
-			eventSources := strings.Split(eventSourceKey, "&")
-			for _, es := range eventSources {
-				if strings.TrimSpace(es) == eventSourceName {
-					eventSchemas[key] = schema
-					break
-				}
-			}
+	// Filter events for this event source, including multi source keys ("http & graphql")
+	eventSchemas := make(map[string]events.Event)
+	for key, event := range allEvents {
+		if event.HasSource(eventSourceName) {
+			eventSchemas[key] = event
 		}
 	}
 
@@ -229,7 +173,10 @@ func createGraphQLSchema(eventSourceName string) error {
 	}
 
 	// Generate Swagger schema
-	swaggerSchema := generateSwaggerJSON(eventSchemas, definitions, eventSourceConfig)
+	swaggerSchema, err := generateSwaggerJSON(eventSchemas, definitions, eventSourceConfig)
+	if err != nil {
+		return err
+	}
 
 	// Save swagger file to temporary location
 	tempDir := os.TempDir()
@@ -250,8 +197,9 @@ func createGraphQLSchema(eventSourceName string) error {
 	return generateGraphQLSchemaFromSwagger(eventSourceName, swaggerFilePath)
 }
 
-// generateSwaggerJSON generates a Swagger JSON schema from event schemas
-func generateSwaggerJSON(eventsSchema map[string]EventSchema, definitions map[string]EventSchema, _ map[string]interface{}) map[string]interface{} {
+// generateSwaggerJSON generates an OpenAPI document from event schemas. The
+// definitions become components, and $refs to them are rewritten to point there.
+func generateSwaggerJSON(eventsSchema map[string]events.Event, definitions *schema.Definitions, _ map[string]interface{}) (map[string]interface{}, error) {
 	swaggerCommonPart := map[string]interface{}{
 		"openapi": "3.0.0",
 		"info": map[string]interface{}{
@@ -276,43 +224,45 @@ func generateSwaggerJSON(eventsSchema map[string]EventSchema, definitions map[st
 	paths := finalSpec["paths"].(map[string]interface{})
 
 	// Process each event schema
-	for event, schema := range eventsSchema {
-		parts := strings.Split(event, ".")
-		if len(parts) < 3 {
+	for _, key := range events.SortedKeys(eventsSchema) {
+		event := eventsSchema[key]
+		method := event.Method()
+		if method == "" {
 			continue
 		}
 
-		apiEndPoint := parts[2]
 		// Convert path parameters from :param format to {param} format
-		apiEndPoint = strings.ReplaceAll(apiEndPoint, ":", "{")
-		apiEndPoint = strings.ReplaceAll(apiEndPoint, "/{", "/{")
-		apiEndPoint = strings.ReplaceAll(apiEndPoint, "}", "}")
-
-		method := parts[1]
+		apiEndPoint := event.OpenAPIPath()
 
 		// Initialize method specification
 		methodSpec := map[string]interface{}{
-			"summary":     schema.Summary,
-			"description": schema.Description,
-			"requestBody": schema.Body,
-			"responses":   schema.Responses,
+			"summary":     event.Summary,
+			"description": event.Description,
+		}
+
+		if body := event.RequestBody(); body != nil {
+			requestBody, err := definitions.Rewrite(body, schema.ComponentsRefPrefix)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			methodSpec["requestBody"] = requestBody
+		}
+
+		if event.Responses != nil {
+			responses, err := definitions.Rewrite(event.Responses, schema.ComponentsRefPrefix)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			methodSpec["responses"] = responses
 		}
 
 		// Handle parameters
-		if len(schema.Parameters) > 0 {
-			methodSpec["parameters"] = schema.Parameters
-		} else if len(schema.Params) > 0 {
-			methodSpec["parameters"] = schema.Params
-		} else if schema.Data != nil && schema.Data["schema"] != nil {
-			schemaMap, ok := schema.Data["schema"].(map[string]interface{})
-			if ok {
-				if params, ok := schemaMap["params"].([]map[string]interface{}); ok {
-					methodSpec["parameters"] = params
-				}
-				if body, ok := schemaMap["body"].(map[string]interface{}); ok {
-					methodSpec["requestBody"] = body
-				}
+		if params := event.RequestParams(); len(params) > 0 {
+			parameters, err := definitions.Rewrite(params, schema.ComponentsRefPrefix)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
 			}
+			methodSpec["parameters"] = parameters
 		}
 
 		// Set it in the overall schema
@@ -324,39 +274,52 @@ func generateSwaggerJSON(eventsSchema map[string]EventSchema, definitions map[st
 		pathMap[method] = methodSpec
 	}
 
-	// Add definitions if available
-	definitionsMap := make(map[string]interface{})
-	for name, def := range definitions {
-		// Convert the definition to a map
-		defMap := make(map[string]interface{})
-		defData, _ := yaml.Marshal(def)
-		yaml.Unmarshal(defData, &defMap)
-		definitionsMap[name] = defMap
+	// Add definitions as component schemas if available
+	components, err := definitions.Components(schema.ComponentsRefPrefix)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(definitionsMap) > 0 {
-		finalSpec["definitions"] = definitionsMap
+	if len(components) > 0 {
+		finalSpec["components"] = map[string]interface{}{
+			"schemas": components,
+		}
 	}
 
-	return finalSpec
+	return finalSpec, nil
 }
 
 // generateGraphQLSchemaFromSwagger generates GraphQL schema from Swagger schema
 func generateGraphQLSchemaFromSwagger(eventSourceName, swaggerFilePath string) error {
-	outputPath := filepath.Join("src", "eventsources", fmt.Sprintf("%s.graphql", eventSourceName))
+	outputPath := schemaPath(eventSourceName)
 
 	// Use swagger-to-graphql to generate GraphQL schema
 	cmd := exec.Command("npx", "swagger-to-graphql", "--swagger-schema="+swaggerFilePath)
-	output, err := cmd.Output()
+	out, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to generate GraphQL schema: %v", err)
 	}
 
 	// Write GraphQL schema to file
-	if err := ioutil.WriteFile(outputPath, output, 0644); err != nil {
+	if err := ioutil.WriteFile(outputPath, out, 0644); err != nil {
 		return err
 	}
 
 	color.Green("GraphQL schema generated successfully for eventsource %s at %s", eventSourceName, outputPath)
 	return nil
 }
+
+// schemaPath returns the path of the GraphQL schema of an eventsource
+func schemaPath(eventSourceName string) string {
+	return filepath.Join("src", "eventsources", fmt.Sprintf("%s.graphql", eventSourceName))
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,490 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/config"
+	"github.com/godspeedsystems/godspeed-cli/internal/events"
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultOverridesDir holds the per-event canned responses, one file per
+// event named after its type name, e.g. mocks/HttpGetUsersById.yaml
+const DefaultOverridesDir = "mocks"
+
+// watchedDirs are polled for changes to hot-reload the routes
+var watchedDirs = []string{
+	filepath.Join("src", "events"),
+	filepath.Join("src", "definitions"),
+	filepath.Join("src", "eventsources"),
+}
+
+// Override is a canned response for an event
+type Override struct {
+	Status  int               `yaml:"status" json:"status"`
+	Headers map[string]string `yaml:"headers" json:"headers"`
+	Body    interface{}       `yaml:"body" json:"body"`
+}
+
+// route is an http event served by the mock server
+type route struct {
+	event    events.Event
+	method   string
+	segments []string
+}
+
+// server serves the routes built from the project's event definitions
+type server struct {
+	mu           sync.RWMutex
+	routes       []route
+	definitions  *schema.Definitions
+	overridesDir string
+}
+
+// Serve starts a mock server for the project's http events on port. It
+// blocks until the server fails.
+func Serve(port int, overridesDir string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	if port == 0 {
+		port = config.ServicePort()
+	}
+	if port == 0 {
+		port = 3000
+	}
+
+	if overridesDir == "" {
+		overridesDir = DefaultOverridesDir
+	}
+
+	s := &server{overridesDir: overridesDir}
+	if err := s.load(); err != nil {
+		return fmt.Errorf("loading events: %w", err)
+	}
+
+	s.mu.RLock()
+	for _, r := range s.routes {
+		fmt.Printf("  %-7s /%s\n", strings.ToUpper(r.method), strings.Join(r.segments, "/"))
+	}
+	count := len(s.routes)
+	s.mu.RUnlock()
+
+	if count == 0 {
+		color.Yellow("No http events found in src/events. Routes will be added as you define them.")
+	}
+
+	go s.watch()
+
+	color.Green("Mock server listening on http://localhost:%d", port)
+	color.Cyan("Canned responses are read from %s/<EventTypeName>.yaml", overridesDir)
+
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), s); err != nil {
+		return fmt.Errorf("running mock server: %w", err)
+	}
+	return nil
+}
+
+// load (re)builds the routes from the event definitions
+func (s *server) load() error {
+	allEvents, err := events.Load(filepath.Join("src", "events"))
+	if err != nil {
+		return err
+	}
+
+	definitions, err := schema.LoadDefinitions(filepath.Join("src", "definitions"))
+	if err != nil {
+		return err
+	}
+
+	basePaths := make(map[string]string)
+	var routes []route
+	for _, key := range events.SortedKeys(allEvents) {
+		event := allEvents[key]
+		if !event.IsHTTP() {
+			continue
+		}
+
+		for _, source := range event.Sources() {
+			basePath, ok := basePaths[source]
+			if !ok {
+				esConfig, _ := events.LoadEventSource(source)
+				if events.IsGraphQL(esConfig) {
+					basePath = "-"
+				} else {
+					basePath = events.BasePath(esConfig)
+				}
+				basePaths[source] = basePath
+			}
+
+			if basePath == "-" {
+				continue
+			}
+
+			routes = append(routes, route{
+				event:    event,
+				method:   event.Method(),
+				segments: splitPath(basePath + event.Path()),
+			})
+		}
+	}
+
+	// Prefer static segments over :params, so /users/me wins over /users/:id
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i].segments, routes[j].segments
+		for k := 0; k < len(a) && k < len(b); k++ {
+			aParam, bParam := strings.HasPrefix(a[k], ":"), strings.HasPrefix(b[k], ":")
+			if aParam != bParam {
+				return bParam
+			}
+		}
+		return false
+	})
+
+	s.mu.Lock()
+	s.routes = routes
+	s.definitions = definitions
+	s.mu.Unlock()
+	return nil
+}
+
+// watch polls the event, definition and eventsource files and reloads the
+// routes when any of them change
+func (s *server) watch() {
+	last := fingerprint()
+	for range time.Tick(time.Second) {
+		current := fingerprint()
+		if current == last {
+			continue
+		}
+		last = current
+
+		if err := s.load(); err != nil {
+			color.Red("Error reloading events, keeping the previous routes: %v", err)
+			continue
+		}
+
+		s.mu.RLock()
+		count := len(s.routes)
+		s.mu.RUnlock()
+		color.Yellow("Reloaded event definitions (%d routes)", count)
+	}
+}
+
+// fingerprint summarizes the names, sizes and modification times of the
+// watched files
+func fingerprint() string {
+	var parts []string
+	for _, dir := range watchedDirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				parts = append(parts, fmt.Sprintf("%s:%d:%d", path, info.Size(), info.ModTime().UnixNano()))
+			}
+			return nil
+		})
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|")
+}
+
+// ServeHTTP matches the request to an event, validates it and responds
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	routes := s.routes
+	definitions := s.definitions
+	s.mu.RUnlock()
+
+	segments := splitPath(r.URL.Path)
+	method := strings.ToLower(r.Method)
+
+	var matched *route
+	var pathParams map[string]string
+	pathMatched := false
+	for i := range routes {
+		params, ok := match(routes[i].segments, segments)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if routes[i].method == method {
+			matched = &routes[i]
+			pathParams = params
+			break
+		}
+	}
+
+	if matched == nil {
+		status := http.StatusNotFound
+		if pathMatched {
+			status = http.StatusMethodNotAllowed
+		}
+		s.respond(w, r, status, nil, map[string]interface{}{"message": http.StatusText(status)})
+		return
+	}
+
+	if errors := validateRequest(definitions, matched.event, r, pathParams); len(errors) > 0 {
+		s.respond(w, r, http.StatusBadRequest, nil, map[string]interface{}{
+			"message": "Request validation failed",
+			"errors":  errors,
+		})
+		return
+	}
+
+	override, err := s.loadOverride(matched.event)
+	if err != nil {
+		color.Red("Error reading override for %s: %v", matched.event.Key, err)
+	}
+	if override != nil {
+		status := override.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		s.respond(w, r, status, override.Headers, override.Body)
+		return
+	}
+
+	status, body := exampleResponse(definitions, matched.event)
+	s.respond(w, r, status, nil, body)
+}
+
+// respond writes a JSON response and logs the request
+func (s *server) respond(w http.ResponseWriter, r *http.Request, status int, headers map[string]string, body interface{}) {
+	for key, value := range headers {
+		w.Header().Set(key, value)
+	}
+
+	if body != nil {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	} else {
+		w.WriteHeader(status)
+	}
+
+	fmt.Printf("%s %s -> %d\n", r.Method, r.URL.RequestURI(), status)
+}
+
+// loadOverride reads the canned response for an event, if one exists
+func (s *server) loadOverride(event events.Event) (*Override, error) {
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		path := filepath.Join(s.overridesDir, event.TypeName()+ext)
+		if !utils.FileExists(path) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var override Override
+		if err := yaml.Unmarshal(data, &override); err != nil {
+			return nil, err
+		}
+		override.Body = schema.Normalize(override.Body)
+		return &override, nil
+	}
+	return nil, nil
+}
+
+// validateRequest checks the path, query, header and body inputs of a request
+// against the event's declared params and body
+func validateRequest(definitions *schema.Definitions, event events.Event, r *http.Request, pathParams map[string]string) []string {
+	var errors []string
+
+	query := r.URL.Query()
+	for _, param := range event.RequestParams() {
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		required, _ := param["required"].(bool)
+
+		var raw string
+		var present bool
+		switch in {
+		case "path":
+			raw, present = pathParams[name]
+		case "query":
+			raw, present = query.Get(name), query.Has(name)
+		case "header":
+			raw = r.Header.Get(name)
+			present = raw != ""
+		default:
+			continue
+		}
+
+		location := fmt.Sprintf("%s.%s", in, name)
+		if !present {
+			if required {
+				errors = append(errors, location+": is required")
+			}
+			continue
+		}
+
+		errors = append(errors, definitions.Validate(param["schema"], coerce(definitions, param["schema"], raw), location)...)
+	}
+
+	body := event.RequestBody()
+	if body == nil {
+		return errors
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return append(errors, "body: "+err.Error())
+	}
+
+	if len(strings.TrimSpace(string(data))) == 0 {
+		if required, _ := body["required"].(bool); required {
+			errors = append(errors, "body: is required")
+		}
+		return errors
+	}
+
+	bodySchema := jsonSchema(body)
+	if bodySchema == nil {
+		return errors
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return append(errors, "body: is not valid JSON")
+	}
+
+	return append(errors, definitions.Validate(bodySchema, value, "body")...)
+}
+
+// exampleResponse synthesizes the response of an event from the schema of
+// its first success response
+func exampleResponse(definitions *schema.Definitions, event events.Event) (int, interface{}) {
+	if len(event.Responses) == 0 {
+		return http.StatusOK, map[string]interface{}{}
+	}
+
+	codes := make([]string, 0, len(event.Responses))
+	for code := range event.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	chosen := ""
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			chosen = code
+			break
+		}
+	}
+	if chosen == "" {
+		if _, ok := event.Responses["default"]; ok {
+			chosen = "default"
+		} else {
+			chosen = codes[0]
+		}
+	}
+
+	status, err := strconv.Atoi(chosen)
+	if err != nil {
+		status = http.StatusOK
+	}
+
+	response, _ := event.Responses[chosen].(map[string]interface{})
+	if content, ok := response["content"].(map[string]interface{}); ok {
+		if media, ok := content["application/json"].(map[string]interface{}); ok {
+			if example, ok := media["example"]; ok {
+				return status, example
+			}
+		}
+	}
+
+	responseSchema := jsonSchema(response)
+	if responseSchema == nil {
+		return status, nil
+	}
+	return status, definitions.Example(responseSchema)
+}
+
+// jsonSchema returns the application/json schema of a body or response
+func jsonSchema(s map[string]interface{}) interface{} {
+	if content, ok := s["content"].(map[string]interface{}); ok {
+		if media, ok := content["application/json"].(map[string]interface{}); ok {
+			return media["schema"]
+		}
+		return nil
+	}
+	return s["schema"]
+}
+
+// coerce converts a raw path, query or header value to the type its schema
+// declares, leaving it as a string if it does not parse
+func coerce(definitions *schema.Definitions, paramSchema interface{}, raw string) interface{} {
+	resolved, err := definitions.Resolve(paramSchema)
+	if err != nil {
+		return raw
+	}
+
+	s, _ := resolved.(map[string]interface{})
+	typ, _ := s["type"].(string)
+	switch typ {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	case "array":
+		var items []interface{}
+		for _, item := range strings.Split(raw, ",") {
+			items = append(items, coerce(definitions, s["items"], item))
+		}
+		return items
+	}
+	return raw
+}
+
+// match matches request path segments against a route's segments, returning
+// the values of its :params
+func match(routeSegments, pathSegments []string) (map[string]string, bool) {
+	if len(routeSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range routeSegments {
+		if strings.HasPrefix(segment, ":") {
+			params[strings.TrimPrefix(segment, ":")] = pathSegments[i]
+			continue
+		}
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// splitPath splits a url path into its non-empty segments
+func splitPath(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
@@ -0,0 +1,584 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// methods are the OpenAPI operation keys of a path item, in output order
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// refPrefixes are the schema locations of OpenAPI 3 and Swagger 2 documents
+var refPrefixes = []string{"#/components/schemas/", "#/definitions/"}
+
+// Result is the JSON result of import openapi. Skipped files already
+// existed and were left untouched.
+type Result struct {
+	Written []string `json:"written"`
+	Skipped []string `json:"skipped"`
+}
+
+// event is an event definition in the order its keys are written
+type event struct {
+	Fn          string                 `yaml:"fn"`
+	Summary     string                 `yaml:"summary,omitempty"`
+	Description string                 `yaml:"description,omitempty"`
+	Body        interface{}            `yaml:"body,omitempty"`
+	Params      []interface{}          `yaml:"params,omitempty"`
+	Responses   map[string]interface{} `yaml:"responses,omitempty"`
+}
+
+// workflow is the stub YAML workflow generated for an operation
+type workflow struct {
+	Summary string `yaml:"summary,omitempty"`
+	Tasks   []task `yaml:"tasks"`
+}
+
+// task is a single workflow task
+type task struct {
+	ID          string                 `yaml:"id"`
+	Description string                 `yaml:"description,omitempty"`
+	Fn          string                 `yaml:"fn"`
+	Args        map[string]interface{} `yaml:"args"`
+}
+
+// importer converts an OpenAPI document into Godspeed files
+type importer struct {
+	doc         map[string]interface{}
+	eventSource string
+	force       bool
+	written     []string
+	skipped     []string
+}
+
+// Import scaffolds events, definitions and stub workflows from an OpenAPI
+// (or Swagger 2) document. Existing files are skipped unless force is set.
+func Import(specPath, eventSource string, force bool) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(specPath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", specPath, err)
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return exitcode.New(exitcode.ValidationFailure, "parsing %s: %v", specPath, err)
+	}
+
+	doc, ok := schema.Normalize(raw).(map[string]interface{})
+	if !ok {
+		return exitcode.New(exitcode.ValidationFailure, "%s is not an OpenAPI document", specPath)
+	}
+
+	if paths, _ := doc["paths"].(map[string]interface{}); len(paths) == 0 {
+		return exitcode.New(exitcode.ValidationFailure, "%s has no paths", specPath)
+	}
+
+	if eventSource == "" {
+		eventSource = "http"
+	}
+
+	imp := &importer{doc: doc, eventSource: eventSource, force: force, written: []string{}, skipped: []string{}}
+	defer func() { output.Set(Result{Written: imp.written, Skipped: imp.skipped}) }()
+
+	if err := imp.importDefinitions(); err != nil {
+		return fmt.Errorf("importing definitions: %w", err)
+	}
+
+	if err := imp.importPaths(); err != nil {
+		return fmt.Errorf("importing paths: %w", err)
+	}
+
+	color.Green("Imported %s: %d files written, %d existing files left untouched.", specPath, len(imp.written), len(imp.skipped))
+	if len(imp.skipped) > 0 && !force {
+		color.Yellow("Use --force to overwrite existing files.")
+	}
+	return nil
+}
+
+// importDefinitions writes each component schema to src/definitions/<Name>.yaml
+func (imp *importer) importDefinitions() error {
+	schemas := imp.componentSchemas()
+	for _, name := range sortedKeys(schemas) {
+		converted, err := imp.convert(schemas[name], nil)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		path := filepath.Join("src", "definitions", name+".yaml")
+		if err := imp.write(path, map[string]interface{}{name: converted}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importPaths writes the events of each top-level path segment to
+// src/events/<segment>.yaml and a stub workflow per operation
+func (imp *importer) importPaths() error {
+	paths, _ := imp.doc["paths"].(map[string]interface{})
+	files := make(map[string]*yaml.Node)
+	stubs := make(map[string]workflow)
+
+	for _, path := range sortedKeys(paths) {
+		pathItem, _ := imp.deref(paths[path]).(map[string]interface{})
+		pathParams, _ := pathItem["parameters"].([]interface{})
+
+		for _, method := range methods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			fn := functionName(operation, method, path)
+			ev, err := imp.buildEvent(operation, pathParams, fn)
+			if err != nil {
+				return fmt.Errorf("%s %s: %v", strings.ToUpper(method), path, err)
+			}
+
+			key := fmt.Sprintf("%s.%s.%s", imp.eventSource, method, godspeedPath(path))
+			fileName := eventFileName(path)
+			if files[fileName] == nil {
+				files[fileName] = &yaml.Node{Kind: yaml.MappingNode}
+			}
+
+			var value yaml.Node
+			if err := value.Encode(ev); err != nil {
+				return err
+			}
+			files[fileName].Content = append(files[fileName].Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: key}, &value)
+
+			if _, ok := stubs[fn]; !ok {
+				stubs[fn] = stubWorkflow(fn, operation)
+			}
+		}
+	}
+
+	for _, fileName := range sortedNodeKeys(files) {
+		if err := imp.write(filepath.Join("src", "events", fileName+".yaml"), files[fileName]); err != nil {
+			return err
+		}
+	}
+
+	fnNames := make([]string, 0, len(stubs))
+	for fn := range stubs {
+		fnNames = append(fnNames, fn)
+	}
+	sort.Strings(fnNames)
+
+	for _, fn := range fnNames {
+		base := filepath.Join(append([]string{"src", "functions"}, strings.Split(fn, ".")...)...)
+		if utils.FileExists(base+".ts") || utils.FileExists(base+".js") {
+			imp.skipped = append(imp.skipped, base+".yaml")
+			color.Yellow("Skipping %s: a function named %s already exists.", base+".yaml", fn)
+			continue
+		}
+		if err := imp.write(base+".yaml", stubs[fn]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildEvent converts an operation into a Godspeed event
+func (imp *importer) buildEvent(operation map[string]interface{}, pathParams []interface{}, fn string) (event, error) {
+	ev := event{
+		Fn:          fn,
+		Summary:     stringValue(operation["summary"]),
+		Description: stringValue(operation["description"]),
+	}
+
+	params, err := imp.mergeParams(pathParams, operation["parameters"])
+	if err != nil {
+		return ev, err
+	}
+
+	var body interface{}
+	if requestBody, ok := operation["requestBody"]; ok {
+		body = requestBody
+	}
+
+	// Swagger 2 declares the body as an "in: body" parameter
+	var kept []interface{}
+	for _, param := range params {
+		paramMap, _ := param.(map[string]interface{})
+		switch paramMap["in"] {
+		case "body":
+			body = map[string]interface{}{
+				"required": paramMap["required"],
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": paramMap["schema"]},
+				},
+			}
+		case "formData":
+			continue
+		default:
+			kept = append(kept, swagger2Param(paramMap))
+		}
+	}
+
+	if ev.Params, err = imp.convertList(kept); err != nil {
+		return ev, err
+	}
+
+	if body != nil {
+		if ev.Body, err = imp.convert(body, nil); err != nil {
+			return ev, err
+		}
+	}
+
+	if responses, ok := operation["responses"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{})
+		for code, response := range responses {
+			responseMap, _ := imp.deref(response).(map[string]interface{})
+			if schemaValue, ok := responseMap["schema"]; ok {
+				// Swagger 2 responses carry the schema directly
+				responseMap = copyWithout(responseMap, "schema")
+				responseMap["content"] = map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaValue},
+				}
+			}
+			if converted[code], err = imp.convert(responseMap, nil); err != nil {
+				return ev, err
+			}
+		}
+		ev.Responses = converted
+	}
+
+	return ev, nil
+}
+
+// mergeParams combines path level and operation level parameters, with the
+// operation's taking precedence for the same name and location
+func (imp *importer) mergeParams(pathParams []interface{}, operationParams interface{}) ([]interface{}, error) {
+	var merged []interface{}
+	index := make(map[string]int)
+
+	add := func(params []interface{}) {
+		for _, param := range params {
+			paramMap, _ := imp.deref(param).(map[string]interface{})
+			if paramMap == nil {
+				continue
+			}
+			id := fmt.Sprintf("%v:%v", paramMap["in"], paramMap["name"])
+			if i, ok := index[id]; ok {
+				merged[i] = paramMap
+				continue
+			}
+			index[id] = len(merged)
+			merged = append(merged, paramMap)
+		}
+	}
+
+	add(pathParams)
+	opParams, _ := operationParams.([]interface{})
+	add(opParams)
+	return merged, nil
+}
+
+// convertList converts each element of a list
+func (imp *importer) convertList(list []interface{}) ([]interface{}, error) {
+	var result []interface{}
+	for _, item := range list {
+		converted, err := imp.convert(item, nil)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
+
+// convert copies node, rewriting schema references to "#/definitions/<Name>"
+// and inlining any other local reference of the document
+func (imp *importer) convert(node interface{}, stack []string) (interface{}, error) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			for _, prefix := range refPrefixes {
+				if strings.HasPrefix(ref, prefix) {
+					result := copyWithout(value, "$ref")
+					result["$ref"] = schema.DefinitionsRefPrefix + strings.TrimPrefix(ref, prefix)
+					return result, nil
+				}
+			}
+
+			for _, seen := range stack {
+				if seen == ref {
+					return nil, fmt.Errorf("circular $ref %s", ref)
+				}
+			}
+
+			target, err := imp.pointer(ref)
+			if err != nil {
+				return nil, err
+			}
+			return imp.convert(target, append(stack, ref))
+		}
+
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			converted, err := imp.convert(v, stack)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = converted
+		}
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			converted, err := imp.convert(v, stack)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = converted
+		}
+		return result, nil
+
+	default:
+		return node, nil
+	}
+}
+
+// deref follows a non-schema $ref such as "#/components/parameters/Limit"
+func (imp *importer) deref(node interface{}) interface{} {
+	for i := 0; i < 16; i++ {
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		ref, ok := nodeMap["$ref"].(string)
+		if !ok {
+			return node
+		}
+		target, err := imp.pointer(ref)
+		if err != nil {
+			return node
+		}
+		node = target
+	}
+	return node
+}
+
+// pointer resolves a local JSON pointer within the document
+func (imp *importer) pointer(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("external $ref %s is not supported", ref)
+	}
+
+	var current interface{} = imp.doc
+	for _, segment := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot resolve $ref %s", ref)
+		}
+		if current, ok = currentMap[segment]; !ok {
+			return nil, fmt.Errorf("cannot resolve $ref %s", ref)
+		}
+	}
+	return current, nil
+}
+
+// componentSchemas returns the reusable schemas of an OpenAPI 3 or Swagger 2 document
+func (imp *importer) componentSchemas() map[string]interface{} {
+	if components, ok := imp.doc["components"].(map[string]interface{}); ok {
+		if schemas, ok := components["schemas"].(map[string]interface{}); ok {
+			return schemas
+		}
+	}
+	if definitions, ok := imp.doc["definitions"].(map[string]interface{}); ok {
+		return definitions
+	}
+	return nil
+}
+
+// write encodes value as YAML to path, unless the file exists and force is not set
+func (imp *importer) write(path string, value interface{}) error {
+	if utils.FileExists(path) && !imp.force {
+		imp.skipped = append(imp.skipped, path)
+		color.Yellow("Skipping %s: file already exists.", path)
+		return nil
+	}
+
+	if err := utils.CreateDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	imp.written = append(imp.written, path)
+	fmt.Printf("  created %s\n", path)
+	return nil
+}
+
+// stubWorkflow returns a workflow that responds with a placeholder
+func stubWorkflow(fn string, operation map[string]interface{}) workflow {
+	description := "Stub generated by godspeed import. Replace it with the real implementation."
+	if operationID := stringValue(operation["operationId"]); operationID != "" {
+		description = fmt.Sprintf("Stub for operation %s generated by godspeed import. Replace it with the real implementation.", operationID)
+	}
+
+	id := strings.ReplaceAll(fn, ".", "_") + "_stub"
+	return workflow{
+		Summary: stringValue(operation["summary"]),
+		Tasks: []task{{
+			ID:          id,
+			Description: description,
+			Fn:          "com.gs.return",
+			Args:        map[string]interface{}{"message": "Not implemented"},
+		}},
+	}
+}
+
+// swagger2Param moves a Swagger 2 parameter's inline type into a schema
+func swagger2Param(param map[string]interface{}) map[string]interface{} {
+	if _, ok := param["schema"]; ok {
+		return param
+	}
+	typ, ok := param["type"]
+	if !ok {
+		return param
+	}
+
+	result := make(map[string]interface{})
+	paramSchema := map[string]interface{}{"type": typ}
+	for k, v := range param {
+		switch k {
+		case "type":
+		case "format", "items", "enum", "default", "minimum", "maximum", "minLength", "maxLength", "pattern":
+			paramSchema[k] = v
+		default:
+			result[k] = v
+		}
+	}
+	result["schema"] = paramSchema
+	return result
+}
+
+// functionName derives the workflow name from the operationId, or from the
+// method and path if there is none
+func functionName(operation map[string]interface{}, method, path string) string {
+	if operationID := stringValue(operation["operationId"]); operationID != "" {
+		return sanitizeName(operationID)
+	}
+
+	var name strings.Builder
+	name.WriteString(method)
+	for _, segment := range strings.Split(path, "/") {
+		isParam := strings.HasPrefix(segment, "{")
+		segment = sanitizeName(strings.Trim(segment, "{}"))
+		if segment == "" {
+			continue
+		}
+		if isParam {
+			name.WriteString("By")
+		}
+		name.WriteString(capitalize(segment))
+	}
+	return name.String()
+}
+
+// capitalize upper-cases the first letter of a word
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// sanitizeName keeps the characters that are valid in a function name
+func sanitizeName(name string) string {
+	var result strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' {
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+// godspeedPath converts OpenAPI path params ("{id}") to express style (":id")
+func godspeedPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + strings.Trim(segment, "{}")
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// eventFileName returns the events file of a path, named after its first segment
+func eventFileName(path string) string {
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" && !strings.HasPrefix(segment, "{") {
+			return sanitizeName(segment)
+		}
+	}
+	return "root"
+}
+
+// copyWithout returns a shallow copy of m without the given key
+func copyWithout(m map[string]interface{}, key string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// sortedKeys returns the keys of m in a stable order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedNodeKeys returns the keys of m in a stable order
+func sortedNodeKeys(m map[string]*yaml.Node) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringValue returns v if it is a string
+func stringValue(v interface{}) string {
+	str, _ := v.(string)
+	return str
+}
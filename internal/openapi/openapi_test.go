@@ -0,0 +1,118 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+)
+
+// inProject writes files into a temporary project and makes it the working
+// directory for the rest of the test
+func inProject(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestFunctionName(t *testing.T) {
+	tests := []struct {
+		operationID string
+		method      string
+		path        string
+		want        string
+	}{
+		{"listUsers", "get", "/users", "listUsers"},
+		{"billing.create-invoice", "post", "/invoices", "billing.createinvoice"},
+		{"", "get", "/users", "getUsers"},
+		{"", "get", "/users/{id}", "getUsersById"},
+		{"", "delete", "/users/{userId}/posts/{post-id}", "deleteUsersByUserIdPostsByPostid"},
+		{"", "get", "/v1/health-check", "getV1Healthcheck"},
+		{"", "get", "/", "get"},
+		{"", "get", "/übersicht/{ölId}", "getÜbersichtByÖlId"},
+	}
+
+	for _, tt := range tests {
+		operation := map[string]interface{}{}
+		if tt.operationID != "" {
+			operation["operationId"] = tt.operationID
+		}
+		if got := functionName(operation, tt.method, tt.path); got != tt.want {
+			t.Errorf("functionName(%q, %s %s) = %q, want %q", tt.operationID, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGodspeedPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users", "/users"},
+		{"/users/{id}", "/users/:id"},
+		{"/users/{userId}/posts/{postId}", "/users/:userId/posts/:postId"},
+		{"/files/{name}.json", "/files/{name}.json"},
+		{"/", "/"},
+	}
+
+	for _, tt := range tests {
+		if got := godspeedPath(tt.path); got != tt.want {
+			t.Errorf("godspeedPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEventFileName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users", "users"},
+		{"/users/{id}/posts", "users"},
+		{"/{tenant}/orders", "orders"},
+		{"/health-check", "healthcheck"},
+		{"/", "root"},
+		{"/{id}", "root"},
+	}
+
+	for _, tt := range tests {
+		if got := eventFileName(tt.path); got != tt.want {
+			t.Errorf("eventFileName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestImportWithoutPaths(t *testing.T) {
+	inProject(t, map[string]string{
+		".godspeed":    "{}",
+		"package.json": "{}",
+		"openapi.yaml": "openapi: 3.0.0\ncomponents:\n  schemas:\n    User:\n      type: object\n",
+	})
+
+	err := Import("openapi.yaml", "", false)
+	if exitcode.Of(err) != exitcode.ValidationFailure {
+		t.Errorf("Import() error = %v, want a validation failure", err)
+	}
+	if _, err := os.Stat("src"); err == nil {
+		t.Error("Import() wrote files for a document without paths")
+	}
+}
@@ -8,27 +8,34 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
 	"github.com/godspeedsystems/godspeed-cli/internal/utils"
 )
 
+// Result is the JSON result of otel enable and disable. Changed is false
+// when observability already was in the requested state.
+type Result struct {
+	Enabled bool `json:"enabled"`
+	Changed bool `json:"changed"`
+}
+
 // Enable enables OpenTelemetry in the project
-func Enable() {
-	if !utils.IsGodspeedProject() {
-		return
+func Enable() error {
+	if err := utils.RequireProject(); err != nil {
+		return err
 	}
 
 	// Check if .env file exists
 	envFilePath := filepath.Join(".", ".env")
 	if !utils.FileExists(envFilePath) {
-		color.Red("Error: .env file not found.")
-		return
+		return fmt.Errorf(".env file not found")
 	}
 
 	// Read .env file
 	envContent, err := readEnvFile(envFilePath)
 	if err != nil {
-		color.Red("Error reading .env file: %v", err)
-		return
+		return fmt.Errorf("reading .env file: %w", err)
 	}
 
 	// Check if OTEL is already enabled
@@ -36,44 +43,45 @@ func Enable() {
 		color.Yellow("Observability is already enabled in the project.")
 
 		// Install tracing package even if already enabled
-		installTracing()
-		return
+		if err := installTracing(); err != nil {
+			return exitcode.New(exitcode.ToolFailure, "installing tracing package: %v", err)
+		}
+		output.Set(Result{Enabled: true, Changed: false})
+		return nil
 	}
 
 	// Install tracing package
 	if err := installTracing(); err != nil {
-		color.Red("Error installing tracing package: %v", err)
-		return
+		return exitcode.New(exitcode.ToolFailure, "installing tracing package: %v", err)
 	}
 
 	// Update .env file
 	updatedEnvContent := updateEnvForOtel(envContent, true)
 	if err := writeEnvFile(envFilePath, updatedEnvContent); err != nil {
-		color.Red("Error updating .env file: %v", err)
-		return
+		return fmt.Errorf("updating .env file: %w", err)
 	}
 
 	color.Green("Observability has been enabled")
+	output.Set(Result{Enabled: true, Changed: true})
+	return nil
 }
 
 // Disable disables OpenTelemetry in the project
-func Disable() {
-	if !utils.IsGodspeedProject() {
-		return
+func Disable() error {
+	if err := utils.RequireProject(); err != nil {
+		return err
 	}
 
 	// Check if .env file exists
 	envFilePath := filepath.Join(".", ".env")
 	if !utils.FileExists(envFilePath) {
-		color.Red("Error: .env file not found.")
-		return
+		return fmt.Errorf(".env file not found")
 	}
 
 	// Read .env file
 	envContent, err := readEnvFile(envFilePath)
 	if err != nil {
-		color.Red("Error reading .env file: %v", err)
-		return
+		return fmt.Errorf("reading .env file: %w", err)
 	}
 
 	// Check if OTEL is already disabled
@@ -81,24 +89,27 @@ func Disable() {
 		color.Yellow("Observability is already disabled.")
 
 		// Uninstall tracing package even if already disabled
-		uninstallTracing()
-		return
+		if err := uninstallTracing(); err != nil {
+			return exitcode.New(exitcode.ToolFailure, "uninstalling tracing package: %v", err)
+		}
+		output.Set(Result{Enabled: false, Changed: false})
+		return nil
 	}
 
 	// Uninstall tracing package
 	if err := uninstallTracing(); err != nil {
-		color.Red("Error uninstalling tracing package: %v", err)
-		return
+		return exitcode.New(exitcode.ToolFailure, "uninstalling tracing package: %v", err)
 	}
 
 	// Update .env file
 	updatedEnvContent := updateEnvForOtel(envContent, false)
 	if err := writeEnvFile(envFilePath, updatedEnvContent); err != nil {
-		color.Red("Error updating .env file: %v", err)
-		return
+		return fmt.Errorf("updating .env file: %w", err)
 	}
 
 	color.Green("Observability has been disabled in the project")
+	output.Set(Result{Enabled: false, Changed: true})
+	return nil
 }
 
 // readEnvFile reads the content of .env file
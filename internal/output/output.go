@@ -0,0 +1,106 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+)
+
+// Output formats of the global --output flag
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Envelope is the JSON document a command prints to stdout with --output
+// json. Result is null for commands without a structured result and may be
+// set when a command fails part way.
+type Envelope struct {
+	Command string      `json:"command"`
+	OK      bool        `json:"ok"`
+	Result  interface{} `json:"result"`
+	Error   *Error      `json:"error,omitempty"`
+}
+
+// Error is the error of a failed command in an Envelope
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	jsonMode bool
+	command  string
+	result   interface{}
+	stdout   = os.Stdout
+)
+
+// Requested reports whether command line arguments ask for JSON output,
+// for decisions made before cobra parses them such as printing the banner
+func Requested(args []string) bool {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return false
+		case arg == "--json", arg == "--output="+FormatJSON:
+			return true
+		case arg == "--output" && i+1 < len(args) && args[i+1] == FormatJSON:
+			return true
+		}
+	}
+	return false
+}
+
+// Start sets the output format of a command. In JSON mode everything the
+// command prints, including the output of the programs it runs, goes to
+// stderr so that stdout only holds the Envelope.
+func Start(format, commandPath string) error {
+	switch format {
+	case FormatText:
+		return nil
+	case FormatJSON:
+	default:
+		return exitcode.New(exitcode.Usage, "unknown output format %q. Use %s or %s", format, FormatText, FormatJSON)
+	}
+
+	jsonMode = true
+	command = commandPath
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+	return nil
+}
+
+// JSON reports whether the command runs in JSON mode
+func JSON() bool {
+	return jsonMode
+}
+
+// Set records the structured result of the command
+func Set(v interface{}) {
+	result = v
+}
+
+// Finish prints the Envelope of the command in JSON mode
+func Finish(err error) {
+	if !jsonMode {
+		return
+	}
+	envelope := Envelope{Command: command, OK: err == nil, Result: result}
+	if err != nil {
+		envelope.Error = &Error{Code: exitcode.Of(err), Message: strings.TrimSpace(err.Error())}
+	}
+	fmt.Fprintln(stdout, Marshal(envelope))
+}
+
+// Marshal returns an Envelope as indented JSON
+func Marshal(envelope Envelope) string {
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return fmt.Sprintf(`{"command": %q, "ok": false, "result": null, "error": {"code": %d, "message": %q}}`, envelope.Command, exitcode.Failure, err.Error())
+	}
+	return string(data)
+}
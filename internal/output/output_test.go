@@ -0,0 +1,136 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+)
+
+// finish runs Finish for a command and returns what it printed
+func finish(t *testing.T, jsonOutput bool, v interface{}, err error) string {
+	t.Helper()
+	file, ferr := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if ferr != nil {
+		t.Fatal(ferr)
+	}
+	defer file.Close()
+
+	previousMode, previousCommand, previousResult, previousStdout := jsonMode, command, result, stdout
+	t.Cleanup(func() {
+		jsonMode, command, result, stdout = previousMode, previousCommand, previousResult, previousStdout
+	})
+	jsonMode, command, result, stdout = jsonOutput, "godspeed plugin add", nil, file
+	if v != nil {
+		Set(v)
+	}
+
+	Finish(err)
+	data, ferr := ioutil.ReadFile(file.Name())
+	if ferr != nil {
+		t.Fatal(ferr)
+	}
+	return string(data)
+}
+
+func TestFinish(t *testing.T) {
+	type plugin struct {
+		Name  string   `json:"name"`
+		Files []string `json:"files"`
+	}
+
+	tests := []struct {
+		name   string
+		result interface{}
+		err    error
+		want   map[string]interface{}
+	}{
+		{
+			name:   "success",
+			result: plugin{Name: "kafka", Files: []string{}},
+			want: map[string]interface{}{
+				"command": "godspeed plugin add",
+				"ok":      true,
+				"result":  map[string]interface{}{"name": "kafka", "files": []interface{}{}},
+			},
+		},
+		{
+			name: "success without a result",
+			want: map[string]interface{}{"command": "godspeed plugin add", "ok": true, "result": nil},
+		},
+		{
+			name: "coded error",
+			err:  exitcode.New(exitcode.NotProject, "not a project\n"),
+			want: map[string]interface{}{
+				"command": "godspeed plugin add",
+				"ok":      false,
+				"result":  nil,
+				"error":   map[string]interface{}{"code": float64(exitcode.NotProject), "message": "not a project"},
+			},
+		},
+		{
+			name:   "plain error with a partial result",
+			result: plugin{Name: "kafka", Files: []string{"src/eventsources/kafka.yaml"}},
+			err:    errors.New("writing lock file"),
+			want: map[string]interface{}{
+				"command": "godspeed plugin add",
+				"ok":      false,
+				"result":  map[string]interface{}{"name": "kafka", "files": []interface{}{"src/eventsources/kafka.yaml"}},
+				"error":   map[string]interface{}{"code": float64(exitcode.Failure), "message": "writing lock file"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			printed := finish(t, true, tt.result, tt.err)
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(printed), &got); err != nil {
+				t.Fatalf("Finish printed invalid JSON: %v\n%s", err, printed)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("envelope = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if printed := finish(t, false, "ignored", errors.New("boom")); printed != "" {
+		t.Errorf("Finish in text mode printed %q, want nothing", printed)
+	}
+}
+
+func TestRequested(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"plugin", "list"}, false},
+		{[]string{"plugin", "list", "--json"}, true},
+		{[]string{"--output", "json", "plugin", "list"}, true},
+		{[]string{"plugin", "list", "--output=json"}, true},
+		{[]string{"plugin", "list", "--output", "text"}, false},
+		{[]string{"plugin", "list", "--output"}, false},
+		{[]string{"dev", "--", "--json"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := Requested(tt.args); got != tt.want {
+			t.Errorf("Requested(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestStartUnknownFormat(t *testing.T) {
+	err := Start("yaml", "godspeed plugin list")
+	if exitcode.Of(err) != exitcode.Usage {
+		t.Errorf("Start(yaml) = %v, want a usage error", err)
+	}
+	if JSON() {
+		t.Errorf("JSON() = true after an unknown format")
+	}
+}
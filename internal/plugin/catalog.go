@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/assets"
+	"github.com/godspeedsystems/godspeed-cli/internal/config"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// catalogTimeout bounds the download of a remote catalog
+const catalogTimeout = 10 * time.Second
+
+// catalog holds the plugins list once it has been loaded
+var catalog []Plugin
+
+// LoadPluginsList loads the list of available plugins: the catalog compiled
+// into the binary, overlaid by the catalogs listed under "pluginCatalogs" in
+// ~/.godspeed/config. Entries of later catalogs replace earlier ones with the
+// same package name.
+func LoadPluginsList() ([]Plugin, error) {
+	if catalog != nil {
+		return catalog, nil
+	}
+
+	var plugins []Plugin
+	if err := json.Unmarshal(assets.PluginsList, &plugins); err != nil {
+		return nil, fmt.Errorf("embedded plugins list: %v", err)
+	}
+
+	sources, err := config.PluginCatalogs()
+	if err != nil {
+		color.Yellow("Ignoring plugin catalogs: %v", err)
+	}
+
+	for _, source := range sources {
+		overlay, err := loadCatalog(source)
+		if err != nil {
+			color.Yellow("Skipping plugin catalog %s: %v", source, err)
+			continue
+		}
+		plugins = mergeCatalog(plugins, overlay)
+	}
+
+	catalog = plugins
+	return catalog, nil
+}
+
+// loadCatalog reads a catalog from a URL or a file. Remote catalogs are
+// cached so that they remain available offline.
+func loadCatalog(source string) ([]Plugin, error) {
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		data, err = fetchCatalog(source)
+	} else {
+		if strings.HasPrefix(source, "~/") {
+			source = filepath.Join(utils.UserHomeDir(), source[2:])
+		}
+		data, err = ioutil.ReadFile(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []Plugin
+	if err := json.Unmarshal(data, &plugins); err != nil {
+		return nil, err
+	}
+
+	for i, plugin := range plugins {
+		if plugin.Value == "" {
+			return nil, fmt.Errorf("entry %d has no value (package name)", i)
+		}
+		if plugin.Name == "" {
+			plugins[i].Name = plugin.Value[strings.LastIndex(plugin.Value, "/")+1:]
+		}
+	}
+	return plugins, nil
+}
+
+// fetchCatalog downloads a remote catalog, falling back to the cached copy
+func fetchCatalog(url string) ([]byte, error) {
+	sum := sha1.Sum([]byte(url))
+	cachePath := filepath.Join(utils.GetGodspeedDir(), "cache", "catalogs", hex.EncodeToString(sum[:])+".json")
+
+	client := &http.Client{Timeout: catalogTimeout}
+	resp, err := client.Get(url)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("unexpected status %s", resp.Status)
+		}
+	}
+
+	var data []byte
+	if err == nil {
+		data, err = ioutil.ReadAll(resp.Body)
+	}
+
+	if err != nil {
+		cached, cacheErr := ioutil.ReadFile(cachePath)
+		if cacheErr != nil {
+			return nil, err
+		}
+		color.Yellow("Using cached copy of plugin catalog %s: %v", url, err)
+		return cached, nil
+	}
+
+	if utils.CreateDir(filepath.Dir(cachePath)) == nil {
+		ioutil.WriteFile(cachePath, data, 0644)
+	}
+	return data, nil
+}
+
+// mergeCatalog overlays the entries of overlay onto base
+func mergeCatalog(base, overlay []Plugin) []Plugin {
+	index := make(map[string]int, len(base))
+	for i, plugin := range base {
+		index[plugin.Value] = i
+	}
+
+	for _, plugin := range overlay {
+		if i, ok := index[plugin.Value]; ok {
+			base[i] = plugin
+			continue
+		}
+		index[plugin.Value] = len(base)
+		base = append(base, plugin)
+	}
+	return base
+}
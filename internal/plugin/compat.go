@@ -0,0 +1,195 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/config"
+	"github.com/godspeedsystems/godspeed-cli/internal/semver"
+)
+
+// CorePackage is the framework package plugins declare as a peer dependency
+const CorePackage = "@godspeedsystems/core"
+
+// frameworkVersion is a version of the framework the project runs on
+type frameworkVersion struct {
+	Label   string
+	Version string
+}
+
+// release is a published version of a plugin with its peer dependencies
+type release struct {
+	Version          string            `json:"version"`
+	PeerDependencies map[string]string `json:"peerDependencies"`
+}
+
+// projectFrameworkVersions returns the installed version of the core package
+// and the gsNodeServiceVersion of .godspeed, where they are known
+func projectFrameworkVersions() []frameworkVersion {
+	var versions []frameworkVersion
+	if version := installedVersion(CorePackage); version != "" {
+		versions = append(versions, frameworkVersion{Label: "installed " + CorePackage, Version: version})
+	}
+	if version := config.GSNodeServiceVersion(); version != "" {
+		if _, err := semver.Parse(version); err == nil {
+			versions = append(versions, frameworkVersion{Label: "gsNodeServiceVersion in .godspeed", Version: version})
+		}
+	}
+	return versions
+}
+
+// checkCompatibility filters out the plugin specs whose peer dependency on
+// the core package doesn't match the project's framework version, printing
+// the newest compatible version of each. With force, incompatible plugins
+// are kept with a warning.
+func checkCompatibility(specs []string, force bool) []string {
+	frameworks := projectFrameworkVersions()
+	if len(frameworks) == 0 {
+		return specs
+	}
+
+	var compatible []string
+	for _, spec := range specs {
+		err := compatibilityError(spec, frameworks)
+		if err == nil {
+			compatible = append(compatible, spec)
+			continue
+		}
+		if force {
+			color.Yellow("Warning: %v", err)
+			compatible = append(compatible, spec)
+			continue
+		}
+		color.Red("%v", err)
+		color.Yellow("Use --force to proceed anyway.")
+	}
+	return compatible
+}
+
+// compatibilityError checks a plugin spec against the framework versions and
+// describes the mismatch, suggesting the newest compatible plugin version
+func compatibilityError(spec string, frameworks []frameworkVersion) error {
+	name, version := SplitSpec(spec)
+	if version == "" {
+		version = "latest"
+	}
+
+	releases, err := registryReleases(name + "@" + version)
+	if err != nil {
+		return catalogCompatibilityError(name, frameworks, err)
+	}
+	target := newestRelease(releases)
+	if target == nil {
+		return nil
+	}
+
+	peerRange := target.PeerDependencies[CorePackage]
+	framework := incompatibleFramework(peerRange, frameworks)
+	if framework == nil {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s@%s requires %s %s, but the project uses %s (%s).",
+		name, target.Version, CorePackage, peerRange, framework.Version, framework.Label)
+
+	if suggestion, err := newestCompatible(name, frameworks); err == nil {
+		if suggestion != "" {
+			message += fmt.Sprintf(" The newest compatible version is %s: godspeed plugin add %s@%s", suggestion, name, suggestion)
+		} else {
+			message += " No published version is compatible."
+		}
+	}
+	return fmt.Errorf("%s", message)
+}
+
+// newestCompatible returns the newest published version of a plugin whose
+// peer dependency on the core package matches the framework versions, or ""
+// if none does
+func newestCompatible(name string, frameworks []frameworkVersion) (string, error) {
+	releases, err := registryReleases(name + "@*")
+	if err != nil {
+		return "", err
+	}
+	var candidates []string
+	for _, r := range releases {
+		if incompatibleFramework(r.PeerDependencies[CorePackage], frameworks) == nil {
+			candidates = append(candidates, r.Version)
+		}
+	}
+	return semver.Latest(candidates), nil
+}
+
+// catalogCompatibilityError checks the minFrameworkVersion of the catalog
+// entry of a plugin when the registry can't be reached
+func catalogCompatibilityError(name string, frameworks []frameworkVersion, registryErr error) error {
+	catalog, _ := LoadPluginsList()
+	for _, plugin := range catalog {
+		if plugin.Value != name || plugin.MinFrameworkVersion == "" {
+			continue
+		}
+		peerRange := ">=" + plugin.MinFrameworkVersion
+		if framework := incompatibleFramework(peerRange, frameworks); framework != nil {
+			return fmt.Errorf("%s requires %s %s, but the project uses %s (%s).",
+				name, CorePackage, peerRange, framework.Version, framework.Label)
+		}
+		return nil
+	}
+
+	color.Yellow("Could not check the compatibility of %s: %v", name, registryErr)
+	return nil
+}
+
+// incompatibleFramework returns the first framework version outside a peer
+// dependency range, or nil. An empty range accepts every version.
+func incompatibleFramework(peerRange string, frameworks []frameworkVersion) *frameworkVersion {
+	if strings.TrimSpace(peerRange) == "" || !semver.ValidRange(peerRange) {
+		return nil
+	}
+	for i, framework := range frameworks {
+		if !semver.Satisfies(framework.Version, peerRange) {
+			return &frameworks[i]
+		}
+	}
+	return nil
+}
+
+// newestRelease returns the highest version of releases, or nil
+func newestRelease(releases []release) *release {
+	var newest *release
+	for i, r := range releases {
+		if newest == nil || newer(r.Version, newest.Version) {
+			newest = &releases[i]
+		}
+	}
+	return newest
+}
+
+// registryReleases returns the versions matching a package spec with their
+// peer dependencies
+func registryReleases(spec string) ([]release, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "npm", "view", spec, "version", "peerDependencies", "--json", "--fetch-retries=1").Output()
+	if err != nil {
+		return nil, fmt.Errorf("npm view failed: %v", err)
+	}
+	if len(strings.TrimSpace(string(out))) == 0 {
+		return nil, fmt.Errorf("no version of %s found", spec)
+	}
+
+	// npm prints an object for a single matching version and a list otherwise
+	var releases []release
+	if err := json.Unmarshal(out, &releases); err != nil {
+		var single release
+		if err := json.Unmarshal(out, &single); err != nil {
+			return nil, err
+		}
+		releases = []release{single}
+	}
+	return releases, nil
+}
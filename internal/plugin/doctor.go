@@ -0,0 +1,358 @@
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/graph"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// generatedLoaderPattern matches the loader type files the CLI generates for
+// plugins, capturing the plugin package they import
+var generatedLoaderPattern = regexp.MustCompile(`import\s*\{\s*(?:EventSource|DataSource)\s*\}\s*from\s*['"]([^'"]+)['"]`)
+
+// problem is an issue found by Doctor. Fix is nil when it can't be repaired
+// automatically, in which case Hint tells the user what to do.
+type problem struct {
+	Message string
+	Hint    string
+	Fix     func() error
+}
+
+// DoctorProblem is a problem in the JSON result of plugin doctor. Error is
+// set when fixing it failed.
+type DoctorProblem struct {
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+	Fixable bool   `json:"fixable"`
+	Fixed   bool   `json:"fixed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DoctorResult is the JSON result of plugin doctor
+type DoctorResult struct {
+	Problems []DoctorProblem `json:"problems"`
+}
+
+// sourceDirs maps each module kind to the directory of its configs
+var sourceDirs = map[string]string{
+	ModuleTypeES: filepath.Join("src", "eventsources"),
+	ModuleTypeDS: filepath.Join("src", "datasources"),
+}
+
+// graphNodeTypes maps each module kind to the type of its configs in the
+// dependency graph
+var graphNodeTypes = map[string]string{
+	ModuleTypeES: graph.TypeEventSource,
+	ModuleTypeDS: graph.TypeDatasource,
+}
+
+// Doctor checks that the installed plugins and the eventsource and datasource
+// files of the project agree. With fix, the problems that can be repaired are:
+// missing files are regenerated and files of plugins missing from
+// node_modules are moved to the trash, unless events or functions still use
+// their configs.
+func Doctor(fix bool) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	installedPlugins, err := GetInstalledPlugins()
+	if err != nil {
+		return fmt.Errorf("checking installed plugins: %w", err)
+	}
+
+	var problems []problem
+
+	// loaders maps each module kind and loader type to the plugins providing it
+	loaders := map[string]map[string][]string{
+		ModuleTypeES: {},
+		ModuleTypeDS: {},
+	}
+
+	regenerated := make(map[string][]string)
+	regenerate := func(pluginName string) func() error {
+		return func() error {
+			files, err := createPluginFiles(pluginName)
+			if err != nil {
+				return err
+			}
+			regenerated[pluginName] = files
+			return nil
+		}
+	}
+
+	for _, name := range sortedKeys(installedPlugins) {
+		if installedVersion(name) == "" {
+			problems = append(problems, problem{
+				Message: fmt.Sprintf("%s is declared in package.json but not installed in node_modules.", name),
+				Hint:    "Run npm install or godspeed plugin sync.",
+			})
+			continue
+		}
+
+		metadata, err := readMetadata(name)
+		if err != nil {
+			problems = append(problems, problem{
+				Message: fmt.Sprintf("%s: %v", name, err),
+				Hint:    "Check the plugin's godspeed metadata with godspeed plugin info.",
+			})
+			continue
+		}
+
+		for kind := range loaders {
+			if metadata.SourceType == kind || metadata.SourceType == ModuleTypeBoth {
+				loaders[kind][metadata.Type] = append(loaders[kind][metadata.Type], name)
+			}
+		}
+
+		var missing []string
+		for _, file := range moduleFiles(metadata.SourceType, metadata.Type, metadata.ConfigFileName) {
+			if !utils.FileExists(file) {
+				missing = append(missing, file)
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, problem{
+				Message: fmt.Sprintf("%s is installed but %s missing.", name, describeFiles(missing)),
+				Fix:     regenerate(name),
+			})
+		}
+	}
+
+	// Loader types provided by several plugins shadow each other
+	for _, kind := range []string{ModuleTypeES, ModuleTypeDS} {
+		for _, loader := range sortedLoaderTypes(loaders[kind]) {
+			if plugins := loaders[kind][loader]; len(plugins) > 1 {
+				problems = append(problems, problem{
+					Message: fmt.Sprintf("Loader type %s of %s is provided by several plugins: %s.", loader, sourceDirs[kind], strings.Join(plugins, ", ")),
+					Hint:    "Remove all but one of them with godspeed plugin remove.",
+				})
+			}
+		}
+	}
+
+	t, err := newTrash()
+	if err != nil {
+		return fmt.Errorf("locating trash: %w", err)
+	}
+
+	// The dependency graph tells which configs events and functions use. If it
+	// can't be built, every config counts as used.
+	dependencies, _ := graph.Build()
+
+	for _, kind := range []string{ModuleTypeES, ModuleTypeDS} {
+		dir := sourceDirs[kind]
+
+		// Loader files generated for plugins missing from node_modules. A
+		// loader may import a package that isn't declared in package.json or
+		// has no godspeed metadata, so only node_modules decides.
+		orphans := make(map[string]bool)
+		for _, file := range loaderFiles(dir) {
+			pluginName := generatedLoaderPlugin(file)
+			if pluginName == "" || installedVersion(pluginName) != "" {
+				continue
+			}
+
+			loader := configName(file)
+			orphans[loader] = true
+			configs := configFilesOfType(dir, loader)
+			files := append([]string{file}, configs...)
+			message := fmt.Sprintf("%s: generated for %s, which is not installed.", strings.Join(files, ", "), pluginName)
+
+			if used := usedConfigs(dependencies, graphNodeTypes[kind], configs); len(used) > 0 {
+				problems = append(problems, problem{
+					Message: message,
+					Hint:    fmt.Sprintf("Install %s, or remove %s and what uses %s.", pluginName, strings.Join(files, ", "), strings.Join(used, ", ")),
+				})
+				continue
+			}
+
+			problems = append(problems, problem{
+				Message: message,
+				Fix: func() error {
+					for _, file := range files {
+						if err := t.move(file); err != nil {
+							return err
+						}
+					}
+					return forgetPlugins([]string{pluginName})
+				},
+			})
+		}
+
+		// Configs whose loader type has no loader file. Missing loaders of
+		// installed plugins are reported with the plugin's files above.
+		for _, file := range configFiles(dir) {
+			loader := configType(file)
+			if loader == "" || orphans[loader] || len(loaders[kind][loader]) > 0 || loaderExists(dir, loader) {
+				continue
+			}
+			problems = append(problems, problem{
+				Message: fmt.Sprintf("%s has type %s, but %s has no %s loader.", file, loader, filepath.Join(dir, "types"), loader),
+				Hint:    fmt.Sprintf("Install the plugin providing %s or remove %s.", loader, file),
+			})
+		}
+	}
+
+	result := DoctorResult{Problems: []DoctorProblem{}}
+	defer output.Set(&result)
+
+	if len(problems) == 0 {
+		color.Green("No problems found. Plugins and their files are in order.")
+		return nil
+	}
+
+	fixable, fixed := 0, 0
+	for _, p := range problems {
+		reported := DoctorProblem{Message: p.Message, Hint: p.Hint, Fixable: p.Fix != nil}
+		color.Red("✗ %s", p.Message)
+		switch {
+		case p.Fix == nil:
+			color.Yellow("    %s", p.Hint)
+		case !fix:
+			fixable++
+		default:
+			fixable++
+			if err := p.Fix(); err != nil {
+				color.Red("    Fix failed: %v", err)
+				reported.Error = err.Error()
+			} else {
+				color.Green("    Fixed")
+				reported.Fixed = true
+				fixed++
+			}
+		}
+		result.Problems = append(result.Problems, reported)
+	}
+
+	if fix && len(regenerated) > 0 && utils.FileExists(LockFileName) {
+		if err := recordPlugins(regenerated); err != nil {
+			color.Red("Error writing %s: %v", LockFileName, err)
+		}
+	}
+
+	fmt.Println()
+	if !fix && fixable > 0 {
+		color.Yellow("Found %d problems. Run godspeed plugin doctor --fix to repair %d of them.", len(problems), fixable)
+	} else {
+		color.Yellow("Found %d problems.", len(problems))
+	}
+	if remaining := len(problems) - fixed; remaining > 0 {
+		return exitcode.New(exitcode.ValidationFailure, "%d plugin problems remain", remaining)
+	}
+	return nil
+}
+
+// usedConfigs returns the configs that events or functions use, all of them
+// when the dependency graph is unknown
+func usedConfigs(dependencies *graph.Graph, nodeType string, configs []string) []string {
+	var used []string
+	for _, file := range configs {
+		if dependencies == nil || dependencies.Referenced(nodeType, configName(file)) {
+			used = append(used, file)
+		}
+	}
+	return used
+}
+
+// describeFiles lists files for a problem message
+func describeFiles(files []string) string {
+	if len(files) == 1 {
+		return files[0] + " is"
+	}
+	return strings.Join(files, ", ") + " are"
+}
+
+// loaderFiles returns the loader type files of a config directory
+func loaderFiles(dir string) []string {
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "types"))
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".ts" || ext == ".js") {
+			files = append(files, filepath.Join(dir, "types", entry.Name()))
+		}
+	}
+	return files
+}
+
+// generatedLoaderPlugin returns the plugin a generated loader file imports,
+// or "" for hand-written loaders
+func generatedLoaderPlugin(file string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	match := generatedLoaderPattern.FindSubmatch(data)
+	if match == nil || strings.HasPrefix(string(match[1]), ".") {
+		return ""
+	}
+	return string(match[1])
+}
+
+// loaderExists reports whether a config directory has a loader of a type
+func loaderExists(dir, loader string) bool {
+	for _, ext := range []string{".ts", ".js"} {
+		if utils.FileExists(filepath.Join(dir, "types", loader+ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// configFiles returns the YAML configs of a config directory
+func configFiles(dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files
+}
+
+// configType returns the type of a YAML config, or "" if it has none
+func configType(file string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+
+	var config struct {
+		Type string `yaml:"type"`
+	}
+	if yaml.Unmarshal(data, &config) != nil {
+		return ""
+	}
+	return config.Type
+}
+
+// sortedLoaderTypes returns the loader types of a map in a stable order
+func sortedLoaderTypes(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,122 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGeneratedLoaderPlugin(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"eventsource", "import { EventSource } from '@godspeedsystems/plugins-kafka-as-datasource-as-eventsource';\nexport default EventSource;\n", "@godspeedsystems/plugins-kafka-as-datasource-as-eventsource"},
+		{"datasource", "import {DataSource} from \"@acme/plugins-queue\";\nexport default DataSource;\n", "@acme/plugins-queue"},
+		{"relative import", "import { DataSource } from './custom';\n", ""},
+		{"hand written", "export default class Custom {}\n", ""},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "loader.ts")
+		if err := ioutil.WriteFile(path, []byte(tt.source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := generatedLoaderPlugin(path); got != tt.want {
+			t.Errorf("%s: generatedLoaderPlugin = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConfigType(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"type: kafka\nport: 1\n", "kafka"},
+		{"port: 1\n", ""},
+		{"type: [kafka\n", ""},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := ioutil.WriteFile(path, []byte(tt.source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := configType(path); got != tt.want {
+			t.Errorf("configType(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	files := map[string]string{
+		".godspeed":    "{}",
+		"package.json": `{"dependencies": {"@godspeedsystems/plugins-a": "1.0.0"}}`,
+		// Generated for a plugin that is gone, and unused
+		"src/eventsources/types/old.ts": "import { EventSource } from '@godspeedsystems/plugins-old';\nexport default EventSource;\n",
+		"src/eventsources/old.yaml":     "type: old\n",
+		// Generated for a plugin that is gone, but a function still calls it
+		"src/datasources/types/legacy.ts": "import { DataSource } from '@godspeedsystems/plugins-legacy';\nexport default DataSource;\n",
+		"src/datasources/legacy.yaml":     "type: legacy\n",
+		"src/events/orders.yaml":          "http.get./orders:\n  fn: orders\n",
+		"src/functions/orders.ts":         "export default async function (ctx) { return ctx.datasources.legacy.execute(ctx, {}); }\n",
+		// Hand-written loader
+		"src/datasources/types/mine.ts": "import { DataSource } from './mine-client';\nexport default DataSource;\n",
+		"src/datasources/mine.yaml":     "type: mine\n",
+		// Config without a loader
+		"src/eventsources/stray.yaml": "type: nothing\n",
+	}
+	for name, content := range testPlugin("a", "1.0.0") {
+		files[name] = content
+	}
+	inProject(t, files)
+	t.Setenv("HOME", t.TempDir())
+
+	exists := func(file string) bool {
+		_, err := os.Stat(filepath.FromSlash(file))
+		return err == nil
+	}
+	kept := []string{
+		"src/datasources/types/legacy.ts",
+		"src/datasources/legacy.yaml",
+		"src/datasources/types/mine.ts",
+		"src/datasources/mine.yaml",
+		"src/eventsources/stray.yaml",
+	}
+
+	Doctor(false)
+	for _, file := range append(kept, "src/eventsources/types/old.ts", "src/eventsources/old.yaml") {
+		if !exists(file) {
+			t.Errorf("doctor without --fix removed %s", file)
+		}
+	}
+	for _, file := range testPluginFiles("a") {
+		if exists(file) {
+			t.Errorf("doctor without --fix created %s", file)
+		}
+	}
+
+	Doctor(true)
+	for _, file := range kept {
+		if !exists(file) {
+			t.Errorf("doctor --fix removed %s", file)
+		}
+	}
+	for _, file := range []string{"src/eventsources/types/old.ts", "src/eventsources/old.yaml"} {
+		if exists(file) {
+			t.Errorf("doctor --fix kept the orphaned %s", file)
+		}
+		trashed, _ := filepath.Glob(filepath.Join(TrashDir, "*", filepath.FromSlash(file)))
+		if len(trashed) != 1 {
+			t.Errorf("%s was not moved to the trash", file)
+		}
+	}
+	for _, file := range testPluginFiles("a") {
+		if !exists(file) {
+			t.Errorf("doctor --fix did not regenerate %s", file)
+		}
+	}
+}
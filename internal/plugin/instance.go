@@ -0,0 +1,417 @@
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/prompt"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// instancePattern matches instance names, which become config file names
+var instancePattern = regexp.MustCompile(`^[A-Za-z_][\w-]*$`)
+
+// prismaSchemaTemplate is the schema of a new prisma datasource instance
+const prismaSchemaTemplate = `datasource db {
+  provider = "postgresql"
+  url      = env("%s_DATABASE_URL")
+}
+
+generator client {
+  provider        = "prisma-client-js"
+  output          = "./prisma-clients/%s"
+  previewFeatures = ["metrics"]
+}
+`
+
+// Instance is a named config of a plugin, sharing the plugin's loader type file
+type Instance struct {
+	Name  string   `json:"name"`
+	Files []string `json:"files"`
+}
+
+// Datasource is a datasource in the JSON result of datasource list. Default
+// is true for the config named by the plugin rather than an instance.
+type Datasource struct {
+	Name    string `json:"name"`
+	Plugin  string `json:"plugin"`
+	Type    string `json:"type"`
+	Default bool   `json:"default"`
+}
+
+// DatasourcesResult is the JSON result of datasource list
+type DatasourcesResult struct {
+	Datasources []Datasource `json:"datasources"`
+}
+
+// AddInstance creates an additional named config of a plugin, e.g. a second
+// postgres datasource. The plugin is installed first if needed.
+func AddInstance(pluginSpec, instance string, force bool) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	if !instancePattern.MatchString(instance) {
+		return exitcode.New(exitcode.Usage, "invalid instance name %q. Use letters, digits, - and _", instance)
+	}
+
+	pluginName, _ := SplitSpec(pluginSpec)
+	installedPlugins, err := GetInstalledPlugins()
+	if err != nil {
+		return fmt.Errorf("checking installed plugins: %w", err)
+	}
+
+	if _, installed := installedPlugins[pluginName]; !installed {
+		if err := Add(pluginSpec, force); err != nil {
+			return err
+		}
+		if installedVersion(pluginName) == "" {
+			return nil
+		}
+	}
+
+	moduleType, loaderFileName, yamlFileName, defaultConfig, err := getModuleInfo(pluginName)
+	if err != nil {
+		return fmt.Errorf("reading plugin %s: %w", pluginName, err)
+	}
+
+	if instance == yamlFileName {
+		return fmt.Errorf("%s is the default config name of %s. Choose another instance name", instance, pluginName)
+	}
+
+	files := instanceFiles(moduleType, loaderFileName, instance)
+	for _, file := range files {
+		if utils.FileExists(file) {
+			return fmt.Errorf("%s already exists", file)
+		}
+	}
+
+	if err := createInstanceFiles(loaderFileName, defaultConfig, files); err != nil {
+		return fmt.Errorf("creating instance %s: %w", instance, err)
+	}
+
+	if err := recordInstance(pluginName, instance, files); err != nil {
+		color.Red("Error writing %s: %v", LockFileName, err)
+	}
+
+	color.Green("Created %s instance %s: %s", pluginName, instance, strings.Join(files, ", "))
+	output.Set(Result{Plugins: []PluginResult{{
+		Name:     pluginName,
+		Version:  installedVersion(pluginName),
+		Instance: instance,
+		Files:    files,
+	}}})
+	return nil
+}
+
+// AddDatasource creates a named datasource instance. Without a plugin name,
+// the user picks one of the installed datasource plugins.
+func AddDatasource(instance, pluginName string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	if pluginName == "" {
+		installedPlugins, err := GetInstalledPlugins()
+		if err != nil {
+			return fmt.Errorf("checking installed plugins: %w", err)
+		}
+
+		var options []string
+		for name := range installedPlugins {
+			if moduleType, _, _, _, err := getModuleInfo(name); err == nil && moduleType != ModuleTypeES {
+				options = append(options, name)
+			}
+		}
+		sort.Strings(options)
+
+		if len(options) == 0 {
+			return fmt.Errorf("there are no datasource plugins installed. Add one with godspeed plugin add")
+		}
+
+		if err := prompt.Ask(&survey.Select{
+			Message: "Please select the datasource plugin:",
+			Options: options,
+		}, &pluginName, "--plugin"); err != nil {
+			return err
+		}
+	}
+
+	if instance == "" {
+		if err := prompt.Ask(&survey.Input{
+			Message: "Name of the datasource:",
+		}, &instance, "<name>", survey.WithValidator(survey.Required)); err != nil {
+			return err
+		}
+	}
+
+	return AddInstance(pluginName, instance, false)
+}
+
+// ListDatasources prints the datasources of the project grouped by plugin
+func ListDatasources() error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	installedPlugins, err := GetInstalledPlugins()
+	if err != nil {
+		return fmt.Errorf("checking installed plugins: %w", err)
+	}
+
+	names := make([]string, 0, len(installedPlugins))
+	for name := range installedPlugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := DatasourcesResult{Datasources: []Datasource{}}
+	defer output.Set(&result)
+
+	found := false
+	for _, name := range names {
+		moduleType, loaderFileName, yamlFileName, _, err := getModuleInfo(name)
+		if err != nil || moduleType == ModuleTypeES {
+			continue
+		}
+
+		found = true
+		color.Cyan("%s (%s)", name, loaderFileName)
+		if loaderFileName != "prisma" {
+			fmt.Printf("  %s (default)\n", yamlFileName)
+			result.Datasources = append(result.Datasources, Datasource{Name: yamlFileName, Plugin: name, Type: loaderFileName, Default: true})
+		}
+		for _, instance := range pluginInstances(name, moduleType, loaderFileName, yamlFileName) {
+			fmt.Printf("  %s\n", instance.Name)
+			result.Datasources = append(result.Datasources, Datasource{Name: instance.Name, Plugin: name, Type: loaderFileName})
+		}
+	}
+
+	if !found {
+		color.Yellow("There are no datasource plugins installed.")
+	}
+	return nil
+}
+
+// removeInstance moves the config files of a named instance to the trash
+func removeInstance(pluginName, instance string) error {
+	moduleType, loaderFileName, yamlFileName, _, err := getModuleInfo(pluginName)
+	if err != nil {
+		return fmt.Errorf("reading plugin %s: %w", pluginName, err)
+	}
+
+	var target *Instance
+	for _, candidate := range pluginInstances(pluginName, moduleType, loaderFileName, yamlFileName) {
+		if candidate.Name == instance {
+			target = &candidate
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("%s has no instance named %s", pluginName, instance)
+	}
+
+	t, err := newTrash()
+	if err != nil {
+		return fmt.Errorf("locating trash: %w", err)
+	}
+	for _, file := range target.Files {
+		if err := t.move(file); err != nil {
+			return fmt.Errorf("removing %s: %w", file, err)
+		}
+	}
+
+	if err := forgetInstance(pluginName, instance); err != nil {
+		color.Red("Error writing %s: %v", LockFileName, err)
+	}
+	color.Green("Removed instance %s of %s.", instance, pluginName)
+	output.Set(Result{Plugins: []PluginResult{{Name: pluginName, Instance: instance, Files: append([]string{}, target.Files...)}}})
+	return nil
+}
+
+// pluginInstances returns the named instances of a plugin: the config files
+// of its loader type other than the default one, and the instances recorded
+// in the lock file
+func pluginInstances(pluginName, moduleType, loaderFileName, yamlFileName string) []Instance {
+	byName := make(map[string]*Instance)
+	add := func(name, file string) {
+		if byName[name] == nil {
+			byName[name] = &Instance{Name: name}
+		}
+		for _, existing := range byName[name].Files {
+			if existing == file {
+				return
+			}
+		}
+		byName[name].Files = append(byName[name].Files, file)
+	}
+
+	if moduleType == ModuleTypeES || moduleType == ModuleTypeBoth {
+		for _, file := range configFilesOfType(filepath.Join("src", "eventsources"), loaderFileName) {
+			if name := configName(file); name != yamlFileName {
+				add(name, file)
+			}
+		}
+	}
+	if (moduleType == ModuleTypeDS || moduleType == ModuleTypeBoth) && loaderFileName != "prisma" {
+		for _, file := range configFilesOfType(filepath.Join("src", "datasources"), loaderFileName) {
+			if name := configName(file); name != yamlFileName {
+				add(name, file)
+			}
+		}
+	}
+
+	if lock, err := LoadLockFile(); err == nil {
+		for name, files := range lock.Plugins[pluginName].Instances {
+			for _, file := range files {
+				if utils.FileExists(file) {
+					add(name, file)
+				}
+			}
+		}
+	}
+
+	instances := make([]Instance, 0, len(byName))
+	for _, instance := range byName {
+		sort.Strings(instance.Files)
+		instances = append(instances, *instance)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Name < instances[j].Name })
+	return instances
+}
+
+// instanceNames returns the names of the instances of a plugin, or nil if
+// the plugin can't be read
+func instanceNames(pluginName string) []string {
+	moduleType, loaderFileName, yamlFileName, _, err := getModuleInfo(pluginName)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, instance := range pluginInstances(pluginName, moduleType, loaderFileName, yamlFileName) {
+		names = append(names, instance.Name)
+	}
+	return names
+}
+
+// instanceFiles returns the config files of a named instance
+func instanceFiles(moduleType, loaderFileName, instance string) []string {
+	var files []string
+	if moduleType == ModuleTypeES || moduleType == ModuleTypeBoth {
+		files = append(files, filepath.Join("src", "eventsources", instance+".yaml"))
+	}
+	if moduleType == ModuleTypeDS || moduleType == ModuleTypeBoth {
+		if loaderFileName == "prisma" {
+			files = append(files, filepath.Join("src", "datasources", instance+".prisma"))
+		} else {
+			files = append(files, filepath.Join("src", "datasources", instance+".yaml"))
+		}
+	}
+	return files
+}
+
+// createInstanceFiles writes the config files of an instance. Prisma
+// instances get their own schema and client output directory.
+func createInstanceFiles(loaderFileName string, defaultConfig map[string]interface{}, files []string) error {
+	for _, file := range files {
+		if filepath.Ext(file) == ".prisma" {
+			if utils.FileExists(file) {
+				continue
+			}
+			name := configName(file)
+			envName := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+			if err := ioutil.WriteFile(file, []byte(fmt.Sprintf(prismaSchemaTemplate, envName, name)), 0644); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := createConfigFile(file, loaderFileName, defaultConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeInstanceDefaults adds new default keys to the YAML configs of every
+// instance of a plugin
+func mergeInstanceDefaults(pluginName, moduleType, loaderFileName, yamlFileName string, defaultConfig map[string]interface{}) error {
+	for _, instance := range pluginInstances(pluginName, moduleType, loaderFileName, yamlFileName) {
+		for _, file := range instance.Files {
+			if filepath.Ext(file) != ".yaml" {
+				continue
+			}
+			if err := createConfigFile(file, loaderFileName, defaultConfig); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// configFilesOfType returns the YAML configs in dir whose type is loaderFileName
+func configFilesOfType(dir, loaderFileName string) []string {
+	var files []string
+	for _, file := range configFiles(dir) {
+		if configType(file) == loaderFileName {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// configName returns the instance name of a config file
+func configName(file string) string {
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
+
+// recordInstance stores an instance's files in the lock file
+func recordInstance(pluginName, instance string, files []string) error {
+	lock, err := LoadLockFile()
+	if err != nil {
+		return err
+	}
+
+	entry := lock.Plugins[pluginName]
+	if entry.Version == "" {
+		entry.Version = installedVersion(pluginName)
+	}
+	if entry.Files == nil {
+		entry.Files, _ = pluginFiles(pluginName)
+	}
+	if entry.Instances == nil {
+		entry.Instances = make(map[string][]string)
+	}
+	entry.Instances[instance] = files
+	lock.Plugins[pluginName] = entry
+	return lock.Save()
+}
+
+// forgetInstance removes an instance from the lock file
+func forgetInstance(pluginName, instance string) error {
+	if !utils.FileExists(LockFileName) {
+		return nil
+	}
+
+	lock, err := LoadLockFile()
+	if err != nil {
+		return err
+	}
+
+	entry, ok := lock.Plugins[pluginName]
+	if !ok {
+		return nil
+	}
+	delete(entry.Instances, instance)
+	lock.Plugins[pluginName] = entry
+	return lock.Save()
+}
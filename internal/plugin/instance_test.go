@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInstanceFiles(t *testing.T) {
+	tests := []struct {
+		moduleType string
+		loader     string
+		want       []string
+	}{
+		{ModuleTypeES, "kafka", []string{filepath.Join("src", "eventsources", "orders.yaml")}},
+		{ModuleTypeDS, "mongoose", []string{filepath.Join("src", "datasources", "orders.yaml")}},
+		{ModuleTypeDS, "prisma", []string{filepath.Join("src", "datasources", "orders.prisma")}},
+		{ModuleTypeBoth, "kafka", []string{
+			filepath.Join("src", "eventsources", "orders.yaml"),
+			filepath.Join("src", "datasources", "orders.yaml"),
+		}},
+	}
+
+	for _, tt := range tests {
+		if got := instanceFiles(tt.moduleType, tt.loader, "orders"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("instanceFiles(%s, %s) = %q, want %q", tt.moduleType, tt.loader, got, tt.want)
+		}
+	}
+}
+
+func TestPluginInstances(t *testing.T) {
+	inProject(t, map[string]string{
+		"src/eventsources/kafka.yaml":   "type: kafka\n",
+		"src/eventsources/orders.yaml":  "type: kafka\nport: 1\n",
+		"src/eventsources/http.yaml":    "type: express\n",
+		"src/eventsources/legacy.yaml":  "port: 2\n",
+		"src/datasources/orders.yaml":   "type: kafka\n",
+		"src/datasources/payments.yaml": "type: kafka\n",
+		LockFileName: `{"lockfileVersion": 1, "plugins": {"@godspeedsystems/plugins-kafka-as-datasource-as-eventsource": {
+			"version": "1.0.0",
+			"files": [],
+			"instances": {
+				"legacy": ["src/eventsources/legacy.yaml"],
+				"removed": ["src/eventsources/removed.yaml"]
+			}
+		}}}`,
+	})
+
+	got := pluginInstances("@godspeedsystems/plugins-kafka-as-datasource-as-eventsource", ModuleTypeBoth, "kafka", "kafka")
+	want := []Instance{
+		{Name: "legacy", Files: []string{filepath.Join("src", "eventsources", "legacy.yaml")}},
+		{Name: "orders", Files: []string{
+			filepath.Join("src", "datasources", "orders.yaml"),
+			filepath.Join("src", "eventsources", "orders.yaml"),
+		}},
+		{Name: "payments", Files: []string{filepath.Join("src", "datasources", "payments.yaml")}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pluginInstances = %+v, want %+v", got, want)
+	}
+
+	if got := pluginInstances("@godspeedsystems/plugins-kafka-as-datasource-as-eventsource", ModuleTypeES, "kafka", "kafka"); len(got) != 2 {
+		t.Errorf("eventsource instances = %+v, want legacy and orders", got)
+	}
+}
+
+func TestCreateInstanceFiles(t *testing.T) {
+	inProject(t, map[string]string{"src/datasources/.keep": ""})
+
+	files := []string{
+		filepath.Join("src", "datasources", "analytics-db.prisma"),
+		filepath.Join("src", "datasources", "orders.yaml"),
+	}
+	if err := createInstanceFiles("prisma", map[string]interface{}{"retries": 3}, files); err != nil {
+		t.Fatalf("createInstanceFiles: %v", err)
+	}
+
+	schema, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`env("ANALYTICS_DB_DATABASE_URL")`, `output          = "./prisma-clients/analytics-db"`} {
+		if !strings.Contains(string(schema), want) {
+			t.Errorf("prisma schema lacks %q:\n%s", want, schema)
+		}
+	}
+
+	config, err := ioutil.ReadFile(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"type: prisma", "retries: 3"} {
+		if !strings.Contains(string(config), want) {
+			t.Errorf("config lacks %q:\n%s", want, config)
+		}
+	}
+
+	// Existing prisma schemas are kept
+	if err := ioutil.WriteFile(files[0], []byte("custom"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := createInstanceFiles("prisma", nil, files[:1]); err != nil {
+		t.Fatalf("createInstanceFiles: %v", err)
+	}
+	if data, _ := ioutil.ReadFile(files[0]); string(data) != "custom" {
+		t.Errorf("existing schema was overwritten with %q", data)
+	}
+}
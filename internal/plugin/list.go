@@ -0,0 +1,288 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/semver"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// Sources of the versions reported by plugin outdated
+const (
+	VersionsFromRegistry = "registry"
+	VersionsFromCatalog  = "catalog"
+)
+
+// registryTimeout bounds each registry lookup of plugin outdated
+const registryTimeout = 30 * time.Second
+
+// OutdatedPlugin is a plugin with a newer version available. Wanted is the
+// highest version matching the range in package.json.
+type OutdatedPlugin struct {
+	Name    string `json:"name"`
+	Range   string `json:"range"`
+	Current string `json:"current"`
+	Wanted  string `json:"wanted"`
+	Latest  string `json:"latest"`
+	Source  string `json:"source"`
+}
+
+// OutdatedResult is the JSON result of plugin outdated
+type OutdatedResult struct {
+	Plugins []OutdatedPlugin `json:"plugins"`
+}
+
+// List prints the installed plugins with their resolved version, type and
+// the files they own
+func List() error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	dependencies, devDependencies, err := projectDependencies()
+	if err != nil {
+		return fmt.Errorf("reading package.json: %w", err)
+	}
+
+	installedPlugins, err := GetInstalledPlugins()
+	if err != nil {
+		return fmt.Errorf("checking installed plugins: %w", err)
+	}
+
+	result := Result{Plugins: []PluginResult{}}
+	defer output.Set(&result)
+
+	if len(installedPlugins) == 0 {
+		color.Yellow("There are no eventsource/datasource plugins installed.")
+		return nil
+	}
+
+	lock, _ := LoadLockFile()
+	for _, name := range sortedKeys(installedPlugins) {
+		version := installedVersion(name)
+		listed := PluginResult{Name: name, Version: version}
+		label := ""
+		if _, dev := devDependencies[name]; dev {
+			if _, prod := dependencies[name]; !prod {
+				label = " (dev)"
+				listed.Dev = true
+			}
+		}
+
+		if version == "" {
+			color.Red("%s%s: not installed (%s). Run npm install or godspeed plugin sync.", name, label, installedPlugins[name])
+			listed.Files = append([]string{}, lockedFiles(lock, name)...)
+			listed.Error = "not installed"
+			printOwnedFiles(listed.Files)
+			result.Plugins = append(result.Plugins, listed)
+			continue
+		}
+
+		metadata, err := readMetadata(name)
+		if err != nil {
+			color.Cyan("%s@%s%s", name, version, label)
+			color.Red("  Error reading plugin: %v", err)
+			listed.Files = append([]string{}, lockedFiles(lock, name)...)
+			listed.Error = err.Error()
+			printOwnedFiles(listed.Files)
+			result.Plugins = append(result.Plugins, listed)
+			continue
+		}
+
+		color.Cyan("%s@%s %s%s", name, version, metadata.SourceType, label)
+		files := moduleFiles(metadata.SourceType, metadata.Type, metadata.ConfigFileName)
+		for _, instance := range pluginInstances(name, metadata.SourceType, metadata.Type, metadata.ConfigFileName) {
+			files = append(files, instance.Files...)
+		}
+		printOwnedFiles(files)
+		listed.Type = metadata.SourceType
+		listed.Files = append([]string{}, files...)
+		result.Plugins = append(result.Plugins, listed)
+	}
+	return nil
+}
+
+// lockedFiles returns the files recorded for a plugin in the lock file
+func lockedFiles(lock *LockFile, pluginName string) []string {
+	if lock == nil {
+		return nil
+	}
+	entry := lock.Plugins[pluginName]
+	files := append([]string{}, entry.Files...)
+	for _, instanceFiles := range entry.Instances {
+		files = append(files, instanceFiles...)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// printOwnedFiles prints the files of a plugin and whether they exist
+func printOwnedFiles(files []string) {
+	for _, file := range files {
+		if utils.FileExists(file) {
+			fmt.Printf("  ✓ %s\n", file)
+		} else {
+			color.Yellow("  ✗ %s (missing)", file)
+		}
+	}
+}
+
+// Outdated prints the plugins that have newer versions in the npm registry,
+// or in the plugin catalog when the registry can't be reached
+func Outdated() error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	installedPlugins, err := GetInstalledPlugins()
+	if err != nil {
+		return fmt.Errorf("checking installed plugins: %w", err)
+	}
+
+	outdated := findOutdated(installedPlugins)
+	output.Set(OutdatedResult{Plugins: outdated})
+
+	if len(outdated) == 0 {
+		color.Green("All plugins are up to date.")
+		return nil
+	}
+
+	width := len("Plugin")
+	for _, plugin := range outdated {
+		if len(plugin.Name) > width {
+			width = len(plugin.Name)
+		}
+	}
+
+	color.Cyan("%-*s  %-10s  %-10s  %s", width, "Plugin", "Current", "Wanted", "Latest")
+	for _, plugin := range outdated {
+		line := fmt.Sprintf("%-*s  %-10s  %-10s  %s", width, plugin.Name, valueOr(plugin.Current, "missing"), plugin.Wanted, plugin.Latest)
+		if plugin.Source == VersionsFromCatalog {
+			line += " (catalog)"
+		}
+		if plugin.Current == plugin.Wanted {
+			color.Yellow(line)
+		} else {
+			color.Red(line)
+		}
+	}
+	fmt.Println("\nRun godspeed plugin update <name> to upgrade to the latest compatible version, keeping pinned plugins pinned, or godspeed plugin add <name>@<version> to pin a version.")
+	return nil
+}
+
+// findOutdated returns the installed plugins that have newer versions
+func findOutdated(installedPlugins map[string]string) []OutdatedPlugin {
+	catalog, _ := LoadPluginsList()
+	catalogVersions := make(map[string]string)
+	for _, plugin := range catalog {
+		catalogVersions[plugin.Value] = plugin.Version
+	}
+
+	outdated := []OutdatedPlugin{}
+	for _, name := range sortedKeys(installedPlugins) {
+		plugin := OutdatedPlugin{
+			Name:    name,
+			Range:   installedPlugins[name],
+			Current: installedVersion(name),
+			Source:  VersionsFromRegistry,
+		}
+
+		versions, latest, err := registryVersions(name)
+		if err != nil {
+			if catalogVersions[name] == "" {
+				color.Yellow("Skipping %s: %v", name, err)
+				continue
+			}
+			plugin.Source = VersionsFromCatalog
+			latest = catalogVersions[name]
+			versions = []string{latest}
+			if plugin.Current != "" {
+				versions = append(versions, plugin.Current)
+			}
+		}
+
+		plugin.Latest = latest
+		plugin.Wanted = semver.MaxSatisfying(versions, plugin.Range)
+		if plugin.Wanted == "" {
+			plugin.Wanted = plugin.Current
+		}
+
+		if plugin.Current == "" || newer(plugin.Wanted, plugin.Current) || newer(plugin.Latest, plugin.Current) {
+			outdated = append(outdated, plugin)
+		}
+	}
+	return outdated
+}
+
+// registryVersions returns the published versions and the latest tag of a package
+func registryVersions(name string) ([]string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "npm", "view", name, "versions", "dist-tags", "--json", "--fetch-retries=1").Output()
+	if err != nil {
+		return nil, "", fmt.Errorf("npm view failed: %v", err)
+	}
+
+	var view struct {
+		Versions json.RawMessage   `json:"versions"`
+		DistTags map[string]string `json:"dist-tags"`
+	}
+	if err := json.Unmarshal(out, &view); err != nil {
+		return nil, "", err
+	}
+
+	// npm prints a single version as a string rather than a list
+	var versions []string
+	if err := json.Unmarshal(view.Versions, &versions); err != nil {
+		var version string
+		if err := json.Unmarshal(view.Versions, &version); err != nil {
+			return nil, "", err
+		}
+		versions = []string{version}
+	}
+
+	latest := view.DistTags["latest"]
+	if latest == "" {
+		latest = semver.Latest(versions)
+	}
+	return versions, latest, nil
+}
+
+// newer reports whether version a is greater than b
+func newer(a, b string) bool {
+	av, err := semver.Parse(a)
+	if err != nil {
+		return false
+	}
+	bv, err := semver.Parse(b)
+	if err != nil {
+		return false
+	}
+	return semver.Compare(av, bv) > 0
+}
+
+// valueOr returns value, or fallback when value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// sortedKeys returns the keys of a map in a stable order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
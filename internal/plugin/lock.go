@@ -0,0 +1,355 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// LockFileName is the lock file written next to .godspeed
+const LockFileName = "godspeed.plugins.lock"
+
+// lockFileVersion is the format version of the lock file
+const lockFileVersion = 1
+
+// LockFile records the installed plugins, their resolved versions and the
+// files generated for them
+type LockFile struct {
+	LockfileVersion int                     `json:"lockfileVersion"`
+	Plugins         map[string]LockedPlugin `json:"plugins"`
+}
+
+// LockedPlugin is a single entry of the lock file. Instances maps the named
+// instances of the plugin to their config files. Link is the "file:" spec of
+// a plugin linked from a local directory.
+type LockedPlugin struct {
+	Version   string              `json:"version"`
+	Link      string              `json:"link,omitempty"`
+	Files     []string            `json:"files"`
+	Instances map[string][]string `json:"instances,omitempty"`
+}
+
+// LoadLockFile reads the lock file of the project. A missing lock file
+// yields an empty one.
+func LoadLockFile() (*LockFile, error) {
+	lock := &LockFile{LockfileVersion: lockFileVersion, Plugins: make(map[string]LockedPlugin)}
+
+	data, err := ioutil.ReadFile(LockFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("%s: %v", LockFileName, err)
+	}
+	if lock.Plugins == nil {
+		lock.Plugins = make(map[string]LockedPlugin)
+	}
+	return lock, nil
+}
+
+// Save writes the lock file
+func (l *LockFile) Save() error {
+	l.LockfileVersion = lockFileVersion
+	for name, entry := range l.Plugins {
+		sort.Strings(entry.Files)
+		for _, files := range entry.Instances {
+			sort.Strings(files)
+		}
+		l.Plugins[name] = entry
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(LockFileName, append(data, '\n'), 0644)
+}
+
+// Sync brings node_modules and the generated plugin files in line with the
+// lock file. Without a lock file, one is created from the installed plugins.
+func Sync() error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	installedPlugins, err := GetInstalledPlugins()
+	if err != nil {
+		return fmt.Errorf("checking installed plugins: %w", err)
+	}
+
+	if !utils.FileExists(LockFileName) {
+		lock, _ := LoadLockFile()
+		for name := range installedPlugins {
+			files, err := pluginFiles(name)
+			if err != nil {
+				return fmt.Errorf("reading plugin %s: %w", name, err)
+			}
+			lock.Plugins[name] = LockedPlugin{Version: installedVersion(name), Files: files}
+		}
+		if err := lock.Save(); err != nil {
+			return fmt.Errorf("writing %s: %w", LockFileName, err)
+		}
+		color.Green("Created %s from the %d installed plugins.", LockFileName, len(lock.Plugins))
+		output.Set(lockResult(lock))
+		return nil
+	}
+
+	lock, err := LoadLockFile()
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", LockFileName, err)
+	}
+
+	// Install plugins whose node_modules version differs from the lock file
+	var toInstall []string
+	for _, name := range sortedPluginNames(lock.Plugins) {
+		entry := lock.Plugins[name]
+		if installedVersion(name) != entry.Version {
+			if entry.Link != "" {
+				toInstall = append(toInstall, entry.Link)
+			} else {
+				toInstall = append(toInstall, name+"@"+entry.Version)
+			}
+		}
+	}
+	if len(toInstall) > 0 {
+		if err := npmInstall("Installing locked plugin versions... ", toInstall); err != nil {
+			return exitcode.New(exitcode.ToolFailure, "installing plugins: %v", err)
+		}
+	}
+
+	// Regenerate missing type and config files
+	restored := 0
+	for _, name := range sortedPluginNames(lock.Plugins) {
+		entry := lock.Plugins[name]
+		missing := len(entry.Files) == 0
+		for _, file := range entry.Files {
+			if !utils.FileExists(file) {
+				missing = true
+			}
+		}
+		for _, files := range entry.Instances {
+			for _, file := range files {
+				if !utils.FileExists(file) {
+					missing = true
+				}
+			}
+		}
+		if !missing {
+			continue
+		}
+
+		files, err := createPluginFiles(name)
+		if err != nil {
+			color.Red("Error creating files for %s: %v", name, err)
+			continue
+		}
+		if err := restoreInstances(name, entry.Instances); err != nil {
+			color.Red("Error creating instances of %s: %v", name, err)
+			continue
+		}
+		fmt.Printf("Restored files of %s\n", name)
+		restored++
+		lock.Plugins[name] = LockedPlugin{Version: entry.Version, Link: entry.Link, Files: files, Instances: entry.Instances}
+	}
+
+	if err := lock.Save(); err != nil {
+		return fmt.Errorf("writing %s: %w", LockFileName, err)
+	}
+
+	// Remove plugins that are installed but not locked
+	var toRemove []string
+	for name := range installedPlugins {
+		if _, locked := lock.Plugins[name]; !locked {
+			toRemove = append(toRemove, name)
+		}
+	}
+	sort.Strings(toRemove)
+	if err := removePlugins(toRemove); err != nil {
+		return err
+	}
+
+	if len(toInstall) == 0 && len(toRemove) == 0 && restored == 0 {
+		color.Green("Plugins are in sync with %s.", LockFileName)
+	} else {
+		color.Green("Plugins synced with %s.", LockFileName)
+	}
+	output.Set(lockResult(lock))
+	return nil
+}
+
+// lockResult returns the plugins of a lock file as the JSON result of
+// plugin sync
+func lockResult(lock *LockFile) Result {
+	result := Result{Plugins: []PluginResult{}}
+	for _, name := range sortedPluginNames(lock.Plugins) {
+		result.Plugins = append(result.Plugins, PluginResult{
+			Name:    name,
+			Version: lock.Plugins[name].Version,
+			Files:   lockedFiles(lock, name),
+		})
+	}
+	return result
+}
+
+// SplitSpec splits a package spec such as "@scope/name@1.2.3" into its name
+// and version
+func SplitSpec(spec string) (name, version string) {
+	at := strings.LastIndex(spec, "@")
+	if at <= 0 {
+		return spec, ""
+	}
+	return spec[:at], spec[at+1:]
+}
+
+// installedVersion returns the version of a package in node_modules, or ""
+// if it is not installed
+func installedVersion(name string) string {
+	pkg, err := readPackageJSON(name)
+	if err != nil {
+		return ""
+	}
+	return pkg.Version
+}
+
+// recordPlugins stores the resolved version and generated files of each
+// plugin in the lock file. Entries whose files are nil keep their files.
+func recordPlugins(files map[string][]string) error {
+	lock, err := LoadLockFile()
+	if err != nil {
+		return err
+	}
+
+	for name, pluginFiles := range files {
+		if pluginFiles == nil {
+			pluginFiles = lock.Plugins[name].Files
+		}
+		lock.Plugins[name] = LockedPlugin{
+			Version:   installedVersion(name),
+			Link:      lock.Plugins[name].Link,
+			Files:     pluginFiles,
+			Instances: lock.Plugins[name].Instances,
+		}
+	}
+	return lock.Save()
+}
+
+// recordLink stores a plugin linked from a local directory in the lock file
+func recordLink(name, link string, files []string) error {
+	lock, err := LoadLockFile()
+	if err != nil {
+		return err
+	}
+
+	lock.Plugins[name] = LockedPlugin{
+		Version:   installedVersion(name),
+		Link:      link,
+		Files:     files,
+		Instances: lock.Plugins[name].Instances,
+	}
+	return lock.Save()
+}
+
+// restoreInstances recreates the missing config files of locked instances
+func restoreInstances(pluginName string, instances map[string][]string) error {
+	if len(instances) == 0 {
+		return nil
+	}
+
+	_, loaderFileName, _, defaultConfig, err := getModuleInfo(pluginName)
+	if err != nil {
+		return err
+	}
+
+	for _, files := range instances {
+		if err := createInstanceFiles(loaderFileName, defaultConfig, files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forgetPlugins removes plugins from the lock file
+func forgetPlugins(names []string) error {
+	if !utils.FileExists(LockFileName) {
+		return nil
+	}
+
+	lock, err := LoadLockFile()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		delete(lock.Plugins, name)
+	}
+	return lock.Save()
+}
+
+// npmInstall installs package specs with a spinner. Specs given with a
+// version are pinned with --save-exact, others keep npm's default range.
+func npmInstall(message string, specs []string) error {
+	var exact, ranged []string
+	for _, spec := range specs {
+		if _, version := SplitSpec(spec); version != "" {
+			exact = append(exact, spec)
+		} else {
+			ranged = append(ranged, spec)
+		}
+	}
+	return runNPMInstall(message, exact, ranged)
+}
+
+// runNPMInstall installs the exact specs with --save-exact and the ranged
+// specs without it, with a spinner
+func runNPMInstall(message string, exact, ranged []string) error {
+	s := utils.NewSpinner(message)
+	s.Start()
+	defer s.Stop()
+
+	for _, group := range []struct {
+		specs []string
+		flags []string
+	}{
+		{exact, []string{"--save-exact"}},
+		{ranged, nil},
+	} {
+		if len(group.specs) == 0 {
+			continue
+		}
+
+		args := append([]string{"install"}, group.specs...)
+		args = append(args, group.flags...)
+		args = append(args, "--quiet", "--no-warnings", "--silent", "--progress=false")
+
+		cmd := exec.Command("npm", args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedPluginNames returns the names of the locked plugins in a stable order
+func sortedPluginNames(plugins map[string]LockedPlugin) []string {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,198 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// inProject writes files into a temporary project and makes it the working
+// directory for the rest of the test
+func inProject(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// fakeNPM puts an npm on the PATH that only records its arguments. The
+// returned function lists the recorded calls without their flags.
+func fakeNPM(t *testing.T) func() []string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake npm is a shell script")
+	}
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$*\" >> \"$NPM_LOG\"\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "npm"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NPM_LOG", log)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return func() []string {
+		data, _ := ioutil.ReadFile(log)
+		calls := []string{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var args []string
+			for _, arg := range strings.Fields(line) {
+				if !strings.HasPrefix(arg, "--") {
+					args = append(args, arg)
+				}
+			}
+			if len(args) > 0 {
+				calls = append(calls, strings.Join(args, " "))
+			}
+		}
+		return calls
+	}
+}
+
+// testPlugin returns the node_modules files of an eventsource plugin whose
+// loader type is its short name
+func testPlugin(short, version string) map[string]string {
+	dir := "node_modules/@godspeedsystems/plugins-" + short + "/"
+	return map[string]string{
+		dir + "package.json": fmt.Sprintf(`{"name": "@godspeedsystems/plugins-%s", "version": %q, "main": "index.js"}`, short, version),
+		dir + "index.js":     fmt.Sprintf("module.exports = {SourceType: 'ES', Type: %q, CONFIG_FILE_NAME: %q, DEFAULT_CONFIG: {port: 1}};\n", short, short),
+	}
+}
+
+// testPluginFiles returns the generated files of a plugin from testPlugin
+func testPluginFiles(short string) []string {
+	return []string{
+		filepath.Join("src", "eventsources", short+".yaml"),
+		filepath.Join("src", "eventsources", "types", short+".ts"),
+	}
+}
+
+func TestSync(t *testing.T) {
+	pluginA := "@godspeedsystems/plugins-a"
+	pluginB := "@godspeedsystems/plugins-b"
+	pluginC := "@godspeedsystems/plugins-c"
+
+	tests := []struct {
+		name      string
+		installed map[string]string
+		lock      map[string]LockedPlugin
+		noFiles   bool
+		calls     []string
+		want      map[string]LockedPlugin
+	}{
+		{
+			name:      "in sync",
+			installed: map[string]string{"a": "1.0.0"},
+			lock:      map[string]LockedPlugin{pluginA: {Version: "1.0.0", Files: testPluginFiles("a")}},
+			calls:     []string{},
+			want:      map[string]LockedPlugin{pluginA: {Version: "1.0.0", Files: testPluginFiles("a")}},
+		},
+		{
+			name:      "locked version differs",
+			installed: map[string]string{"a": "1.0.0", "b": "1.0.0"},
+			lock: map[string]LockedPlugin{
+				pluginA: {Version: "1.0.0", Files: testPluginFiles("a")},
+				pluginB: {Version: "1.1.0", Files: testPluginFiles("b")},
+			},
+			calls: []string{"install " + pluginB + "@1.1.0"},
+			want: map[string]LockedPlugin{
+				pluginA: {Version: "1.0.0", Files: testPluginFiles("a")},
+				pluginB: {Version: "1.1.0", Files: testPluginFiles("b")},
+			},
+		},
+		{
+			name:      "installed but not locked",
+			installed: map[string]string{"a": "1.0.0", "c": "2.0.0"},
+			lock:      map[string]LockedPlugin{pluginA: {Version: "1.0.0", Files: testPluginFiles("a")}},
+			calls:     []string{"uninstall " + pluginC},
+			want:      map[string]LockedPlugin{pluginA: {Version: "1.0.0", Files: testPluginFiles("a")}},
+		},
+		{
+			name:      "missing files are restored",
+			installed: map[string]string{"a": "1.0.0"},
+			lock:      map[string]LockedPlugin{pluginA: {Version: "1.0.0", Files: testPluginFiles("a")}},
+			noFiles:   true,
+			calls:     []string{},
+			want:      map[string]LockedPlugin{pluginA: {Version: "1.0.0", Files: testPluginFiles("a")}},
+		},
+		{
+			name:      "lock file created from installed plugins",
+			installed: map[string]string{"a": "1.0.0", "b": "1.2.0"},
+			calls:     []string{},
+			want: map[string]LockedPlugin{
+				pluginA: {Version: "1.0.0", Files: testPluginFiles("a")},
+				pluginB: {Version: "1.2.0", Files: testPluginFiles("b")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{".godspeed": "{}"}
+			dependencies := make(map[string]string)
+			for short, version := range tt.installed {
+				for name, content := range testPlugin(short, version) {
+					files[name] = content
+				}
+				dependencies["@godspeedsystems/plugins-"+short] = version
+				if !tt.noFiles {
+					for _, file := range testPluginFiles(short) {
+						files[filepath.ToSlash(file)] = "type: " + short + "\n"
+					}
+				}
+			}
+			pkg, _ := json.Marshal(map[string]interface{}{"dependencies": dependencies})
+			files["package.json"] = string(pkg)
+			if tt.lock != nil {
+				lock, _ := json.Marshal(LockFile{LockfileVersion: lockFileVersion, Plugins: tt.lock})
+				files[LockFileName] = string(lock)
+			}
+			inProject(t, files)
+			t.Setenv("HOME", t.TempDir())
+			calls := fakeNPM(t)
+
+			Sync()
+
+			if got := calls(); !reflect.DeepEqual(got, tt.calls) {
+				t.Errorf("npm calls = %q, want %q", got, tt.calls)
+			}
+			lock, err := LoadLockFile()
+			if err != nil {
+				t.Fatalf("LoadLockFile: %v", err)
+			}
+			if !reflect.DeepEqual(lock.Plugins, tt.want) {
+				t.Errorf("locked plugins = %+v, want %+v", lock.Plugins, tt.want)
+			}
+			for _, entry := range lock.Plugins {
+				for _, file := range entry.Files {
+					if _, err := os.Stat(file); err != nil {
+						t.Errorf("locked file %s: %v", file, err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// writeConfigFile writes the config YAML of a plugin. An existing file keeps
+// its values and comments; only the default keys it lacks are added. The
+// dotted paths of the added keys are returned.
+func writeConfigFile(path, loaderFileName string, defaultConfig map[string]interface{}) ([]string, error) {
+	config := map[string]interface{}{
+		"type": loaderFileName,
+	}
+
+	// Add default config
+	for k, v := range defaultConfig {
+		config[k] = v
+	}
+
+	if !utils.FileExists(path) {
+		yamlData, err := yaml.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ioutil.WriteFile(path, yamlData, 0644)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: expected a mapping at the top level", path)
+	}
+
+	added, err := mergeDefaults(root, config, "")
+	if err != nil || len(added) == 0 {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return added, ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// mergeDefaults adds the keys of defaults missing from node, recursing into
+// mappings present on both sides
+func mergeDefaults(node *yaml.Node, defaults map[string]interface{}, prefix string) ([]string, error) {
+	var added []string
+	for _, key := range sortedConfigKeys(defaults) {
+		value := mappingValue(node, key)
+		if value == nil {
+			var keyNode, valueNode yaml.Node
+			keyNode.SetString(key)
+			if err := valueNode.Encode(defaults[key]); err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &keyNode, &valueNode)
+			added = append(added, prefix+key)
+			continue
+		}
+
+		if nested, ok := defaults[key].(map[string]interface{}); ok && value.Kind == yaml.MappingNode {
+			nestedAdded, err := mergeDefaults(value, nested, prefix+key+".")
+			if err != nil {
+				return nil, err
+			}
+			added = append(added, nestedAdded...)
+		}
+	}
+	return added, nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// configChange is a default config key that differs between plugin versions
+type configChange struct {
+	Key      string
+	Kind     string
+	Previous interface{}
+	Current  interface{}
+}
+
+// Config change kinds
+const (
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeChanged = "changed"
+)
+
+// diffDefaults compares the default config of two plugin versions
+func diffDefaults(previous, current map[string]interface{}, prefix string) []configChange {
+	keys := make(map[string]bool)
+	for key := range previous {
+		keys[key] = true
+	}
+	for key := range current {
+		keys[key] = true
+	}
+
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	var changes []configChange
+	for _, key := range names {
+		before, hadBefore := previous[key]
+		after, hasAfter := current[key]
+		switch {
+		case !hadBefore:
+			changes = append(changes, configChange{Key: prefix + key, Kind: changeAdded, Current: after})
+		case !hasAfter:
+			changes = append(changes, configChange{Key: prefix + key, Kind: changeRemoved, Previous: before})
+		default:
+			beforeMap, beforeIsMap := before.(map[string]interface{})
+			afterMap, afterIsMap := after.(map[string]interface{})
+			if beforeIsMap && afterIsMap {
+				changes = append(changes, diffDefaults(beforeMap, afterMap, prefix+key+".")...)
+			} else if !reflect.DeepEqual(before, after) {
+				changes = append(changes, configChange{Key: prefix + key, Kind: changeChanged, Previous: before, Current: after})
+			}
+		}
+	}
+	return changes
+}
+
+// printConfigChanges prints the default config keys that changed in an update
+func printConfigChanges(pluginName string, changes []configChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	color.Cyan("\nDefault config of %s changed:", pluginName)
+	for _, change := range changes {
+		switch change.Kind {
+		case changeAdded:
+			color.Green("  + %s: %v", change.Key, change.Current)
+		case changeRemoved:
+			color.Red("  - %s (was %v)", change.Key, change.Previous)
+		default:
+			color.Yellow("  ~ %s: %v -> %v", change.Key, change.Previous, change.Current)
+		}
+	}
+}
+
+// sortedConfigKeys returns the keys of a config map in a stable order, with
+// "type" first
+func sortedConfigKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == "type" || keys[j] == "type" {
+			return keys[i] == "type"
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
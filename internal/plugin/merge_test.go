@@ -0,0 +1,173 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteConfigFile(t *testing.T) {
+	defaults := map[string]interface{}{
+		"port": 9092,
+		"client": map[string]interface{}{
+			"id":      "godspeed",
+			"retries": 3,
+		},
+		"brokers": []interface{}{"localhost:9092"},
+	}
+
+	tests := []struct {
+		name     string
+		existing *string
+		want     map[string]interface{}
+		added    []string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "new file",
+			want: map[string]interface{}{
+				"type":    "kafka",
+				"port":    9092,
+				"client":  map[string]interface{}{"id": "godspeed", "retries": 3},
+				"brokers": []interface{}{"localhost:9092"},
+			},
+		},
+		{
+			name:     "user values and comments are kept",
+			existing: stringPtr("# Kafka config\ntype: kafka\nport: 19092 # custom port\nclient:\n  id: my-service\nbrokers: [kafka:9092]\nextra: true\n"),
+			want: map[string]interface{}{
+				"type":    "kafka",
+				"port":    19092,
+				"client":  map[string]interface{}{"id": "my-service", "retries": 3},
+				"brokers": []interface{}{"kafka:9092"},
+				"extra":   true,
+			},
+			added:    []string{"client.retries"},
+			contains: []string{"# Kafka config", "port: 19092 # custom port"},
+		},
+		{
+			name:     "user type and scalar overrides of mappings are kept",
+			existing: stringPtr("type: custom\nclient: disabled\n"),
+			want: map[string]interface{}{
+				"type":    "custom",
+				"port":    9092,
+				"client":  "disabled",
+				"brokers": []interface{}{"localhost:9092"},
+			},
+			added: []string{"brokers", "port"},
+		},
+		{
+			name:     "complete file is left alone",
+			existing: stringPtr("# untouched\ntype: kafka\nport: 1\nclient: {id: x, retries: 0}\nbrokers: []\n"),
+			want: map[string]interface{}{
+				"type":    "kafka",
+				"port":    1,
+				"client":  map[string]interface{}{"id": "x", "retries": 0},
+				"brokers": []interface{}{},
+			},
+			contains: []string{"# untouched\ntype: kafka\nport: 1\nclient: {id: x, retries: 0}\n"},
+		},
+		{
+			name:     "empty file",
+			existing: stringPtr(""),
+			want: map[string]interface{}{
+				"type":    "kafka",
+				"port":    9092,
+				"client":  map[string]interface{}{"id": "godspeed", "retries": 3},
+				"brokers": []interface{}{"localhost:9092"},
+			},
+			added: []string{"type", "brokers", "client", "port"},
+		},
+		{
+			name:     "top level list",
+			existing: stringPtr("- a\n- b\n"),
+			wantErr:  "expected a mapping at the top level",
+		},
+		{
+			name:     "invalid yaml",
+			existing: stringPtr("type: [kafka\n"),
+			wantErr:  "kafka.yaml:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kafka.yaml")
+			if tt.existing != nil {
+				if err := ioutil.WriteFile(path, []byte(*tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			added, err := writeConfigFile(path, "kafka", defaults)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("writeConfigFile error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("writeConfigFile: %v", err)
+			}
+			if !reflect.DeepEqual(added, tt.added) {
+				t.Errorf("added = %q, want %q", added, tt.added)
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := yaml.Unmarshal(data, &got); err != nil {
+				t.Fatalf("written file is not valid YAML: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("written config = %v, want %v", got, tt.want)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("written file lacks %q:\n%s", want, data)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffDefaults(t *testing.T) {
+	previous := map[string]interface{}{
+		"port":    9092,
+		"timeout": 30,
+		"client":  map[string]interface{}{"id": "godspeed", "retries": 3},
+		"tls":     false,
+	}
+	current := map[string]interface{}{
+		"port":    9092,
+		"timeout": 60,
+		"client":  map[string]interface{}{"id": "godspeed", "backoff": 100},
+		"tls":     map[string]interface{}{"enabled": false},
+	}
+
+	want := []configChange{
+		{Key: "client.backoff", Kind: changeAdded, Current: 100},
+		{Key: "client.retries", Kind: changeRemoved, Previous: 3},
+		{Key: "timeout", Kind: changeChanged, Previous: 30, Current: 60},
+		{Key: "tls", Kind: changeChanged, Previous: false, Current: map[string]interface{}{"enabled": false}},
+	}
+	if got := diffDefaults(previous, current, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffDefaults = %+v, want %+v", got, want)
+	}
+
+	if changes := diffDefaults(previous, previous, ""); len(changes) != 0 {
+		t.Errorf("diffDefaults of equal configs = %+v, want none", changes)
+	}
+}
+
+// stringPtr returns a pointer to s
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,260 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFileName is the optional metadata file at the root of a plugin package
+const ManifestFileName = "godspeed-plugin.json"
+
+// Metadata sources
+const (
+	MetadataFromManifest    = ManifestFileName
+	MetadataFromPackageJSON = "package.json"
+	MetadataFromNode        = "node"
+)
+
+// Metadata describes what a plugin provides and how it is configured. Plugins
+// declare it in godspeed-plugin.json or in the "godspeed" section of their
+// package.json:
+//
+//	"godspeed": {
+//	  "sourceType": "DS",
+//	  "type": "axios",
+//	  "configFileName": "api",
+//	  "defaultConfig": { "base_url": "http://localhost:4000" }
+//	}
+type Metadata struct {
+	SourceType     string                 `json:"sourceType"`
+	Type           string                 `json:"type"`
+	ConfigFileName string                 `json:"configFileName"`
+	DefaultConfig  map[string]interface{} `json:"defaultConfig"`
+	Source         string                 `json:"-"`
+}
+
+// packageJSON holds the fields of a plugin's package.json the CLI reads
+type packageJSON struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Description string    `json:"description"`
+	Homepage    string    `json:"homepage"`
+	Godspeed    *Metadata `json:"godspeed"`
+}
+
+// InfoResult is the JSON result of plugin info. Missing lists the files of
+// the plugin that don't exist.
+type InfoResult struct {
+	Name           string                 `json:"name"`
+	Version        string                 `json:"version"`
+	Description    string                 `json:"description,omitempty"`
+	SourceType     string                 `json:"sourceType"`
+	LoaderType     string                 `json:"loaderType"`
+	ConfigFileName string                 `json:"configFileName"`
+	MetadataFrom   string                 `json:"metadataFrom"`
+	Files          []string               `json:"files"`
+	Missing        []string               `json:"missing"`
+	Instances      []Instance             `json:"instances"`
+	DefaultConfig  map[string]interface{} `json:"defaultConfig,omitempty"`
+	Docs           string                 `json:"docs"`
+}
+
+// readMetadata reads the metadata of an installed plugin from node_modules,
+// falling back to loading the plugin with node when it declares none
+func readMetadata(pluginName string) (*Metadata, error) {
+	dir := filepath.Join("node_modules", filepath.FromSlash(pluginName))
+
+	manifestPath := filepath.Join(dir, ManifestFileName)
+	if utils.FileExists(manifestPath) {
+		data, err := ioutil.ReadFile(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		var metadata Metadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("%s: %v", manifestPath, err)
+		}
+		metadata.Source = MetadataFromManifest
+		return metadata.validate(pluginName)
+	}
+
+	if pkg, err := readPackageJSON(pluginName); err == nil && pkg.Godspeed != nil {
+		pkg.Godspeed.Source = MetadataFromPackageJSON
+		return pkg.Godspeed.validate(pluginName)
+	}
+
+	metadata, err := metadataFromNode(pluginName)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Source = MetadataFromNode
+	return metadata.validate(pluginName)
+}
+
+// validate checks the metadata and fills in the defaults
+func (m *Metadata) validate(pluginName string) (*Metadata, error) {
+	m.SourceType = strings.ToUpper(m.SourceType)
+	switch m.SourceType {
+	case ModuleTypeDS, ModuleTypeES, ModuleTypeBoth:
+	default:
+		return nil, fmt.Errorf("plugin %s has an unknown source type %q, expected %s, %s or %s", pluginName, m.SourceType, ModuleTypeES, ModuleTypeDS, ModuleTypeBoth)
+	}
+
+	if m.Type == "" {
+		return nil, fmt.Errorf("plugin %s does not declare its loader type", pluginName)
+	}
+	if m.ConfigFileName == "" {
+		m.ConfigFileName = m.Type
+	}
+	if m.DefaultConfig == nil {
+		m.DefaultConfig = make(map[string]interface{})
+	}
+	return m, nil
+}
+
+// metadataFromNode loads the plugin with node to read the constants it exports.
+// This runs the plugin's code, so it is only used for plugins without a manifest.
+func metadataFromNode(pluginName string) (*Metadata, error) {
+	script := fmt.Sprintf(`
+		try {
+			const Module = require('%s');
+			console.log(JSON.stringify({
+				sourceType: Module.SourceType,
+				type: Module.Type,
+				configFileName: Module.CONFIG_FILE_NAME,
+				defaultConfig: Module.DEFAULT_CONFIG || {}
+			}));
+		} catch (e) {
+			console.error(e.message);
+			process.exit(1);
+		}
+	`, pluginName)
+
+	cmd := exec.Command("node", "-e", script)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting module info: %v", err)
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(out, &metadata); err != nil {
+		return nil, fmt.Errorf("error parsing module info: %v", err)
+	}
+	return &metadata, nil
+}
+
+// declaresMetadata reports whether an installed package describes itself as
+// a godspeed plugin
+func declaresMetadata(name string) bool {
+	if utils.FileExists(filepath.Join("node_modules", filepath.FromSlash(name), ManifestFileName)) {
+		return true
+	}
+	pkg, err := readPackageJSON(name)
+	return err == nil && pkg.Godspeed != nil
+}
+
+// readPackageJSON reads the package.json of an installed package
+func readPackageJSON(pluginName string) (*packageJSON, error) {
+	data, err := ioutil.ReadFile(filepath.Join("node_modules", filepath.FromSlash(pluginName), "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// Info prints the metadata of an installed plugin
+func Info(pluginName string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	pkg, err := readPackageJSON(pluginName)
+	if err != nil {
+		return fmt.Errorf("plugin %s is not installed. Run npm install or godspeed plugin sync", pluginName)
+	}
+
+	metadata, err := readMetadata(pluginName)
+	if err != nil {
+		return fmt.Errorf("reading plugin %s: %w", pluginName, err)
+	}
+
+	color.Cyan("%s@%s", pkg.Name, pkg.Version)
+	if pkg.Description != "" {
+		fmt.Println(pkg.Description)
+	}
+	fmt.Println()
+	fmt.Printf("Source type:      %s\n", metadata.SourceType)
+	fmt.Printf("Loader type:      %s\n", metadata.Type)
+	fmt.Printf("Config file name: %s\n", metadata.ConfigFileName)
+	fmt.Printf("Metadata from:    %s\n", metadata.Source)
+
+	result := InfoResult{
+		Name:           pkg.Name,
+		Version:        pkg.Version,
+		Description:    pkg.Description,
+		SourceType:     metadata.SourceType,
+		LoaderType:     metadata.Type,
+		ConfigFileName: metadata.ConfigFileName,
+		MetadataFrom:   metadata.Source,
+		Missing:        []string{},
+		Instances:      []Instance{},
+		DefaultConfig:  metadata.DefaultConfig,
+	}
+	defer output.Set(&result)
+
+	files := moduleFiles(metadata.SourceType, metadata.Type, metadata.ConfigFileName)
+	result.Files = append([]string{}, files...)
+	fmt.Println("Files:")
+	for _, file := range files {
+		status := ""
+		if !utils.FileExists(file) {
+			status = " (missing)"
+			result.Missing = append(result.Missing, file)
+		}
+		fmt.Printf("  %s%s\n", file, status)
+	}
+
+	if instances := pluginInstances(pluginName, metadata.SourceType, metadata.Type, metadata.ConfigFileName); len(instances) > 0 {
+		result.Instances = instances
+		fmt.Println("Instances:")
+		for _, instance := range instances {
+			fmt.Printf("  %s: %s\n", instance.Name, strings.Join(instance.Files, ", "))
+		}
+	}
+
+	if len(metadata.DefaultConfig) > 0 {
+		defaults, err := yaml.Marshal(metadata.DefaultConfig)
+		if err == nil {
+			fmt.Println("Default config:")
+			for _, line := range strings.Split(strings.TrimRight(string(defaults), "\n"), "\n") {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+
+	if metadata.Source == MetadataFromNode {
+		color.Yellow("\n%s declares no metadata, so it was loaded with node. Plugin authors can add a %q section to package.json or a %s file.", pluginName, "godspeed", ManifestFileName)
+	}
+
+	homepage := pkg.Homepage
+	if homepage == "" {
+		homepage = "https://www.npmjs.com/package/" + pluginName
+	}
+	result.Docs = homepage
+	color.Cyan("\nDocs: %s", homepage)
+	return nil
+}
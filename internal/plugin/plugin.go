@@ -11,135 +11,131 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/prompt"
+	"github.com/godspeedsystems/godspeed-cli/internal/semver"
 	"github.com/godspeedsystems/godspeed-cli/internal/utils"
-	"gopkg.in/yaml.v3"
 )
 
-// Plugin represents a Godspeed plugin
+// Plugin represents a Godspeed plugin in the catalog. Version optionally
+// records the latest published version, for use without the registry.
 type Plugin struct {
-	Value       string `json:"value"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Value               string   `json:"value"`
+	Name                string   `json:"name"`
+	Description         string   `json:"description"`
+	Type                string   `json:"type,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	MinFrameworkVersion string   `json:"minFrameworkVersion,omitempty"`
+	DocsURL             string   `json:"docsUrl,omitempty"`
+	Version             string   `json:"version,omitempty"`
 }
 
-// LoadPluginsList loads the list of available plugins
-func LoadPluginsList() ([]Plugin, error) {
-	// First try to load from the embedded plugins list
-	execPath, err := os.Executable()
-	if err != nil {
-		return nil, err
-	}
+// PluginResult is a plugin in the JSON result of the plugin commands. Files
+// are the files the command generated or removed, or for plugin list the
+// files the plugin owns.
+type PluginResult struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version,omitempty"`
+	Instance string   `json:"instance,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Dev      bool     `json:"dev,omitempty"`
+	Files    []string `json:"files"`
+	Error    string   `json:"error,omitempty"`
+}
 
-	// Path to the plugins list relative to the executable
-	pluginsPath := filepath.Join(filepath.Dir(execPath), "assets", "plugins_list.json")
+// Result is the JSON result of the plugin commands
+type Result struct {
+	Plugins []PluginResult `json:"plugins"`
+}
 
-	// Try to read the plugins list
-	data, err := ioutil.ReadFile(pluginsPath)
-	if err != nil {
-		// If not found, try to search on npm
-		return searchPluginsFromNpm()
+// DocsLink returns the documentation URL of the plugin
+func (p Plugin) DocsLink() string {
+	if p.DocsURL != "" {
+		return p.DocsURL
 	}
+	return "https://www.npmjs.com/package/" + p.Value
+}
 
-	var plugins []Plugin
-	err = json.Unmarshal(data, &plugins)
-	if err != nil {
+// GetInstalledPlugins returns the plugins declared in package.json, from
+// both dependencies and devDependencies, with their version ranges. Besides
+// @godspeedsystems plugins and catalog entries, packages that declare
+// godspeed metadata, such as linked local plugins, are included.
+func GetInstalledPlugins() (map[string]string, error) {
+	if err := utils.RequireProject(); err != nil {
 		return nil, err
 	}
 
-	return plugins, nil
-}
-
-// searchPluginsFromNpm searches for Godspeed plugins on npm
-func searchPluginsFromNpm() ([]Plugin, error) {
-	cmd := exec.Command("npm", "search", "@godspeedsystems/plugins", "--json")
-	output, err := cmd.Output()
+	dependencies, devDependencies, err := projectDependencies()
 	if err != nil {
 		return nil, err
 	}
 
-	var searchResults []struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		Version     string `json:"version"`
+	// Filter Godspeed plugins, including private ones from configured catalogs
+	catalog, _ := LoadPluginsList()
+	inCatalog := make(map[string]bool)
+	for _, plugin := range catalog {
+		inCatalog[plugin.Value] = true
 	}
 
-	err = json.Unmarshal(output, &searchResults)
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert npm search results to Plugin format
-	var plugins []Plugin
-	for _, result := range searchResults {
-		// Extract the plugin name without the prefix
-		nameParts := strings.Split(result.Name, "plugins-")
-		if len(nameParts) > 1 {
-			plugins = append(plugins, Plugin{
-				Value:       result.Name,
-				Name:        nameParts[1],
-				Description: result.Description,
-			})
+	plugins := make(map[string]string)
+	for _, deps := range []map[string]string{devDependencies, dependencies} {
+		for name, version := range deps {
+			if strings.HasPrefix(name, "@godspeedsystems/plugins") || inCatalog[name] || declaresMetadata(name) {
+				plugins[name] = version
+			}
 		}
 	}
 
 	return plugins, nil
 }
 
-// GetInstalledPlugins returns a list of installed plugins
-func GetInstalledPlugins() (map[string]string, error) {
-	if !utils.IsGodspeedProject() {
-		return nil, fmt.Errorf("not a godspeed project")
-	}
-
+// projectDependencies reads the dependencies and devDependencies of the
+// project's package.json
+func projectDependencies() (dependencies, devDependencies map[string]string, err error) {
 	// Read package.json
 	pkgPath := filepath.Join(".", "package.json")
 	if !utils.FileExists(pkgPath) {
-		return nil, fmt.Errorf("package.json not found")
+		return nil, nil, fmt.Errorf("package.json not found")
 	}
 
 	pkgData, err := ioutil.ReadFile(pkgPath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var pkg struct {
-		Dependencies map[string]string `json:"dependencies"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
 	}
 
 	err = json.Unmarshal(pkgData, &pkg)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Filter Godspeed plugins
-	plugins := make(map[string]string)
-	for name, version := range pkg.Dependencies {
-		if strings.HasPrefix(name, "@godspeedsystems/plugins") {
-			plugins[name] = version
-		}
-	}
-
-	return plugins, nil
+	return pkg.Dependencies, pkg.DevDependencies, nil
 }
 
-// Add adds a plugin to the project
-func Add(pluginName string) {
-	if !utils.IsGodspeedProject() {
-		return
+// Add adds a plugin to the project. The plugin may be given with a version,
+// e.g. "@godspeedsystems/plugins-express-as-http@1.0.3". Plugins incompatible
+// with the project's framework version are refused unless force is set.
+func Add(pluginSpec string, force bool) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
 	}
+	output.Set(Result{Plugins: []PluginResult{}})
 
 	// Load available plugins
 	availablePlugins, err := LoadPluginsList()
 	if err != nil {
-		color.Red("Error loading plugins list: %v", err)
-		return
+		return fmt.Errorf("loading plugins list: %w", err)
 	}
 
 	// Get installed plugins
 	installedPlugins, err := GetInstalledPlugins()
 	if err != nil {
-		color.Red("Error checking installed plugins: %v", err)
-		return
+		return fmt.Errorf("checking installed plugins: %w", err)
 	}
 
 	// Filter out installed plugins
@@ -150,37 +146,46 @@ func Add(pluginName string) {
 		}
 	}
 
+	pluginName, pluginVersion := SplitSpec(pluginSpec)
+
 	// No plugin name provided, show interactive menu
 	if pluginName == "" {
 		if len(missingPlugins) == 0 {
-			color.Red("All plugins are already installed.")
-			return
+			color.Yellow("All plugins are already installed.")
+			return nil
 		}
 
+		s := utils.NewSpinner("Fetching plugin versions... ")
+		s.Start()
+		versions := latestVersions(missingPlugins)
+		s.Stop()
+
 		var selectedPlugins []string
 		options := make([]string, len(missingPlugins))
 		optionsMap := make(map[string]string)
 
 		for i, plugin := range missingPlugins {
-			displayName := fmt.Sprintf("%s - %s", plugin.Name, plugin.Description)
+			displayName := fmt.Sprintf("%s - %s", pluginLabel(plugin, versions), plugin.Description)
 			options[i] = displayName
 			optionsMap[displayName] = plugin.Value
 		}
 
-		prompt := &survey.MultiSelect{
-			Message: "Please select godspeed plugin to install:",
+		// Typing filters the list with the same fuzzy matching as plugin search
+		question := &survey.MultiSelect{
+			Message: "Please select godspeed plugin to install (type to filter):",
 			Options: options,
+			Filter: func(filter string, value string, index int) bool {
+				return matchScore(filter, missingPlugins[index]) > 0
+			},
 		}
 
-		err = survey.AskOne(prompt, &selectedPlugins)
+		err = prompt.Ask(question, &selectedPlugins, "<pluginName>")
 		if err != nil {
-			color.Red("Error: %v", err)
-			return
+			return err
 		}
 
 		if len(selectedPlugins) == 0 {
-			color.Red("No plugins selected.")
-			return
+			return fmt.Errorf("no plugins selected")
 		}
 
 		// Convert display names to plugin names
@@ -189,51 +194,54 @@ func Add(pluginName string) {
 			pluginsToInstall[i] = optionsMap[name]
 		}
 
-		installPlugins(pluginsToInstall)
+		return installPlugins(pluginsToInstall, force)
 	} else {
 		// Find the plugin by name
-		found := false
-		for _, plugin := range availablePlugins {
+		var found *Plugin
+		for i, plugin := range availablePlugins {
 			if plugin.Value == pluginName {
-				found = true
+				found = &availablePlugins[i]
 				break
 			}
 		}
 
-		if !found {
-			color.Red("\nPlease provide a valid plugin name.\n")
-			return
+		if found == nil {
+			return exitcode.New(exitcode.Usage, "%s is not in the plugin catalog. Find plugins with godspeed plugin search", pluginName)
 		}
 
 		// Check if plugin is already installed
 		if _, installed := installedPlugins[pluginName]; installed {
-			color.Yellow("Plugin %s is already installed.", pluginName)
-			return
+			if pluginVersion == "" || pluginVersion == installedVersion(pluginName) {
+				color.Yellow("Plugin %s is already installed.", pluginName)
+				return nil
+			}
 		}
 
-		installPlugins([]string{pluginName})
+		if err := installPlugins([]string{pluginSpec}, force); err != nil {
+			return err
+		}
 
 		color.Cyan("\nFor detailed documentation and examples, visit:")
-		color.Yellow("https://www.npmjs.com/package/%s\n", pluginName)
+		color.Yellow("%s\n", found.DocsLink())
 	}
+	return nil
 }
 
-// Remove removes a plugin from the project
-func Remove(pluginName string) {
-	if !utils.IsGodspeedProject() {
-		return
+// Remove removes a plugin from the project. With an instance name, only that
+// named instance of the plugin is removed.
+func Remove(pluginName, instance string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
 	}
 
 	// Get installed plugins
 	installedPlugins, err := GetInstalledPlugins()
 	if err != nil {
-		color.Red("Error checking installed plugins: %v", err)
-		return
+		return fmt.Errorf("checking installed plugins: %w", err)
 	}
 
 	if len(installedPlugins) == 0 {
-		color.Red("There are no eventsource/datasource plugins installed.")
-		return
+		return fmt.Errorf("there are no eventsource/datasource plugins installed")
 	}
 
 	var pluginsToRemove []string
@@ -241,8 +249,10 @@ func Remove(pluginName string) {
 	// If plugin name is provided, remove that specific plugin
 	if pluginName != "" {
 		if _, installed := installedPlugins[pluginName]; !installed {
-			color.Red("Plugin %s is not installed.", pluginName)
-			return
+			return fmt.Errorf("plugin %s is not installed", pluginName)
+		}
+		if instance != "" {
+			return removeInstance(pluginName, instance)
 		}
 		pluginsToRemove = []string{pluginName}
 	} else {
@@ -254,8 +264,7 @@ func Remove(pluginName string) {
 		// Load available plugins to get descriptions
 		availablePlugins, err := LoadPluginsList()
 		if err != nil {
-			color.Red("Error loading plugins list: %v", err)
-			return
+			return fmt.Errorf("loading plugins list: %w", err)
 		}
 
 		// Create a map for quick lookup
@@ -270,25 +279,23 @@ func Remove(pluginName string) {
 			if description == "" {
 				description = "No description available"
 			}
-			displayName := fmt.Sprintf("%s - %s", name, description)
+			displayName := fmt.Sprintf("%s - %s%s", name, description, instancesLabel(name))
 			options = append(options, displayName)
 			optionsMap[displayName] = name
 		}
 
-		prompt := &survey.MultiSelect{
+		question := &survey.MultiSelect{
 			Message: "Please select godspeed plugin to uninstall:",
 			Options: options,
 		}
 
-		err = survey.AskOne(prompt, &selectedPlugins)
+		err = prompt.Ask(question, &selectedPlugins, "<pluginName>")
 		if err != nil {
-			color.Red("Error: %v", err)
-			return
+			return err
 		}
 
 		if len(selectedPlugins) == 0 {
-			color.Red("No plugins selected to remove.")
-			return
+			return fmt.Errorf("no plugins selected to remove")
 		}
 
 		// Convert display names to plugin names
@@ -297,25 +304,38 @@ func Remove(pluginName string) {
 		}
 	}
 
-	removePlugins(pluginsToRemove)
+	return removePlugins(pluginsToRemove)
 }
 
-// Update updates plugins in the project
-func Update() {
-	if !utils.IsGodspeedProject() {
-		return
+// Update updates plugins in the project: the named plugins, every installed
+// plugin with all, or the ones the user selects. Updates to versions
+// incompatible with the project's framework version are refused unless
+// force is set.
+func Update(names []string, all, force bool) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
 	}
 
 	// Get installed plugins
 	installedPlugins, err := GetInstalledPlugins()
 	if err != nil {
-		color.Red("Error checking installed plugins: %v", err)
-		return
+		return fmt.Errorf("checking installed plugins: %w", err)
 	}
 
 	if len(installedPlugins) == 0 {
-		color.Red("There are no eventsource/datasource plugins installed.")
-		return
+		return fmt.Errorf("there are no eventsource/datasource plugins installed")
+	}
+
+	if all {
+		return updatePlugins(sortedKeys(installedPlugins), force)
+	}
+	if len(names) > 0 {
+		for _, name := range names {
+			if _, installed := installedPlugins[name]; !installed {
+				return fmt.Errorf("plugin %s is not installed", name)
+			}
+		}
+		return updatePlugins(names, force)
 	}
 
 	// Interactive selection
@@ -326,8 +346,7 @@ func Update() {
 	// Load available plugins to get descriptions
 	availablePlugins, err := LoadPluginsList()
 	if err != nil {
-		color.Red("Error loading plugins list: %v", err)
-		return
+		return fmt.Errorf("loading plugins list: %w", err)
 	}
 
 	// Create a map for quick lookup
@@ -342,25 +361,23 @@ func Update() {
 		if description == "" {
 			description = "No description available"
 		}
-		displayName := fmt.Sprintf("%s - %s", name, description)
+		displayName := fmt.Sprintf("%s - %s%s", name, description, instancesLabel(name))
 		options = append(options, displayName)
 		optionsMap[displayName] = name
 	}
 
-	prompt := &survey.MultiSelect{
+	question := &survey.MultiSelect{
 		Message: "Please select godspeed plugin to update:",
 		Options: options,
 	}
 
-	err = survey.AskOne(prompt, &selectedPlugins)
+	err = prompt.Ask(question, &selectedPlugins, "<pluginName> or --all")
 	if err != nil {
-		color.Red("Error: %v", err)
-		return
+		return err
 	}
 
 	if len(selectedPlugins) == 0 {
-		color.Red("No plugins selected to update.")
-		return
+		return fmt.Errorf("no plugins selected to update")
 	}
 
 	// Convert display names to plugin names
@@ -369,58 +386,95 @@ func Update() {
 		pluginsToUpdate = append(pluginsToUpdate, optionsMap[name])
 	}
 
-	updatePlugins(pluginsToUpdate)
+	return updatePlugins(pluginsToUpdate, force)
 }
 
-// installPlugins installs the specified plugins
-func installPlugins(plugins []string) {
+// installPlugins installs the specified plugins, given as "name" or
+// "name@version", pinning the resolved versions in the lock file. Plugins
+// refused as incompatible make it return an exitcode.ValidationFailure error
+// after the others are installed.
+func installPlugins(plugins []string, force bool) error {
+	requested := len(plugins)
+	plugins = checkCompatibility(plugins, force)
+	refused := requested - len(plugins)
 	if len(plugins) == 0 {
-		return
+		return incompatibleError(refused)
 	}
 
-	// Start spinner
-	s := utils.NewSpinner("Installing plugins... ")
-	s.Start()
-
-	// Create npm install command with all plugins
-	args := append([]string{"install"}, plugins...)
-	args = append(args, "--quiet", "--no-warnings", "--silent", "--progress=false")
-
-	cmd := exec.Command("npm", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
-	s.Stop()
-
-	if err != nil {
-		color.Red("\nError installing plugins: %v", err)
-		return
+	if err := npmInstall("Installing plugins... ", plugins); err != nil {
+		return exitcode.New(exitcode.ToolFailure, "installing plugins: %v", err)
 	}
 
 	color.Green("\nPlugins installed successfully!")
 
+	result := Result{Plugins: []PluginResult{}}
+	defer output.Set(&result)
+
 	// Create necessary files for each plugin
-	for _, pluginName := range plugins {
-		if err := createPluginFiles(pluginName); err != nil {
+	var failed []string
+	generated := make(map[string][]string)
+	for _, spec := range plugins {
+		pluginName, _ := SplitSpec(spec)
+		installed := PluginResult{Name: pluginName, Version: installedVersion(pluginName), Files: []string{}}
+		files, err := createPluginFiles(pluginName)
+		if err != nil {
 			color.Red("Error creating files for %s: %v", pluginName, err)
+			installed.Error = err.Error()
+			result.Plugins = append(result.Plugins, installed)
+			failed = append(failed, pluginName)
+			continue
 		}
+		generated[pluginName] = files
+		installed.Files = append(installed.Files, files...)
+		result.Plugins = append(result.Plugins, installed)
+	}
+
+	if err := recordPlugins(generated); err != nil {
+		return fmt.Errorf("writing %s: %w", LockFileName, err)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("creating the files of %s failed", strings.Join(failed, ", "))
 	}
 
 	color.Cyan("Happy coding with Godspeed! 🚀🎉\n")
+	return incompatibleError(refused)
+}
+
+// incompatibleError returns the error for plugins refused as incompatible
+// with the project's framework version, or nil if none were
+func incompatibleError(refused int) error {
+	if refused == 0 {
+		return nil
+	}
+	return exitcode.New(exitcode.ValidationFailure, "%d plugins are incompatible with the project's framework version", refused)
 }
 
 // removePlugins removes the specified plugins
-func removePlugins(plugins []string) {
+func removePlugins(plugins []string) error {
 	if len(plugins) == 0 {
-		return
+		return nil
 	}
 
-	// For each plugin, remove the associated files
+	// For each plugin, move the associated files to the trash
+	t, err := newTrash()
+	if err != nil {
+		return fmt.Errorf("locating trash: %w", err)
+	}
+	result := Result{Plugins: []PluginResult{}}
 	for _, pluginName := range plugins {
-		if err := removePluginFiles(pluginName); err != nil {
+		removed := PluginResult{Name: pluginName, Files: []string{}}
+		if files, err := pluginFiles(pluginName); err == nil {
+			for _, file := range files {
+				if utils.FileExists(file) {
+					removed.Files = append(removed.Files, file)
+				}
+			}
+		}
+		if err := removePluginFiles(pluginName, t); err != nil {
 			color.Red("Error removing files for %s: %v", pluginName, err)
+			removed.Error = err.Error()
 		}
+		result.Plugins = append(result.Plugins, removed)
 	}
 
 	// Start spinner
@@ -435,46 +489,135 @@ func removePlugins(plugins []string) {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	s.Stop()
 
 	if err != nil {
-		color.Red("\nError uninstalling plugins: %v", err)
-		return
+		return exitcode.New(exitcode.ToolFailure, "uninstalling plugins: %v", err)
+	}
+
+	if err := forgetPlugins(plugins); err != nil {
+		color.Red("Error writing %s: %v", LockFileName, err)
 	}
 
 	color.Green("\nPlugins uninstalled successfully!")
 	color.Cyan("Happy coding with Godspeed! 🚀🎉\n")
+	output.Set(result)
+	return nil
 }
 
-// updatePlugins updates the specified plugins
-func updatePlugins(plugins []string) {
-	if len(plugins) == 0 {
-		return
+// updatePlugins updates the specified plugins to their latest version, or
+// to the newest version compatible with the project's framework when the
+// latest is not. Pinned plugins stay pinned. Plugins refused as incompatible
+// make it return an exitcode.ValidationFailure error after the others are
+// updated.
+func updatePlugins(plugins []string, force bool) error {
+	installedPlugins, err := GetInstalledPlugins()
+	if err != nil {
+		return fmt.Errorf("checking installed plugins: %w", err)
 	}
 
-	// Start spinner
-	s := utils.NewSpinner("Updating plugins... ")
-	s.Start()
+	frameworks := projectFrameworkVersions()
+	specs := make([]string, len(plugins))
+	for i, pluginName := range plugins {
+		specs[i] = pluginName + "@" + updateTarget(pluginName, frameworks, force)
+	}
+	requested := len(specs)
+	specs = checkCompatibility(specs, force)
+	refused := requested - len(specs)
 
-	// Create npm update command with all plugins
-	args := append([]string{"update"}, plugins...)
-	args = append(args, "--quiet", "--no-warnings", "--silent", "--progress=false")
+	result := Result{Plugins: []PluginResult{}}
+	defer output.Set(&result)
+	if len(specs) == 0 {
+		return incompatibleError(refused)
+	}
 
-	cmd := exec.Command("npm", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Remember the default config of each plugin to show what the update changes
+	plugins = nil
+	previousDefaults := make(map[string]map[string]interface{})
+	for _, spec := range specs {
+		pluginName, _ := SplitSpec(spec)
+		plugins = append(plugins, pluginName)
+		if _, _, _, defaultConfig, err := getModuleInfo(pluginName); err == nil {
+			previousDefaults[pluginName] = defaultConfig
+		}
+	}
 
-	err := cmd.Run()
-	s.Stop()
+	// Plugins pinned in package.json are installed with --save-exact again
+	var exact, ranged []string
+	for _, spec := range specs {
+		pluginName, _ := SplitSpec(spec)
+		if semver.IsExact(installedPlugins[pluginName]) {
+			exact = append(exact, spec)
+		} else {
+			ranged = append(ranged, spec)
+		}
+	}
+	if err := runNPMInstall("Updating plugins... ", exact, ranged); err != nil {
+		return exitcode.New(exitcode.ToolFailure, "updating plugins: %v", err)
+	}
 
-	if err != nil {
-		color.Red("\nError updating plugins: %v", err)
-		return
+	updated := make(map[string][]string)
+	for _, pluginName := range plugins {
+		updated[pluginName] = nil
+
+		previous, ok := previousDefaults[pluginName]
+		if !ok {
+			continue
+		}
+		_, _, _, current, err := getModuleInfo(pluginName)
+		if err != nil {
+			continue
+		}
+
+		changes := diffDefaults(previous, current, "")
+		printConfigChanges(pluginName, changes)
+		if len(changes) > 0 {
+			// Merge new default keys, keeping the values already configured
+			if updated[pluginName], err = createPluginFiles(pluginName); err != nil {
+				color.Red("Error updating files for %s: %v", pluginName, err)
+			}
+		}
+	}
+
+	if utils.FileExists(LockFileName) {
+		if err := recordPlugins(updated); err != nil {
+			color.Red("Error writing %s: %v", LockFileName, err)
+		}
+	}
+	for _, pluginName := range plugins {
+		result.Plugins = append(result.Plugins, PluginResult{
+			Name:    pluginName,
+			Version: installedVersion(pluginName),
+			Files:   append([]string{}, updated[pluginName]...),
+		})
 	}
 
 	color.Green("\nPlugins updated successfully!")
 	color.Cyan("Happy coding with Godspeed! 🚀🎉\n")
+	return incompatibleError(refused)
+}
+
+// updateTarget returns the version a plugin updates to: its latest release,
+// or the newest release compatible with the framework versions when the
+// latest is not and force isn't set. It falls back to the latest tag when
+// the registry can't be reached.
+func updateTarget(name string, frameworks []frameworkVersion, force bool) string {
+	_, latest, err := registryVersions(name)
+	if err != nil || latest == "" {
+		return "latest"
+	}
+	if force || len(frameworks) == 0 || compatibilityError(name+"@"+latest, frameworks) == nil {
+		return latest
+	}
+
+	// Without a newer compatible version, the latest one is refused later
+	compatible, err := newestCompatible(name, frameworks)
+	if err != nil || compatible == "" || !newer(compatible, installedVersion(name)) {
+		return latest
+	}
+	color.Yellow("%s@%s is not compatible with the project. Updating to %s, the newest compatible version.", name, latest, compatible)
+	return compatible
 }
 
 // Module types constants
@@ -486,77 +629,88 @@ const (
 
 // getModuleInfo gets information about a plugin module
 func getModuleInfo(pluginName string) (moduleType, loaderFileName, yamlFileName string, defaultConfig map[string]interface{}, err error) {
-	// Run a small Node.js script to get the module info
-	script := fmt.Sprintf(`
-		try {
-			const Module = require('%s');
-			console.log(JSON.stringify({
-				moduleType: Module.SourceType,
-				loaderFileName: Module.Type,
-				yamlFileName: Module.CONFIG_FILE_NAME,
-				defaultConfig: Module.DEFAULT_CONFIG || {}
-			}));
-		} catch (e) {
-			console.error(e.message);
-			process.exit(1);
-		}
-	`, pluginName)
-
-	cmd := exec.Command("node", "-e", script)
-	output, err := cmd.Output()
+	metadata, err := readMetadata(pluginName)
 	if err != nil {
-		return "", "", "", nil, fmt.Errorf("error getting module info: %v", err)
-	}
-
-	var result struct {
-		ModuleType     string                 `json:"moduleType"`
-		LoaderFileName string                 `json:"loaderFileName"`
-		YamlFileName   string                 `json:"yamlFileName"`
-		DefaultConfig  map[string]interface{} `json:"defaultConfig"`
-	}
-
-	if err := json.Unmarshal(output, &result); err != nil {
-		return "", "", "", nil, fmt.Errorf("error parsing module info: %v", err)
+		return "", "", "", nil, err
 	}
-
-	return result.ModuleType, result.LoaderFileName, result.YamlFileName, result.DefaultConfig, nil
+	return metadata.SourceType, metadata.Type, metadata.ConfigFileName, metadata.DefaultConfig, nil
 }
 
-// createPluginFiles creates the necessary files for a plugin
-func createPluginFiles(pluginName string) error {
+// createPluginFiles creates the necessary files for a plugin and returns their paths
+func createPluginFiles(pluginName string) ([]string, error) {
 	moduleType, loaderFileName, yamlFileName, defaultConfig, err := getModuleInfo(pluginName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	switch moduleType {
 	case ModuleTypeBoth:
 		// Create files for both EventSource and DataSource
 		if err := createEventSourceFiles(pluginName, loaderFileName, yamlFileName, defaultConfig); err != nil {
-			return err
+			return nil, err
 		}
 
 		if err := createDataSourceFiles(pluginName, loaderFileName, yamlFileName, defaultConfig); err != nil {
-			return err
+			return nil, err
 		}
 
 	case ModuleTypeDS:
 		// Create files for DataSource
 		if err := createDataSourceFiles(pluginName, loaderFileName, yamlFileName, defaultConfig); err != nil {
-			return err
+			return nil, err
 		}
 
 	case ModuleTypeES:
 		// Create files for EventSource
 		if err := createEventSourceFiles(pluginName, loaderFileName, yamlFileName, defaultConfig); err != nil {
-			return err
+			return nil, err
 		}
 
 	default:
-		return fmt.Errorf("unknown module type: %s", moduleType)
+		return nil, fmt.Errorf("unknown module type: %s", moduleType)
 	}
 
-	return nil
+	// Named instances get the new default keys as well
+	if err := mergeInstanceDefaults(pluginName, moduleType, loaderFileName, yamlFileName, defaultConfig); err != nil {
+		return nil, err
+	}
+
+	return moduleFiles(moduleType, loaderFileName, yamlFileName), nil
+}
+
+// instancesLabel describes the named instances of a plugin in prompts
+func instancesLabel(pluginName string) string {
+	names := instanceNames(pluginName)
+	if len(names) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (instances: %s)", strings.Join(names, ", "))
+}
+
+// pluginFiles returns the paths of the files generated for a plugin
+func pluginFiles(pluginName string) ([]string, error) {
+	moduleType, loaderFileName, yamlFileName, _, err := getModuleInfo(pluginName)
+	if err != nil {
+		return nil, err
+	}
+	return moduleFiles(moduleType, loaderFileName, yamlFileName), nil
+}
+
+// moduleFiles returns the type and config files of a plugin module
+func moduleFiles(moduleType, loaderFileName, yamlFileName string) []string {
+	var files []string
+	if moduleType == ModuleTypeES || moduleType == ModuleTypeBoth {
+		files = append(files,
+			filepath.Join("src", "eventsources", "types", loaderFileName+".ts"),
+			filepath.Join("src", "eventsources", yamlFileName+".yaml"))
+	}
+	if moduleType == ModuleTypeDS || moduleType == ModuleTypeBoth {
+		files = append(files, filepath.Join("src", "datasources", "types", loaderFileName+".ts"))
+		if loaderFileName != "prisma" {
+			files = append(files, filepath.Join("src", "datasources", yamlFileName+".yaml"))
+		}
+	}
+	return files
 }
 
 // createEventSourceFiles creates the files for an EventSource plugin
@@ -578,23 +732,9 @@ export default EventSource;
 		return err
 	}
 
-	// Create YAML file
-	config := map[string]interface{}{
-		"type": loaderFileName,
-	}
-
-	// Add default config
-	for k, v := range defaultConfig {
-		config[k] = v
-	}
-
-	yamlData, err := yaml.Marshal(config)
-	if err != nil {
-		return err
-	}
-
+	// Create YAML file, keeping the values of an existing one
 	yamlPath := filepath.Join("src", "eventsources", fmt.Sprintf("%s.yaml", yamlFileName))
-	return ioutil.WriteFile(yamlPath, yamlData, 0644)
+	return createConfigFile(yamlPath, loaderFileName, defaultConfig)
 }
 
 // createDataSourceFiles creates the files for a DataSource plugin
@@ -621,27 +761,26 @@ export default DataSource;
 		return nil
 	}
 
-	// Create YAML file
-	config := map[string]interface{}{
-		"type": loaderFileName,
-	}
-
-	// Add default config
-	for k, v := range defaultConfig {
-		config[k] = v
-	}
+	// Create YAML file, keeping the values of an existing one
+	yamlPath := filepath.Join("src", "datasources", fmt.Sprintf("%s.yaml", yamlFileName))
+	return createConfigFile(yamlPath, loaderFileName, defaultConfig)
+}
 
-	yamlData, err := yaml.Marshal(config)
+// createConfigFile writes a plugin config YAML and reports the default keys
+// merged into an existing one
+func createConfigFile(yamlPath, loaderFileName string, defaultConfig map[string]interface{}) error {
+	added, err := writeConfigFile(yamlPath, loaderFileName, defaultConfig)
 	if err != nil {
 		return err
 	}
-
-	yamlPath := filepath.Join("src", "datasources", fmt.Sprintf("%s.yaml", yamlFileName))
-	return ioutil.WriteFile(yamlPath, yamlData, 0644)
+	if len(added) > 0 {
+		color.Cyan("Added new default keys to %s: %s", yamlPath, strings.Join(added, ", "))
+	}
+	return nil
 }
 
-// removePluginFiles removes the files associated with a plugin
-func removePluginFiles(pluginName string) error {
+// removePluginFiles moves the files associated with a plugin to the trash
+func removePluginFiles(pluginName string, t *trash) error {
 	moduleType, loaderFileName, yamlFileName, _, err := getModuleInfo(pluginName)
 	if err != nil {
 		return err
@@ -650,23 +789,23 @@ func removePluginFiles(pluginName string) error {
 	switch moduleType {
 	case ModuleTypeBoth:
 		// Remove both EventSource and DataSource files
-		if err := removeEventSourceFiles(loaderFileName, yamlFileName); err != nil {
+		if err := removeEventSourceFiles(loaderFileName, yamlFileName, t); err != nil {
 			return err
 		}
 
-		if err := removeDataSourceFiles(loaderFileName, yamlFileName); err != nil {
+		if err := removeDataSourceFiles(loaderFileName, yamlFileName, t); err != nil {
 			return err
 		}
 
 	case ModuleTypeDS:
 		// Remove DataSource files
-		if err := removeDataSourceFiles(loaderFileName, yamlFileName); err != nil {
+		if err := removeDataSourceFiles(loaderFileName, yamlFileName, t); err != nil {
 			return err
 		}
 
 	case ModuleTypeES:
 		// Remove EventSource files
-		if err := removeEventSourceFiles(loaderFileName, yamlFileName); err != nil {
+		if err := removeEventSourceFiles(loaderFileName, yamlFileName, t); err != nil {
 			return err
 		}
 
@@ -674,38 +813,37 @@ func removePluginFiles(pluginName string) error {
 		return fmt.Errorf("unknown module type: %s", moduleType)
 	}
 
+	// Named instances go with the plugin
+	for _, instance := range pluginInstances(pluginName, moduleType, loaderFileName, yamlFileName) {
+		for _, file := range instance.Files {
+			if err := t.move(file); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
-// removeEventSourceFiles removes the files for an EventSource plugin
-func removeEventSourceFiles(loaderFileName, yamlFileName string) error {
+// removeEventSourceFiles moves the files for an EventSource plugin to the trash
+func removeEventSourceFiles(loaderFileName, yamlFileName string, t *trash) error {
 	// Remove TypeScript file
 	tsPath := filepath.Join("src", "eventsources", "types", fmt.Sprintf("%s.ts", loaderFileName))
-	if utils.FileExists(tsPath) {
-		if err := os.Remove(tsPath); err != nil {
-			return err
-		}
+	if err := t.move(tsPath); err != nil {
+		return err
 	}
 
 	// Remove YAML file
 	yamlPath := filepath.Join("src", "eventsources", fmt.Sprintf("%s.yaml", yamlFileName))
-	if utils.FileExists(yamlPath) {
-		if err := os.Remove(yamlPath); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return t.move(yamlPath)
 }
 
-// removeDataSourceFiles removes the files for a DataSource plugin
-func removeDataSourceFiles(loaderFileName, yamlFileName string) error {
+// removeDataSourceFiles moves the files for a DataSource plugin to the trash
+func removeDataSourceFiles(loaderFileName, yamlFileName string, t *trash) error {
 	// Remove TypeScript file
 	tsPath := filepath.Join("src", "datasources", "types", fmt.Sprintf("%s.ts", loaderFileName))
-	if utils.FileExists(tsPath) {
-		if err := os.Remove(tsPath); err != nil {
-			return err
-		}
+	if err := t.move(tsPath); err != nil {
+		return err
 	}
 
 	// Skip YAML file for prisma
@@ -715,11 +853,5 @@ func removeDataSourceFiles(loaderFileName, yamlFileName string) error {
 
 	// Remove YAML file
 	yamlPath := filepath.Join("src", "datasources", fmt.Sprintf("%s.yaml", yamlFileName))
-	if utils.FileExists(yamlPath) {
-		if err := os.Remove(yamlPath); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return t.move(yamlPath)
 }
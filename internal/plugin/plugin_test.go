@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeNPMScript installs plugins into node_modules and package.json the way
+// npm does, publishing 1.0.0 and $NPM_LATEST of every package
+const fakeNPMScript = `#!/usr/bin/env node
+const fs = require('fs');
+const args = process.argv.slice(2);
+const latest = process.env.NPM_LATEST;
+if (args[0] === 'view') {
+  console.log(JSON.stringify({versions: ['1.0.0', latest], 'dist-tags': {latest}}));
+  process.exit(0);
+}
+if (args[0] !== 'install') process.exit(0);
+
+const exact = args.includes('--save-exact');
+const pkg = JSON.parse(fs.readFileSync('package.json', 'utf8'));
+pkg.dependencies = pkg.dependencies || {};
+for (const spec of args.slice(1).filter((arg) => !arg.startsWith('--'))) {
+  const at = spec.lastIndexOf('@');
+  const name = at > 0 ? spec.slice(0, at) : spec;
+  const version = at > 0 ? spec.slice(at + 1) : '1.0.0';
+  const dir = 'node_modules/' + name;
+  fs.mkdirSync(dir, {recursive: true});
+  fs.writeFileSync(dir + '/package.json', JSON.stringify({
+    name, version,
+    godspeed: {sourceType: 'ES', type: 'express', configFileName: 'http', defaultConfig: {port: 3000}},
+  }));
+  pkg.dependencies[name] = exact ? version : '^' + version;
+}
+fs.writeFileSync('package.json', JSON.stringify(pkg));
+`
+
+// installingNPM puts an npm on the PATH that installs plugins without a
+// registry. Its latest version of every package is latest.
+func installingNPM(t *testing.T, latest string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake npm is a node script")
+	}
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "npm"), []byte(fakeNPMScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NPM_LATEST", latest)
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestAddThenUpdate(t *testing.T) {
+	name := "@godspeedsystems/plugins-express-as-http"
+
+	tests := []struct {
+		spec      string
+		added     string
+		updated   string
+		installed string
+	}{
+		{spec: name, added: "^1.0.0", updated: "^1.2.0", installed: "1.2.0"},
+		{spec: name + "@1.0.0", added: "1.0.0", updated: "1.2.0", installed: "1.2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			inProject(t, map[string]string{".godspeed": "{}", "package.json": "{}"})
+			t.Setenv("HOME", t.TempDir())
+			installingNPM(t, "1.2.0")
+
+			if err := Add(tt.spec, false); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			dependencies, _, err := projectDependencies()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dependencies[name] != tt.added {
+				t.Errorf("package.json after add = %q, want %q", dependencies[name], tt.added)
+			}
+
+			if err := Update([]string{name}, false, false); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			dependencies, _, err = projectDependencies()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dependencies[name] != tt.updated {
+				t.Errorf("package.json after update = %q, want %q", dependencies[name], tt.updated)
+			}
+			if got := installedVersion(name); got != tt.installed {
+				t.Errorf("installed version = %q, want %q", got, tt.installed)
+			}
+
+			data, err := ioutil.ReadFile(LockFileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lock LockFile
+			if err := json.Unmarshal(data, &lock); err != nil {
+				t.Fatal(err)
+			}
+			if got := lock.Plugins[name].Version; got != tt.installed {
+				t.Errorf("locked version = %q, want %q", got, tt.installed)
+			}
+		})
+	}
+}
+
+func TestUpdateOutdatedPinnedPlugin(t *testing.T) {
+	name := "@godspeedsystems/plugins-express-as-http"
+	inProject(t, map[string]string{".godspeed": "{}", "package.json": "{}"})
+	t.Setenv("HOME", t.TempDir())
+	installingNPM(t, "1.2.0")
+
+	if err := Add(name+"@1.0.0", false); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	installedPlugins, err := GetInstalledPlugins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdated := findOutdated(installedPlugins)
+	if len(outdated) != 1 || outdated[0].Wanted != "1.0.0" || outdated[0].Latest != "1.2.0" {
+		t.Fatalf("outdated = %+v, want %s wanted 1.0.0 latest 1.2.0", outdated, name)
+	}
+
+	// The update the outdated hint suggests reaches the latest version
+	if err := Update([]string{name}, false, false); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	installedPlugins, err = GetInstalledPlugins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outdated := findOutdated(installedPlugins); len(outdated) != 0 {
+		t.Errorf("outdated after update = %+v, want none", outdated)
+	}
+	if installedPlugins[name] != "1.2.0" {
+		t.Errorf("package.json after update = %q, want the pin 1.2.0", installedPlugins[name])
+	}
+}
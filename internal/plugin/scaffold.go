@@ -0,0 +1,377 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// packageNamePattern matches valid npm package names
+var packageNamePattern = regexp.MustCompile(`^(@[a-z0-9-~][a-z0-9-._~]*/)?[a-z0-9-~][a-z0-9-._~]*$`)
+
+// loaderPattern matches characters not allowed in a loader type
+var loaderPattern = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ScaffoldResult is the JSON result of plugin new
+type ScaffoldResult struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Loader string   `json:"loader"`
+	Dir    string   `json:"dir"`
+	Files  []string `json:"files"`
+}
+
+// scaffoldPackage is the package.json of a new plugin
+type scaffoldPackage struct {
+	Name             string            `json:"name"`
+	Version          string            `json:"version"`
+	Description      string            `json:"description"`
+	Main             string            `json:"main"`
+	Types            string            `json:"types"`
+	Files            []string          `json:"files"`
+	Scripts          map[string]string `json:"scripts"`
+	Godspeed         Metadata          `json:"godspeed"`
+	PeerDependencies map[string]string `json:"peerDependencies"`
+	DevDependencies  map[string]string `json:"devDependencies"`
+}
+
+// dataSourceTemplate is the DataSource class of a new plugin
+const dataSourceTemplate = `class DataSource extends GSDataSource {
+  protected async initClient(): Promise<PlainObject> {
+    // Create the client of the service from this.config, the datasource YAML
+    return {};
+  }
+
+  async execute(ctx: GSContext, args: PlainObject): Promise<any> {
+    const {
+      meta: { fnNameInWorkflow },
+      ...rest
+    } = args;
+
+    try {
+      // Workflows call datasource.<name>.<method>
+      const method = fnNameInWorkflow.split(".")[2];
+      return new GSStatus(true, 200, undefined, { method, args: rest });
+    } catch (error: any) {
+      return new GSStatus(false, 500, error.message);
+    }
+  }
+}
+`
+
+// eventSourceTemplate is the EventSource class of a new plugin
+const eventSourceTemplate = `class EventSource extends GSEventSource {
+  protected async initClient(): Promise<PlainObject> {
+    // Create the client of the service from this.config, the eventsource YAML
+    return {};
+  }
+
+  async subscribeToEvent(
+    eventRoute: string,
+    eventConfig: PlainObject,
+    processEvent: (event: GSCloudEvent, eventConfig: PlainObject) => Promise<GSStatus>,
+    event?: PlainObject
+  ): Promise<void> {
+    // Listen for eventRoute with the client and hand every event to processEvent
+  }
+}
+`
+
+// indexTemplate is the entry point of a new plugin
+const indexTemplate = `import { %[1]s } from "@godspeedsystems/core";
+
+%[2]s
+const SourceType = "%[3]s";
+const Type = "%[4]s";
+const CONFIG_FILE_NAME = "%[5]s";
+const DEFAULT_CONFIG = %[6]s;
+
+export { %[7]s, SourceType, Type, CONFIG_FILE_NAME, DEFAULT_CONFIG };
+`
+
+// testTemplate checks that the exports and the package.json metadata agree
+const testTemplate = `import * as plugin from "../src";
+
+const pkg = require("../package.json");
+
+describe("%[1]s", () => {
+  it("exports the metadata declared in package.json", () => {
+    expect(pkg.godspeed).toEqual({
+      sourceType: plugin.SourceType,
+      type: plugin.Type,
+      configFileName: plugin.CONFIG_FILE_NAME,
+      defaultConfig: plugin.DEFAULT_CONFIG,
+    });
+  });
+%[2]s});
+`
+
+// tsconfigTemplate is the TypeScript config of a new plugin
+const tsconfigTemplate = `{
+  "compilerOptions": {
+    "target": "ES2020",
+    "module": "commonjs",
+    "declaration": true,
+    "outDir": "dist",
+    "rootDir": "src",
+    "strict": true,
+    "esModuleInterop": true,
+    "resolveJsonModule": true,
+    "skipLibCheck": true
+  },
+  "include": ["src"]
+}
+`
+
+// jestConfigTemplate is the jest config of a new plugin
+const jestConfigTemplate = `module.exports = {
+  preset: "ts-jest",
+  testEnvironment: "node",
+  testMatch: ["**/test/**/*.test.ts"],
+};
+`
+
+// readmeTemplate is the README of a new plugin
+const readmeTemplate = "# %[1]s\n\n" +
+	"Godspeed %[2]s plugin of type `%[3]s`.\n\n" +
+	"## Development\n\n" +
+	"```bash\nnpm install\nnpm run build\nnpm test\n```\n\n" +
+	"Link it into a Godspeed project to try it without publishing:\n\n" +
+	"```bash\ncd path/to/project\ngodspeed plugin link path/to/%[4]s\n```\n\n" +
+	"## Config\n\n" +
+	"`config/%[5]s.yaml` is a sample config. The CLI writes it to %[6]s when the plugin is added. " +
+	"The defaults come from the `godspeed` section of `package.json`, which must match the constants exported by `src/index.ts`.\n"
+
+// New scaffolds a TypeScript plugin package in dir. The loader type defaults
+// to the plugin name without the "plugins-" prefix and "-as-..." suffix.
+func New(name, sourceType, loader, dir string) error {
+	if !packageNamePattern.MatchString(name) {
+		return exitcode.New(exitcode.Usage, "invalid package name %q", name)
+	}
+
+	sourceType = strings.ToUpper(sourceType)
+	if sourceType != ModuleTypeES && sourceType != ModuleTypeDS && sourceType != ModuleTypeBoth {
+		return exitcode.New(exitcode.Usage, "invalid plugin type %q. Use ES, DS or BOTH", sourceType)
+	}
+
+	baseName := name[strings.LastIndex(name, "/")+1:]
+	if loader == "" {
+		loader = strings.TrimPrefix(baseName, "plugins-")
+		if i := strings.Index(loader, "-as-"); i > 0 {
+			loader = loader[:i]
+		}
+	}
+	loader = loaderPattern.ReplaceAllString(loader, "-")
+	if loader == "" {
+		return fmt.Errorf("please provide the loader type with --loader")
+	}
+
+	if dir == "" {
+		dir = baseName
+	}
+	if utils.DirExists(dir) {
+		return fmt.Errorf("directory %s already exists", dir)
+	}
+
+	files, err := scaffoldFiles(name, sourceType, loader, dir)
+	if err != nil {
+		return fmt.Errorf("creating plugin: %w", err)
+	}
+
+	paths := make([]string, 0, len(files))
+	for file := range files {
+		paths = append(paths, file)
+	}
+	sort.Strings(paths)
+
+	result := ScaffoldResult{Name: name, Type: sourceType, Loader: loader, Dir: dir, Files: []string{}}
+	defer output.Set(&result)
+
+	for _, file := range paths {
+		path := filepath.Join(dir, file)
+		if err := utils.CreateDir(filepath.Dir(path)); err != nil {
+			return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+		}
+		if err := ioutil.WriteFile(path, []byte(files[file]), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		fmt.Printf("Created %s\n", path)
+		result.Files = append(result.Files, path)
+	}
+
+	color.Green("\nCreated %s plugin %s in %s", sourceType, name, dir)
+	color.Cyan("Next steps:")
+	fmt.Printf("  cd %s && npm install && npm run build && npm test\n", dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	fmt.Printf("  cd path/to/project && godspeed plugin link %s\n", dir)
+	return nil
+}
+
+// scaffoldFiles returns the contents of a new plugin package by path
+func scaffoldFiles(name, sourceType, loader, dir string) (map[string]string, error) {
+	defaultConfig := make(map[string]interface{})
+	var imports, classes, exports []string
+	var tests string
+	var target string
+
+	if sourceType == ModuleTypeDS || sourceType == ModuleTypeBoth {
+		defaultConfig["timeout"] = 5000
+		imports = append(imports, "GSContext", "GSDataSource", "GSStatus", "PlainObject")
+		classes = append(classes, dataSourceTemplate)
+		exports = append(exports, "DataSource")
+		tests += "\n  it(\"exports a DataSource\", () => {\n    expect(plugin.DataSource).toBeDefined();\n  });\n"
+		target = "`src/datasources/" + loader + ".yaml`"
+	}
+	if sourceType == ModuleTypeES || sourceType == ModuleTypeBoth {
+		defaultConfig["port"] = 3000
+		imports = append(imports, "GSCloudEvent", "GSEventSource")
+		if sourceType == ModuleTypeES {
+			imports = append(imports, "GSStatus", "PlainObject")
+		}
+		classes = append(classes, eventSourceTemplate)
+		exports = append(exports, "EventSource")
+		tests += "\n  it(\"exports an EventSource\", () => {\n    expect(plugin.EventSource).toBeDefined();\n  });\n"
+		if target != "" {
+			target += " and "
+		}
+		target += "`src/eventsources/" + loader + ".yaml`"
+	}
+
+	sort.Strings(imports)
+
+	defaults, err := json.MarshalIndent(defaultConfig, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := scaffoldPackage{
+		Name:        name,
+		Version:     "0.1.0",
+		Description: fmt.Sprintf("Godspeed %s plugin", loader),
+		Main:        "dist/index.js",
+		Types:       "dist/index.d.ts",
+		Files:       []string{"dist"},
+		Scripts: map[string]string{
+			"build":   "tsc",
+			"prepare": "npm run build",
+			"test":    "jest",
+		},
+		Godspeed: Metadata{
+			SourceType:     sourceType,
+			Type:           loader,
+			ConfigFileName: loader,
+			DefaultConfig:  defaultConfig,
+		},
+		PeerDependencies: map[string]string{CorePackage: "^2.0.0"},
+		DevDependencies: map[string]string{
+			CorePackage:   "^2.0.0",
+			"@types/jest": "^29.5.0",
+			"jest":        "^29.7.0",
+			"ts-jest":     "^29.1.0",
+			"typescript":  "^5.3.0",
+		},
+	}
+	packageData, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	sampleConfig := map[string]interface{}{"type": loader}
+	for key, value := range defaultConfig {
+		sampleConfig[key] = value
+	}
+	var sample strings.Builder
+	for _, key := range sortedConfigKeys(sampleConfig) {
+		fmt.Fprintf(&sample, "%s: %v\n", key, sampleConfig[key])
+	}
+
+	kind := map[string]string{
+		ModuleTypeDS:   "datasource",
+		ModuleTypeES:   "eventsource",
+		ModuleTypeBoth: "eventsource and datasource",
+	}[sourceType]
+
+	return map[string]string{
+		"package.json":                          string(packageData) + "\n",
+		"tsconfig.json":                         tsconfigTemplate,
+		"jest.config.js":                        jestConfigTemplate,
+		".gitignore":                            "node_modules\ndist\n",
+		"README.md":                             fmt.Sprintf(readmeTemplate, name, kind, loader, filepath.Base(dir), loader, target),
+		filepath.Join("config", loader+".yaml"): sample.String(),
+		filepath.Join("src", "index.ts"): fmt.Sprintf(indexTemplate,
+			strings.Join(imports, ", "), strings.Join(classes, "\n"), sourceType, loader, loader,
+			string(defaults), strings.Join(exports, ", ")),
+		filepath.Join("test", "index.test.ts"): fmt.Sprintf(testTemplate, name, tests),
+	}, nil
+}
+
+// Link installs a local plugin package into the project as a symlink and
+// generates its files, so that it can be tried without publishing
+func Link(path string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(path, "package.json"))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", filepath.Join(path, "package.json"), err)
+	}
+
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return fmt.Errorf("parsing %s: %w", filepath.Join(path, "package.json"), err)
+	}
+	if pkg.Name == "" {
+		return fmt.Errorf("%s has no package name", filepath.Join(path, "package.json"))
+	}
+	if pkg.Godspeed == nil && !utils.FileExists(filepath.Join(path, ManifestFileName)) {
+		color.Yellow("%s declares no godspeed metadata; it will be loaded with node.", pkg.Name)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", path, err)
+	}
+	rel := abs
+	if cwd, err := filepath.Abs("."); err == nil {
+		if r, err := filepath.Rel(cwd, abs); err == nil {
+			rel = r
+		}
+	}
+	link := "file:" + filepath.ToSlash(rel)
+
+	if err := npmInstall("Linking plugin... ", []string{link}); err != nil {
+		return exitcode.New(exitcode.ToolFailure, "linking %s: %v", path, err)
+	}
+
+	files, err := createPluginFiles(pkg.Name)
+	if err != nil {
+		return fmt.Errorf("creating files for %s: %w", pkg.Name, err)
+	}
+	output.Set(Result{Plugins: []PluginResult{{
+		Name:    pkg.Name,
+		Version: installedVersion(pkg.Name),
+		Files:   append([]string{}, files...),
+	}}})
+
+	if err := recordLink(pkg.Name, link, files); err != nil {
+		color.Red("Error writing %s: %v", LockFileName, err)
+	}
+
+	color.Green("\nLinked %s from %s", pkg.Name, path)
+	if utils.FileExists(filepath.Join(path, "src", "index.ts")) && !utils.DirExists(filepath.Join(path, "dist")) {
+		color.Yellow("Run npm run build in %s before starting the project.", path)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScaffoldFiles(t *testing.T) {
+	tests := []struct {
+		sourceType string
+		defaults   map[string]interface{}
+		exports    string
+		sample     string
+	}{
+		{ModuleTypeES, map[string]interface{}{"port": float64(3000)}, "export { EventSource, SourceType, Type, CONFIG_FILE_NAME, DEFAULT_CONFIG }", "type: queue\nport: 3000\n"},
+		{ModuleTypeDS, map[string]interface{}{"timeout": float64(5000)}, "export { DataSource, SourceType, Type, CONFIG_FILE_NAME, DEFAULT_CONFIG }", "type: queue\ntimeout: 5000\n"},
+		{ModuleTypeBoth, map[string]interface{}{"port": float64(3000), "timeout": float64(5000)}, "export { DataSource, EventSource, SourceType, Type, CONFIG_FILE_NAME, DEFAULT_CONFIG }", "type: queue\nport: 3000\ntimeout: 5000\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sourceType, func(t *testing.T) {
+			files, err := scaffoldFiles("@acme/plugins-queue-as-eventsource", tt.sourceType, "queue", "queue-plugin")
+			if err != nil {
+				t.Fatalf("scaffoldFiles: %v", err)
+			}
+
+			var pkg packageJSON
+			if err := json.Unmarshal([]byte(files["package.json"]), &pkg); err != nil {
+				t.Fatalf("package.json: %v", err)
+			}
+			if pkg.Name != "@acme/plugins-queue-as-eventsource" || pkg.Godspeed == nil {
+				t.Fatalf("package.json = %s, want the name and godspeed metadata", files["package.json"])
+			}
+			metadata, err := pkg.Godspeed.validate(pkg.Name)
+			if err != nil {
+				t.Fatalf("metadata: %v", err)
+			}
+			if metadata.SourceType != tt.sourceType || metadata.Type != "queue" || metadata.ConfigFileName != "queue" {
+				t.Errorf("metadata = %+v, want %s queue", metadata, tt.sourceType)
+			}
+			if !reflect.DeepEqual(metadata.DefaultConfig, tt.defaults) {
+				t.Errorf("default config = %v, want %v", metadata.DefaultConfig, tt.defaults)
+			}
+
+			if sample := files[filepath.Join("config", "queue.yaml")]; sample != tt.sample {
+				t.Errorf("sample config = %q, want %q", sample, tt.sample)
+			}
+			index := files[filepath.Join("src", "index.ts")]
+			for _, want := range []string{tt.exports, `const SourceType = "` + tt.sourceType + `"`, `const Type = "queue"`} {
+				if !strings.Contains(index, want) {
+					t.Errorf("src/index.ts lacks %q:\n%s", want, index)
+				}
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		loader string
+		want   string
+	}{
+		{"@acme/plugins-queue-as-datasource-as-eventsource", "", "queue"},
+		{"plugins-kafka", "", "kafka"},
+		{"my-plugin", "", "my-plugin"},
+		{"my-plugin", "Rabbit MQ", "Rabbit-MQ"},
+	}
+
+	for _, tt := range tests {
+		inProject(t, map[string]string{})
+		New(tt.name, "es", tt.loader, "plugin")
+
+		data, err := ioutil.ReadFile(filepath.Join("plugin", "package.json"))
+		if err != nil {
+			t.Fatalf("New(%q) wrote no package.json: %v", tt.name, err)
+		}
+		var pkg packageJSON
+		if err := json.Unmarshal(data, &pkg); err != nil {
+			t.Fatal(err)
+		}
+		if pkg.Godspeed.Type != tt.want || pkg.Godspeed.SourceType != ModuleTypeES {
+			t.Errorf("New(%q, %q) loader = %s %s, want ES %s", tt.name, tt.loader, pkg.Godspeed.SourceType, pkg.Godspeed.Type, tt.want)
+		}
+	}
+
+	for _, invalid := range [][2]string{{"Bad Name", "ES"}, {"good-name", "XX"}} {
+		inProject(t, map[string]string{})
+		New(invalid[0], invalid[1], "", "plugin")
+		if _, err := ioutil.ReadDir("plugin"); err == nil {
+			t.Errorf("New(%q, %q) created the plugin", invalid[0], invalid[1])
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// versionLookupTimeout bounds the registry lookups of the latest versions
+const versionLookupTimeout = 5 * time.Second
+
+// defaultRegistry is used when no npm registry is configured in the environment
+const defaultRegistry = "https://registry.npmjs.org/"
+
+// SearchedPlugin is a catalog plugin in the JSON result of plugin search.
+// Name is the package name and Title the name shown in the catalog.
+type SearchedPlugin struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Type        string   `json:"type,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Installed   bool     `json:"installed"`
+}
+
+// SearchResult is the JSON result of plugin search, best matches first
+type SearchResult struct {
+	Plugins []SearchedPlugin `json:"plugins"`
+}
+
+// Search prints the catalog plugins matching a query, best matches first.
+// sourceType optionally restricts the results to es, ds or both plugins.
+func Search(query, sourceType string) error {
+	if sourceType != "" && !validSourceTypeFilter(sourceType) {
+		return exitcode.New(exitcode.Usage, "invalid plugin type %q. Use es, ds or both", sourceType)
+	}
+
+	availablePlugins, err := LoadPluginsList()
+	if err != nil {
+		return fmt.Errorf("loading plugins list: %w", err)
+	}
+
+	results := rankPlugins(availablePlugins, query, sourceType)
+	result := SearchResult{Plugins: []SearchedPlugin{}}
+	defer output.Set(&result)
+	if len(results) == 0 {
+		color.Yellow("No plugins match %q.", query)
+		return nil
+	}
+
+	installedPlugins := make(map[string]string)
+	if utils.FileExists(".godspeed") {
+		installedPlugins, _ = GetInstalledPlugins()
+	}
+	versions := latestVersions(results)
+
+	for _, plugin := range results {
+		installed := ""
+		_, isInstalled := installedPlugins[plugin.Value]
+		if isInstalled {
+			installed = " (installed)"
+		}
+		result.Plugins = append(result.Plugins, SearchedPlugin{
+			Name:        plugin.Value,
+			Title:       plugin.Name,
+			Type:        strings.ToUpper(plugin.Type),
+			Version:     versions[plugin.Value],
+			Description: plugin.Description,
+			Tags:        append([]string{}, plugin.Tags...),
+			Installed:   isInstalled,
+		})
+		color.Cyan("%s%s", pluginLabel(plugin, versions), installed)
+		fmt.Printf("  %s\n", plugin.Description)
+		if len(plugin.Tags) > 0 {
+			fmt.Printf("  tags: %s\n", strings.Join(plugin.Tags, ", "))
+		}
+	}
+	return nil
+}
+
+// validSourceTypeFilter reports whether a --type value is known
+func validSourceTypeFilter(sourceType string) bool {
+	switch strings.ToUpper(sourceType) {
+	case ModuleTypeES, ModuleTypeDS, ModuleTypeBoth:
+		return true
+	}
+	return false
+}
+
+// matchesSourceType reports whether a plugin provides the requested kind.
+// BOTH plugins match es and ds.
+func matchesSourceType(plugin Plugin, sourceType string) bool {
+	if sourceType == "" {
+		return true
+	}
+	pluginType := strings.ToUpper(plugin.Type)
+	sourceType = strings.ToUpper(sourceType)
+	return pluginType == sourceType || (pluginType == ModuleTypeBoth && sourceType != ModuleTypeBoth)
+}
+
+// rankPlugins returns the plugins matching query and sourceType, best first
+func rankPlugins(plugins []Plugin, query, sourceType string) []Plugin {
+	type ranked struct {
+		plugin Plugin
+		score  int
+	}
+
+	var matches []ranked
+	for _, plugin := range plugins {
+		if !matchesSourceType(plugin, sourceType) {
+			continue
+		}
+		if score := matchScore(query, plugin); score > 0 {
+			matches = append(matches, ranked{plugin, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].plugin.Name < matches[j].plugin.Name
+	})
+
+	results := make([]Plugin, len(matches))
+	for i, match := range matches {
+		results[i] = match.plugin
+	}
+	return results
+}
+
+// matchScore scores how well a plugin matches every word of a query, or
+// returns 0 if some word matches nothing. An empty query matches everything.
+func matchScore(query string, plugin Plugin) int {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return 1
+	}
+
+	name := strings.ToLower(plugin.Name)
+	shortName := strings.TrimPrefix(name, "plugins-")
+	value := strings.ToLower(plugin.Value)
+	description := strings.ToLower(plugin.Description)
+
+	total := 0
+	for _, word := range words {
+		best := 0
+		consider := func(score int) {
+			if score > best {
+				best = score
+			}
+		}
+
+		switch {
+		case name == word || shortName == word:
+			consider(100)
+		case strings.HasPrefix(shortName, word):
+			consider(80)
+		case strings.Contains(name, word) || strings.Contains(value, word):
+			consider(60)
+		}
+		for _, tag := range plugin.Tags {
+			tag = strings.ToLower(tag)
+			if tag == word {
+				consider(50)
+			} else if strings.HasPrefix(tag, word) {
+				consider(40)
+			}
+		}
+		if strings.Contains(description, word) {
+			consider(30)
+		}
+		consider(subsequenceScore(word, shortName))
+
+		// Tolerate typos in longer words, e.g. "schedular"
+		if len(word) > 3 {
+			candidates := append(strings.FieldsFunc(shortName, func(r rune) bool { return r == '-' }), plugin.Tags...)
+			for _, candidate := range candidates {
+				if editDistance(word, strings.ToLower(candidate)) <= len(word)/4 {
+					consider(15)
+				}
+			}
+		}
+
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// subsequenceScore scores word as a subsequence of text, e.g. "pgsql" in
+// "postgresql", preferring tight matches. It returns 0 if word is not one or
+// its letters are too far apart.
+func subsequenceScore(word, text string) int {
+	if len(word) < 2 {
+		return 0
+	}
+
+	start, pos := -1, 0
+	for _, r := range word {
+		i := strings.IndexRune(text[pos:], r)
+		if i < 0 {
+			return 0
+		}
+		if start < 0 {
+			start = pos + i
+		}
+		pos += i + 1
+	}
+
+	gaps := (pos - start) - len(word)
+	if gaps > len(word) {
+		return 0
+	}
+	return 25 - gaps
+}
+
+// editDistance returns the Levenshtein distance of two words
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// pluginLabel describes a plugin with its type and latest version
+func pluginLabel(plugin Plugin, versions map[string]string) string {
+	label := plugin.Name
+	if plugin.Type != "" {
+		label += " [" + strings.ToUpper(plugin.Type) + "]"
+	}
+	if version := versions[plugin.Value]; version != "" {
+		label += " v" + version
+	}
+	return label
+}
+
+// latestVersions looks up the latest published version of each plugin in the
+// npm registry, falling back to the version recorded in the catalog
+func latestVersions(plugins []Plugin) map[string]string {
+	registry := os.Getenv("npm_config_registry")
+	if registry == "" {
+		registry = os.Getenv("NPM_CONFIG_REGISTRY")
+	}
+	if registry == "" {
+		registry = defaultRegistry
+	}
+	registry = strings.TrimSuffix(registry, "/")
+
+	client := &http.Client{Timeout: versionLookupTimeout}
+	versions := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, plugin := range plugins {
+		if plugin.Version != "" {
+			versions[plugin.Value] = plugin.Version
+		}
+	}
+
+	for _, plugin := range plugins {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			resp, err := client.Get(registry + "/" + url.PathEscape(name) + "/latest")
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+
+			var manifest struct {
+				Version string `json:"version"`
+			}
+			if json.NewDecoder(resp.Body).Decode(&manifest) != nil || manifest.Version == "" {
+				return
+			}
+
+			mu.Lock()
+			versions[name] = manifest.Version
+			mu.Unlock()
+		}(plugin.Value)
+	}
+
+	wg.Wait()
+	return versions
+}
@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testCatalog = []Plugin{
+	{
+		Value:       "@godspeedsystems/plugins-kafka-as-eventsource",
+		Name:        "plugins-kafka-as-eventsource",
+		Description: "Consume Kafka topics",
+		Type:        ModuleTypeES,
+		Tags:        []string{"kafka", "queue"},
+	},
+	{
+		Value:       "@godspeedsystems/plugins-cron-as-eventsource",
+		Name:        "plugins-cron-as-eventsource",
+		Description: "Run scheduled jobs",
+		Type:        ModuleTypeES,
+		Tags:        []string{"scheduler", "cron"},
+	},
+	{
+		Value:       "@godspeedsystems/plugins-prisma-as-datastore",
+		Name:        "plugins-prisma-as-datastore",
+		Description: "Prisma ORM for postgresql and mysql",
+		Type:        ModuleTypeDS,
+		Tags:        []string{"sql", "postgresql"},
+	},
+	{
+		Value:       "@godspeedsystems/plugins-aws-as-datasource",
+		Name:        "plugins-aws-as-datasource",
+		Description: "AWS services",
+		Type:        ModuleTypeBoth,
+	},
+}
+
+func TestMatchScore(t *testing.T) {
+	kafka, cron, prisma := testCatalog[0], testCatalog[1], testCatalog[2]
+
+	tests := []struct {
+		name   string
+		query  string
+		plugin Plugin
+		want   int
+	}{
+		{"empty query", "", kafka, 1},
+		{"exact name", "plugins-kafka-as-eventsource", kafka, 100},
+		{"short name prefix", "kafka", kafka, 80},
+		{"case insensitive", "KAFKA", kafka, 80},
+		{"name substring", "as-eventsource", cron, 60},
+		{"exact tag", "queue", kafka, 50},
+		{"tag prefix", "sq", prisma, 40},
+		{"description", "orm", prisma, 30},
+		{"typo", "schedular", cron, 15},
+		{"every word adds up", "kafka queue", kafka, 130},
+		{"a word matching nothing", "kafka cron", kafka, 0},
+		{"no match", "redis", prisma, 0},
+	}
+
+	for _, tt := range tests {
+		if got := matchScore(tt.query, tt.plugin); got != tt.want {
+			t.Errorf("%s: matchScore(%q, %s) = %d, want %d", tt.name, tt.query, tt.plugin.Name, got, tt.want)
+		}
+	}
+}
+
+func TestSubsequenceScore(t *testing.T) {
+	tests := []struct {
+		word, text string
+		want       int
+	}{
+		{"kafka", "kafka", 25},
+		{"ka", "xkxa", 24},
+		{"pgsql", "postgresql", 20},
+		{"ab", "axxxxb", 0},
+		{"xyz", "abc", 0},
+		{"ba", "ab", 0},
+		{"a", "abc", 0},
+	}
+
+	for _, tt := range tests {
+		if got := subsequenceScore(tt.word, tt.text); got != tt.want {
+			t.Errorf("subsequenceScore(%q, %q) = %d, want %d", tt.word, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"abc", "abc", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"schedular", "scheduler", 1},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRankPlugins(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		sourceType string
+		want       []string
+	}{
+		{"best match first", "kafka", "", []string{"plugins-kafka-as-eventsource"}},
+		{"ties sorted by name", "eventsource", "", []string{"plugins-cron-as-eventsource", "plugins-kafka-as-eventsource"}},
+		{"source type includes BOTH", "", "ds", []string{"plugins-aws-as-datasource", "plugins-prisma-as-datastore"}},
+		{"source type filters matches", "kafka", "ds", []string{}},
+	}
+
+	for _, tt := range tests {
+		names := []string{}
+		for _, plugin := range rankPlugins(testCatalog, tt.query, tt.sourceType) {
+			names = append(names, plugin.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("%s: rankPlugins(%q, %q) = %v, want %v", tt.name, tt.query, tt.sourceType, names, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/prompt"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// TrashDir is the directory of a project that the files of removed plugins
+// are moved to. The project's .godspeed is its config file, so the trash is
+// a sibling of it. It is added to .gitignore.
+const TrashDir = ".godspeed-trash"
+
+// trashTimeFormat names the trash entry of each removal. Nanoseconds keep
+// removals in the same second apart and entries sorted by time.
+const trashTimeFormat = "20060102-150405.000000000"
+
+// trash moves the files of removed plugins out of the project into TrashDir
+type trash struct {
+	dir string
+}
+
+// RestoreResult is the JSON result of plugin restore. Skipped files were
+// left in the trash because they exist in the project.
+type RestoreResult struct {
+	Entry    string   `json:"entry"`
+	Restored []string `json:"restored"`
+	Skipped  []string `json:"skipped"`
+}
+
+// newTrash returns a new trash entry for the current removal, adding
+// TrashDir to .gitignore
+func newTrash() (*trash, error) {
+	if err := ignoreTrash(); err != nil {
+		return nil, fmt.Errorf("adding %s to .gitignore: %w", TrashDir, err)
+	}
+
+	entry := time.Now().Format(trashTimeFormat)
+	dir := filepath.Join(TrashDir, entry)
+	for i := 2; utils.DirExists(dir); i++ {
+		dir = filepath.Join(TrashDir, fmt.Sprintf("%s-%d", entry, i))
+	}
+	return &trash{dir: dir}, nil
+}
+
+// ignoreTrash adds TrashDir to the project's .gitignore unless it is listed
+func ignoreTrash() error {
+	data, err := ioutil.ReadFile(".gitignore")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.Trim(strings.TrimSpace(line), "/") == TrashDir {
+			return nil
+		}
+	}
+
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, TrashDir+"/\n"...)
+	return ioutil.WriteFile(".gitignore", data, 0644)
+}
+
+// move moves a project file into the trash, keeping its relative path
+func (t *trash) move(path string) error {
+	if !utils.FileExists(path) {
+		return nil
+	}
+
+	dest := filepath.Join(t.dir, path)
+	if err := moveFile(path, dest); err != nil {
+		return err
+	}
+	fmt.Printf("Moved %s to the trash\n", path)
+	return nil
+}
+
+// Restore moves the files of a trash entry back into the project. Without an
+// entry, the only one is restored or the user picks one.
+func Restore(entry string) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	dir := TrashDir
+	entries, err := trashEntries(dir)
+	if err != nil {
+		return fmt.Errorf("reading trash: %w", err)
+	}
+
+	result := RestoreResult{Restored: []string{}, Skipped: []string{}}
+	defer output.Set(&result)
+	if len(entries) == 0 {
+		color.Yellow("The trash of this project is empty.")
+		return nil
+	}
+
+	if entry == "" {
+		if len(entries) == 1 {
+			entry = entries[0]
+		} else if err := prompt.Ask(&survey.Select{
+			Message: "Please select the removal to restore:",
+			Options: entries,
+		}, &entry, "<entry>"); err != nil {
+			return err
+		}
+	}
+
+	entryDir := filepath.Join(dir, entry)
+	if !utils.DirExists(entryDir) {
+		return fmt.Errorf("trash entry %s not found. Available: %v", entry, entries)
+	}
+	result.Entry = entry
+
+	err = filepath.Walk(entryDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(entryDir, path)
+		if err != nil {
+			return err
+		}
+		if utils.FileExists(rel) {
+			color.Yellow("Skipping %s: file already exists.", rel)
+			result.Skipped = append(result.Skipped, rel)
+			return nil
+		}
+		if err := moveFile(path, rel); err != nil {
+			return err
+		}
+		fmt.Printf("Restored %s\n", rel)
+		result.Restored = append(result.Restored, rel)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("restoring files: %w", err)
+	}
+
+	if len(result.Skipped) == 0 {
+		os.RemoveAll(entryDir)
+	}
+	color.Green("Restored %d files from %s.", len(result.Restored), entry)
+	return nil
+}
+
+// trashEntries returns the removals in the trash, newest first
+func trashEntries(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []string
+	for _, info := range infos {
+		if info.IsDir() {
+			entries = append(entries, info.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(entries)))
+	return entries, nil
+}
+
+// moveFile moves a file, copying it when a rename is not possible, e.g.
+// across devices
+func moveFile(src, dest string) error {
+	if err := utils.CreateDir(filepath.Dir(dest)); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+	if err := utils.CopyFile(src, dest); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -6,41 +6,67 @@ import (
 	"path/filepath"
 
 	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
 	"github.com/godspeedsystems/godspeed-cli/internal/utils"
 )
 
+// SchemaResult is a Prisma schema in the JSON result of prisma prepare
+type SchemaResult struct {
+	Schema string `json:"schema"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Result is the JSON result of prisma prepare
+type Result struct {
+	Schemas []SchemaResult `json:"schemas"`
+}
+
 // Prepare prepares the Prisma database for use
-func Prepare() {
-	if !utils.IsGodspeedProject() {
-		return
+func Prepare() error {
+	if err := utils.RequireProject(); err != nil {
+		return err
 	}
 
 	// Find all Prisma files in the project
 	prismaFiles, err := findPrismaFiles()
 	if err != nil {
-		color.Red("Error finding Prisma files: %v", err)
-		return
+		return fmt.Errorf("finding Prisma files: %w", err)
 	}
 
+	result := Result{Schemas: []SchemaResult{}}
+	defer output.Set(&result)
+
 	if len(prismaFiles) == 0 {
 		color.Yellow("No Prisma schema files found.")
-		return
+		return nil
 	}
 
 	// Generate client and sync database for each Prisma file
+	failed := 0
 	for _, file := range prismaFiles {
 		if err := generatePrismaClient(file); err != nil {
 			color.Red("Error generating Prisma client for %s: %v", file, err)
+			result.Schemas = append(result.Schemas, SchemaResult{Schema: file, Error: err.Error()})
+			failed++
 			continue
 		}
 
 		if err := pushPrismaDb(file); err != nil {
 			color.Red("Error pushing Prisma database for %s: %v", file, err)
+			result.Schemas = append(result.Schemas, SchemaResult{Schema: file, Error: err.Error()})
+			failed++
 			continue
 		}
+		result.Schemas = append(result.Schemas, SchemaResult{Schema: file, OK: true})
+	}
+	if failed > 0 {
+		return exitcode.New(exitcode.ToolFailure, "preparing %d of %d Prisma schemas failed", failed, len(prismaFiles))
 	}
 
 	color.Green("Prisma database preparation completed successfully.")
+	return nil
 }
 
 // findPrismaFiles finds all Prisma schema files in the project
@@ -0,0 +1,84 @@
+package prompt
+
+import (
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/core"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/mattn/go-isatty"
+)
+
+// EnvCI turns on non-interactive mode when set to "true", as CI services do
+const EnvCI = "CI"
+
+var nonInteractive bool
+
+// useDefaults is set when non-interactive mode was asked for with --yes,
+// rather than detected from CI or stdin
+var useDefaults bool
+
+// Init decides whether prompts are shown. They aren't with yes (--yes or
+// --non-interactive), when CI is "true" or when stdin isn't a terminal.
+func Init(yes bool) {
+	useDefaults = yes
+	nonInteractive = yes || os.Getenv(EnvCI) == "true" || !stdinIsTerminal()
+}
+
+// Interactive reports whether prompts are shown
+func Interactive() bool {
+	return !nonInteractive
+}
+
+// Ask asks a survey prompt and writes the answer to response. flag names
+// the flag or argument that supplies the answer, or is "" if none does. In
+// non-interactive mode the prompt's default is the answer instead, and
+// prompts without one fail with an exitcode.Usage error naming flag. Unless
+// --yes was given, the defaults of prompts that have a flag aren't used
+// either, so that CI must pass the flag. A Confirm given a flag has no
+// default, so that what it guards, e.g. overwriting files, needs the flag.
+func Ask(p survey.Prompt, response interface{}, flag string, opts ...survey.AskOpt) error {
+	if !nonInteractive {
+		return survey.AskOne(p, response, opts...)
+	}
+
+	message, answer, ok := defaultAnswer(p, flag)
+	if ok && (flag == "" || useDefaults) {
+		return core.WriteAnswer(response, "", answer)
+	}
+
+	message = strings.TrimRight(strings.Join(strings.Fields(message), " "), ":")
+	if ok {
+		return exitcode.New(exitcode.Usage, "%s is required in non-interactive mode (%s). Pass --yes to use the default", flag, message)
+	}
+	return exitcode.New(exitcode.Usage, "%s is required in non-interactive mode (%s)", flag, message)
+}
+
+// defaultAnswer returns the message of a prompt and its default answer, if
+// it has one
+func defaultAnswer(p survey.Prompt, flag string) (message string, answer interface{}, ok bool) {
+	switch p := p.(type) {
+	case *survey.Input:
+		return p.Message, p.Default, p.Default != ""
+	case *survey.Confirm:
+		return p.Message, p.Default, flag == ""
+	case *survey.Select:
+		if value, isString := p.Default.(string); isString && value != "" {
+			return p.Message, value, true
+		}
+		return p.Message, nil, false
+	case *survey.MultiSelect:
+		if values, isSlice := p.Default.([]string); isSlice && len(values) > 0 {
+			return p.Message, values, true
+		}
+		return p.Message, nil, false
+	}
+	return "", nil, false
+}
+
+// stdinIsTerminal reports whether stdin is a terminal rather than a pipe or file
+func stdinIsTerminal() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
@@ -0,0 +1,194 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+)
+
+// nonInteractiveMode turns prompts off for the rest of the test, as --yes
+// does with yes and as CI detection does without it
+func nonInteractiveMode(t *testing.T, yes bool) {
+	t.Helper()
+	previous, previousDefaults := nonInteractive, useDefaults
+	t.Cleanup(func() { nonInteractive, useDefaults = previous, previousDefaults })
+	nonInteractive, useDefaults = true, yes
+}
+
+func TestAskNonInteractive(t *testing.T) {
+	nonInteractiveMode(t, true)
+
+	tests := []struct {
+		name     string
+		prompt   survey.Prompt
+		flag     string
+		response interface{}
+		want     interface{}
+		wantErr  string
+	}{
+		{
+			name:     "input with a default",
+			prompt:   &survey.Input{Message: "Summary:", Default: "Get users"},
+			flag:     "--summary",
+			response: new(string),
+			want:     "Get users",
+		},
+		{
+			name:     "input without a default",
+			prompt:   &survey.Input{Message: "Enter project name:"},
+			flag:     "<projectName>",
+			response: new(string),
+			wantErr:  "<projectName> is required in non-interactive mode (Enter project name)",
+		},
+		{
+			name:     "select with a default",
+			prompt:   &survey.Select{Message: "Language:", Options: []string{"ts", "yaml"}, Default: "ts"},
+			flag:     "--language",
+			response: new(string),
+			want:     "ts",
+		},
+		{
+			name:     "select without a default",
+			prompt:   &survey.Select{Message: "Select a plugin:", Options: []string{"a", "b"}},
+			flag:     "<pluginName>",
+			response: new(string),
+			wantErr:  "<pluginName> is required in non-interactive mode (Select a plugin)",
+		},
+		{
+			name:     "multi select with a default",
+			prompt:   &survey.MultiSelect{Message: "Plugins:", Options: []string{"a", "b", "c"}, Default: []string{"a", "c"}},
+			flag:     "--plugins",
+			response: new([]string),
+			want:     []string{"a", "c"},
+		},
+		{
+			name:     "multi select without a default",
+			prompt:   &survey.MultiSelect{Message: "Plugins:", Options: []string{"a", "b"}},
+			flag:     "--plugins",
+			response: new([]string),
+			wantErr:  "--plugins is required",
+		},
+		{
+			name:     "confirm without a flag",
+			prompt:   &survey.Confirm{Message: "Continue?", Default: true},
+			response: new(bool),
+			want:     true,
+		},
+		{
+			name:     "confirm guarded by a flag",
+			prompt:   &survey.Confirm{Message: "Overwrite existing files?", Default: true},
+			flag:     "--force",
+			response: new(bool),
+			wantErr:  "--force is required in non-interactive mode (Overwrite existing files?)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Ask(tt.prompt, tt.response, tt.flag)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Ask error = %v, want %q", err, tt.wantErr)
+				}
+				if code := exitcode.Of(err); code != exitcode.Usage {
+					t.Errorf("exit code = %d, want %d", code, exitcode.Usage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Ask: %v", err)
+			}
+			if got := reflect.ValueOf(tt.response).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("answer = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAskDetectedNonInteractive(t *testing.T) {
+	nonInteractiveMode(t, false)
+
+	tests := []struct {
+		name     string
+		prompt   survey.Prompt
+		flag     string
+		response interface{}
+		want     interface{}
+		wantErr  string
+	}{
+		{
+			name:     "select with a default and a flag",
+			prompt:   &survey.Select{Message: "Method:", Options: []string{"get", "post"}, Default: "get"},
+			flag:     "--method",
+			response: new(string),
+			wantErr:  "--method is required in non-interactive mode (Method). Pass --yes to use the default",
+		},
+		{
+			name:     "input with a default and a flag",
+			prompt:   &survey.Input{Message: "Function to call (created if it does not exist):", Default: "users.get"},
+			flag:     "--fn",
+			response: new(string),
+			wantErr:  "--fn is required in non-interactive mode",
+		},
+		{
+			name:     "input without a default",
+			prompt:   &survey.Input{Message: "Path:"},
+			flag:     "--path",
+			response: new(string),
+			wantErr:  "--path is required in non-interactive mode (Path)",
+		},
+		{
+			name:     "optional input",
+			prompt:   &survey.Input{Message: "Description:", Default: "none"},
+			response: new(string),
+			want:     "none",
+		},
+		{
+			name:     "confirm without a flag",
+			prompt:   &survey.Confirm{Message: "Continue?", Default: true},
+			response: new(bool),
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Ask(tt.prompt, tt.response, tt.flag)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Ask error = %v, want %q", err, tt.wantErr)
+				}
+				if code := exitcode.Of(err); code != exitcode.Usage {
+					t.Errorf("exit code = %d, want %d", code, exitcode.Usage)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Ask: %v", err)
+			}
+			if got := reflect.ValueOf(tt.response).Elem().Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("answer = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	previous, previousDefaults := nonInteractive, useDefaults
+	t.Cleanup(func() { nonInteractive, useDefaults = previous, previousDefaults })
+
+	t.Setenv(EnvCI, "")
+	Init(true)
+	if Interactive() || !useDefaults {
+		t.Errorf("Interactive() = %v, defaults used = %v with --yes, want false, true", Interactive(), useDefaults)
+	}
+
+	t.Setenv(EnvCI, "true")
+	Init(false)
+	if Interactive() || useDefaults {
+		t.Errorf("Interactive() = %v, defaults used = %v with CI=true, want false, false", Interactive(), useDefaults)
+	}
+}
@@ -0,0 +1,153 @@
+package schema
+
+import "strings"
+
+// Example synthesizes an example value for schema. Explicit example, default,
+// enum and const values are preferred, otherwise a placeholder is derived from
+// the type and format. References are followed lazily, so recursive
+// definitions simply stop at the first repetition.
+func (d *Definitions) Example(schema interface{}) interface{} {
+	return d.example(Normalize(schema), "", nil)
+}
+
+// example synthesizes an example for node, read from file, with stack holding
+// the references being expanded
+func (d *Definitions) example(node interface{}, file string, stack []string) interface{} {
+	s, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		name, target, targetFile, err := d.lookup(ref, file)
+		if err != nil {
+			return nil
+		}
+		key := name
+		if key == "" {
+			key = targetFile + ref
+		}
+		for _, seen := range stack {
+			if seen == key {
+				return nil
+			}
+		}
+		return d.example(target, targetFile, append(stack, key))
+	}
+
+	if value, ok := s["example"]; ok {
+		return value
+	}
+	if values, ok := s["examples"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+	if value, ok := s["default"]; ok {
+		return value
+	}
+	if value, ok := s["const"]; ok {
+		return value
+	}
+	if values, ok := s["enum"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if variants, ok := s[keyword].([]interface{}); ok && len(variants) > 0 {
+			return d.example(variants[0], file, stack)
+		}
+	}
+
+	if parts, ok := s["allOf"].([]interface{}); ok && len(parts) > 0 {
+		merged := make(map[string]interface{})
+		for _, part := range parts {
+			if partExample, ok := d.example(part, file, stack).(map[string]interface{}); ok {
+				for k, v := range partExample {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+
+	typ, _ := s["type"].(string)
+	if types, ok := s["type"].([]interface{}); ok {
+		for _, t := range types {
+			if str, _ := t.(string); str != "null" {
+				typ = str
+				break
+			}
+		}
+	}
+	if typ == "" {
+		if _, ok := s["properties"]; ok {
+			typ = "object"
+		} else if _, ok := s["items"]; ok {
+			typ = "array"
+		}
+	}
+
+	switch typ {
+	case "string":
+		return stringExample(s)
+	case "integer":
+		if minimum, ok := s["minimum"]; ok {
+			return minimum
+		}
+		return 0
+	case "number":
+		if minimum, ok := s["minimum"]; ok {
+			return minimum
+		}
+		return 0.0
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	case "array":
+		item := d.example(s["items"], file, stack)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "object":
+		result := make(map[string]interface{})
+		properties, _ := s["properties"].(map[string]interface{})
+		for name, property := range properties {
+			if value := d.example(property, file, stack); value != nil {
+				result[name] = value
+			}
+		}
+		return result
+	}
+
+	return nil
+}
+
+// stringExample returns a placeholder string matching the schema's format
+func stringExample(s map[string]interface{}) string {
+	format, _ := s["format"].(string)
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	}
+
+	example := "string"
+	if minLength, ok := s["minLength"].(int); ok && minLength > len(example) {
+		example += strings.Repeat("x", minLength-len(example))
+	}
+	return example
+}
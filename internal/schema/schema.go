@@ -0,0 +1,440 @@
+package schema
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefinitionsRefPrefix is the prefix used by events and definitions to refer
+// to a shared definition, e.g. "#/definitions/User"
+const DefinitionsRefPrefix = "#/definitions/"
+
+// ComponentsRefPrefix is the prefix of OpenAPI component schema references
+const ComponentsRefPrefix = "#/components/schemas/"
+
+// Definitions holds the JSON-schema definitions loaded from src/definitions.
+// Every top-level key of a definitions file is a definition. Definitions in
+// nested directories are namespaced by their directory, so the key Invoice in
+// src/definitions/billing/invoice.yaml is referred to as
+// "#/definitions/billing/Invoice".
+type Definitions struct {
+	dir     string
+	schemas map[string]interface{}
+	origins map[string]string
+	files   map[string]interface{}
+}
+
+// LoadDefinitions loads the definitions under dirPath, including nested
+// directories. A missing directory yields an empty set of definitions.
+func LoadDefinitions(dirPath string) (*Definitions, error) {
+	defs := &Definitions{
+		dir:     filepath.Clean(dirPath),
+		schemas: make(map[string]interface{}),
+		origins: make(map[string]string),
+		files:   make(map[string]interface{}),
+	}
+
+	if info, err := os.Stat(dirPath); err != nil || !info.IsDir() {
+		return defs, nil
+	}
+
+	err := filepath.Walk(defs.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !isSchemaFile(path) {
+			return nil
+		}
+
+		doc, err := readDocument(path)
+		if err != nil {
+			return err
+		}
+		defs.files[path] = doc
+
+		docMap, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		relDir, err := filepath.Rel(defs.dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+
+		for key, value := range docMap {
+			name := key
+			if relDir != "." {
+				name = filepath.ToSlash(filepath.Join(relDir, key))
+			}
+
+			if origin, ok := defs.origins[name]; ok {
+				return fmt.Errorf("definition %s is declared in both %s and %s", name, origin, path)
+			}
+
+			defs.schemas[name] = value
+			defs.origins[name] = path
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// Names returns the names of all definitions in a stable order
+func (d *Definitions) Names() []string {
+	names := make([]string, 0, len(d.schemas))
+	for name := range d.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Schema returns the raw, unresolved schema of a definition
+func (d *Definitions) Schema(name string) (interface{}, bool) {
+	schema, ok := d.schemas[name]
+	return schema, ok
+}
+
+// Resolve returns a copy of schema with every $ref inlined. References in
+// schema are resolved relative to the definitions directory. Circular
+// references cannot be inlined and are reported as an error.
+func (d *Definitions) Resolve(schema interface{}) (interface{}, error) {
+	r := &resolver{defs: d, inline: true}
+	return r.walk(Normalize(schema), "", nil)
+}
+
+// ResolveDefinition returns a definition with every $ref inlined
+func (d *Definitions) ResolveDefinition(name string) (interface{}, error) {
+	schema, ok := d.schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("definition %s not found", name)
+	}
+	r := &resolver{defs: d, inline: true}
+	return r.walk(schema, d.origins[name], []string{name})
+}
+
+// Rewrite returns a copy of schema in which references to definitions point
+// at prefix+ComponentName(name) instead, e.g. "#/components/schemas/User".
+// References to anything other than a whole definition are inlined.
+func (d *Definitions) Rewrite(schema interface{}, prefix string) (interface{}, error) {
+	r := &resolver{defs: d, prefix: prefix}
+	return r.walk(Normalize(schema), "", nil)
+}
+
+// RewriteDefinition returns a definition with its references rewritten like
+// Rewrite does
+func (d *Definitions) RewriteDefinition(name, prefix string) (interface{}, error) {
+	schema, ok := d.schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("definition %s not found", name)
+	}
+	r := &resolver{defs: d, prefix: prefix}
+	return r.walk(schema, d.origins[name], []string{name})
+}
+
+// Components returns all definitions keyed by ComponentName, with their
+// references rewritten like Rewrite does
+func (d *Definitions) Components(prefix string) (map[string]interface{}, error) {
+	components := make(map[string]interface{})
+	for _, name := range d.Names() {
+		schema, err := d.RewriteDefinition(name, prefix)
+		if err != nil {
+			return nil, err
+		}
+		components[ComponentName(name)] = schema
+	}
+	return components, nil
+}
+
+// ComponentName converts a definition name into a name that is valid as an
+// OpenAPI component key ("billing/Invoice" becomes "billing.Invoice")
+func ComponentName(name string) string {
+	return strings.ReplaceAll(name, "/", ".")
+}
+
+// resolver walks a schema and resolves the $refs found in it
+type resolver struct {
+	defs   *Definitions
+	inline bool
+	prefix string
+}
+
+// walk copies node, resolving $refs. file is the definitions file node was
+// read from, if any, and stack holds the references currently being resolved.
+func (r *resolver) walk(node interface{}, file string, stack []string) (interface{}, error) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			return r.resolveRef(ref, value, file, stack)
+		}
+
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			resolved, err := r.walk(v, file, stack)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = resolved
+		}
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			resolved, err := r.walk(v, file, stack)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+
+	default:
+		return node, nil
+	}
+}
+
+// resolveRef resolves a single $ref found in node
+func (r *resolver) resolveRef(ref string, node map[string]interface{}, file string, stack []string) (interface{}, error) {
+	name, target, targetFile, err := r.defs.lookup(ref, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.inline && name != "" {
+		result := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			result[k] = v
+		}
+		result["$ref"] = r.prefix + ComponentName(name)
+		return result, nil
+	}
+
+	key := name
+	if key == "" {
+		key = targetFile + "#"
+		if i := strings.Index(ref, "#"); i >= 0 {
+			key += ref[i+1:]
+		}
+	}
+
+	for i, seen := range stack {
+		if seen == key {
+			cycle := append(append([]string{}, stack[i:]...), key)
+			return nil, fmt.Errorf("circular $ref: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	resolved, err := r.walk(target, targetFile, append(stack, key))
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep sibling keywords such as description next to the resolved schema
+	if resolvedMap, ok := resolved.(map[string]interface{}); ok && len(node) > 1 {
+		merged := make(map[string]interface{}, len(resolvedMap)+len(node))
+		for k, v := range resolvedMap {
+			merged[k] = v
+		}
+		for k, v := range node {
+			if k != "$ref" {
+				merged[k] = v
+			}
+		}
+		return merged, nil
+	}
+
+	return resolved, nil
+}
+
+// lookup finds the target of ref. name is set when the target is a whole
+// definition. Local refs ("#/...") other than "#/definitions/..." point into
+// the current file, and file refs ("common.yaml#/Address") are relative to the
+// current file or, outside of a definitions file, to the definitions directory.
+func (d *Definitions) lookup(ref, file string) (name string, target interface{}, targetFile string, err error) {
+	if strings.HasPrefix(ref, DefinitionsRefPrefix) {
+		return d.lookupDefinition(strings.TrimPrefix(ref, DefinitionsRefPrefix), ref)
+	}
+
+	filePart, pointer := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		filePart, pointer = ref[:i], ref[i+1:]
+	}
+
+	targetFile = file
+	if filePart != "" {
+		baseDir := d.dir
+		if file != "" {
+			baseDir = filepath.Dir(file)
+		}
+		targetFile = filepath.Join(baseDir, filepath.FromSlash(filePart))
+	}
+
+	if targetFile == "" {
+		return "", nil, "", fmt.Errorf("cannot resolve $ref %s", ref)
+	}
+
+	doc, ok := d.files[targetFile]
+	if !ok {
+		return "", nil, "", fmt.Errorf("cannot resolve $ref %s: %s is not a definitions file", ref, targetFile)
+	}
+
+	segments := splitPointer(pointer)
+	target, err = walkPointer(doc, segments)
+	if err != nil {
+		return "", nil, "", fmt.Errorf("cannot resolve $ref %s: %v", ref, err)
+	}
+
+	// A pointer to a top-level key of a definitions file is a definition
+	if len(segments) == 1 {
+		relDir, relErr := filepath.Rel(d.dir, filepath.Dir(targetFile))
+		if relErr == nil && !strings.HasPrefix(relDir, "..") {
+			candidate := segments[0]
+			if relDir != "." {
+				candidate = filepath.ToSlash(filepath.Join(relDir, candidate))
+			}
+			if d.origins[candidate] == targetFile {
+				name = candidate
+			}
+		}
+	}
+
+	return name, target, targetFile, nil
+}
+
+// lookupDefinition resolves "#/definitions/<name>[/<pointer>]"
+func (d *Definitions) lookupDefinition(path, ref string) (string, interface{}, string, error) {
+	if schema, ok := d.schemas[path]; ok {
+		return path, schema, d.origins[path], nil
+	}
+
+	// The ref may point inside a definition, e.g. #/definitions/User/properties/id
+	segments := strings.Split(path, "/")
+	for i := len(segments) - 1; i > 0; i-- {
+		name := strings.Join(segments[:i], "/")
+		schema, ok := d.schemas[name]
+		if !ok {
+			continue
+		}
+		target, err := walkPointer(schema, unescapeSegments(segments[i:]))
+		if err != nil {
+			return "", nil, "", fmt.Errorf("cannot resolve $ref %s: %v", ref, err)
+		}
+		return "", target, d.origins[name], nil
+	}
+
+	return "", nil, "", fmt.Errorf("cannot resolve $ref %s: definition not found", ref)
+}
+
+// splitPointer splits a JSON pointer into its unescaped segments
+func splitPointer(pointer string) []string {
+	pointer = strings.TrimPrefix(pointer, "/")
+	if pointer == "" {
+		return nil
+	}
+	return unescapeSegments(strings.Split(pointer, "/"))
+}
+
+// unescapeSegments applies JSON pointer unescaping to each segment
+func unescapeSegments(segments []string) []string {
+	result := make([]string, len(segments))
+	for i, segment := range segments {
+		segment = strings.ReplaceAll(segment, "~1", "/")
+		result[i] = strings.ReplaceAll(segment, "~0", "~")
+	}
+	return result
+}
+
+// walkPointer follows the pointer segments through node
+func walkPointer(node interface{}, segments []string) (interface{}, error) {
+	current := node
+	for _, segment := range segments {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			next, ok := value[segment]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found", segment)
+			}
+			current = next
+		case []interface{}:
+			var index int
+			if _, err := fmt.Sscanf(segment, "%d", &index); err != nil || index < 0 || index >= len(value) {
+				return nil, fmt.Errorf("invalid index %s", segment)
+			}
+			current = value[index]
+		default:
+			return nil, fmt.Errorf("cannot descend into %s", segment)
+		}
+	}
+	return current, nil
+}
+
+// Normalize converts the map[interface{}]interface{} values produced by yaml
+// for non-string keys (e.g. response codes) into map[string]interface{}
+func Normalize(node interface{}) interface{} {
+	switch value := node.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[fmt.Sprintf("%v", k)] = Normalize(v)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = Normalize(v)
+		}
+		return result
+	case []map[string]interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = Normalize(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = Normalize(v)
+		}
+		return result
+	default:
+		return node
+	}
+}
+
+// readDocument reads a yaml or json schema file
+func readDocument(path string) (interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return Normalize(doc), nil
+}
+
+// isSchemaFile checks whether the path is a yaml or json file
+func isSchemaFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
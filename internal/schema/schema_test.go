@@ -0,0 +1,294 @@
+package schema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeDefinitions writes files into a temporary definitions directory and
+// loads it
+func writeDefinitions(t *testing.T, files map[string]string) *Definitions {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defs, err := LoadDefinitions(dir)
+	if err != nil {
+		t.Fatalf("LoadDefinitions: %v", err)
+	}
+	return defs
+}
+
+var testDefinitions = map[string]string{
+	"user.yaml": `
+User:
+  type: object
+  properties:
+    id: {type: string}
+    address: {$ref: 'common.yaml#/Address'}
+Admin:
+  $ref: '#/definitions/User'
+  description: an admin
+`,
+	"common.yaml": `
+Address:
+  type: object
+  properties:
+    city: {type: string}
+`,
+	"billing/invoice.yaml": `
+Invoice:
+  type: object
+  properties:
+    owner: {$ref: '../user.yaml#/User'}
+    total: {type: number}
+`,
+	"cycle.yaml": `
+Node:
+  type: object
+  properties:
+    next: {$ref: '#/definitions/Node'}
+Ping:
+  type: object
+  properties:
+    pong: {$ref: '#/Pong'}
+Pong:
+  type: object
+  properties:
+    ping: {$ref: '#/Ping'}
+`,
+	"README.md": "not a schema",
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	defs := writeDefinitions(t, testDefinitions)
+
+	want := []string{"Address", "Admin", "Node", "Ping", "Pong", "User", "billing/Invoice"}
+	if got := defs.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadDefinitionsDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.yaml", "b.yaml"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("User: {type: object}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := LoadDefinitions(dir)
+	if err == nil || !strings.Contains(err.Error(), "definition User is declared in both") {
+		t.Errorf("LoadDefinitions error = %v, want a duplicate definition error", err)
+	}
+}
+
+func TestLoadDefinitionsMissingDir(t *testing.T) {
+	defs, err := LoadDefinitions(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("LoadDefinitions: %v", err)
+	}
+	if names := defs.Names(); len(names) != 0 {
+		t.Errorf("Names() = %v, want none", names)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	defs := writeDefinitions(t, testDefinitions)
+	address := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+	}
+
+	tests := []struct {
+		name    string
+		schema  interface{}
+		want    interface{}
+		wantErr string
+	}{
+		{
+			name:   "definition ref",
+			schema: map[string]interface{}{"$ref": "#/definitions/Address"},
+			want:   address,
+		},
+		{
+			name:   "file ref relative to the definitions directory",
+			schema: map[string]interface{}{"$ref": "common.yaml#/Address"},
+			want:   address,
+		},
+		{
+			name:   "ref into a definition",
+			schema: map[string]interface{}{"$ref": "#/definitions/Address/properties/city"},
+			want:   map[string]interface{}{"type": "string"},
+		},
+		{
+			name:   "nested refs and sibling keywords",
+			schema: map[string]interface{}{"$ref": "#/definitions/billing/Invoice/properties/owner", "description": "owner"},
+			want: map[string]interface{}{
+				"type":        "object",
+				"description": "owner",
+				"properties": map[string]interface{}{
+					"id":      map[string]interface{}{"type": "string"},
+					"address": address,
+				},
+			},
+		},
+		{
+			name:   "yaml maps with non-string keys",
+			schema: map[interface{}]interface{}{200: map[string]interface{}{"$ref": "#/definitions/Address"}},
+			want:   map[string]interface{}{"200": address},
+		},
+		{
+			name:    "missing definition",
+			schema:  map[string]interface{}{"$ref": "#/definitions/Missing"},
+			wantErr: "cannot resolve $ref #/definitions/Missing: definition not found",
+		},
+		{
+			name:    "missing key inside a definition",
+			schema:  map[string]interface{}{"$ref": "#/definitions/User/properties/email"},
+			wantErr: "key email not found",
+		},
+		{
+			name:    "missing file",
+			schema:  map[string]interface{}{"$ref": "nope.yaml#/User"},
+			wantErr: "is not a definitions file",
+		},
+		{
+			name:    "missing key in a file",
+			schema:  map[string]interface{}{"$ref": "common.yaml#/Phone"},
+			wantErr: "key Phone not found",
+		},
+		{
+			name:    "local ref outside a definitions file",
+			schema:  map[string]interface{}{"$ref": "#/Address"},
+			wantErr: "cannot resolve $ref #/Address",
+		},
+		{
+			name:    "self reference",
+			schema:  map[string]interface{}{"$ref": "#/definitions/Node"},
+			wantErr: "circular $ref: Node -> Node",
+		},
+		{
+			name:    "mutual references",
+			schema:  map[string]interface{}{"$ref": "#/definitions/Ping"},
+			wantErr: "circular $ref: Ping -> Pong -> Ping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := defs.Resolve(tt.schema)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDefinition(t *testing.T) {
+	defs := writeDefinitions(t, testDefinitions)
+
+	admin, err := defs.ResolveDefinition("Admin")
+	if err != nil {
+		t.Fatalf("ResolveDefinition(Admin): %v", err)
+	}
+	if got := admin.(map[string]interface{})["description"]; got != "an admin" {
+		t.Errorf("Admin description = %v, want the sibling keyword kept", got)
+	}
+
+	if _, err := defs.ResolveDefinition("Missing"); err == nil {
+		t.Error("ResolveDefinition(Missing) returned no error")
+	}
+	if _, err := defs.ResolveDefinition("Node"); err == nil || !strings.Contains(err.Error(), "circular $ref") {
+		t.Errorf("ResolveDefinition(Node) error = %v, want a circular $ref error", err)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	defs := writeDefinitions(t, testDefinitions)
+
+	tests := []struct {
+		name    string
+		schema  interface{}
+		want    interface{}
+		wantErr string
+	}{
+		{
+			name:   "whole definitions become component refs",
+			schema: map[string]interface{}{"$ref": "#/definitions/billing/Invoice"},
+			want:   map[string]interface{}{"$ref": ComponentsRefPrefix + "billing.Invoice"},
+		},
+		{
+			name:   "cyclic definitions are referenced, not inlined",
+			schema: map[string]interface{}{"$ref": "#/definitions/Node"},
+			want:   map[string]interface{}{"$ref": ComponentsRefPrefix + "Node"},
+		},
+		{
+			name:   "refs into a definition are inlined",
+			schema: map[string]interface{}{"$ref": "#/definitions/User/properties/id"},
+			want:   map[string]interface{}{"type": "string"},
+		},
+		{
+			name:    "missing refs are still an error",
+			schema:  map[string]interface{}{"$ref": "#/definitions/Missing"},
+			wantErr: "definition not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := defs.Rewrite(tt.schema, ComponentsRefPrefix)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Rewrite error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Rewrite: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Rewrite = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComponents(t *testing.T) {
+	defs := writeDefinitions(t, testDefinitions)
+
+	components, err := defs.Components(ComponentsRefPrefix)
+	if err != nil {
+		t.Fatalf("Components: %v", err)
+	}
+	invoice, ok := components["billing.Invoice"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Components has no billing.Invoice: %v", components)
+	}
+	owner := invoice["properties"].(map[string]interface{})["owner"]
+	want := map[string]interface{}{"$ref": ComponentsRefPrefix + "User"}
+	if !reflect.DeepEqual(owner, want) {
+		t.Errorf("billing.Invoice owner = %#v, want %#v", owner, want)
+	}
+}
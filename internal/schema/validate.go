@@ -0,0 +1,349 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Validate checks value against schema and returns a description of every
+// violation found, prefixed with the location of the offending value. An
+// empty result means the value is valid.
+func (d *Definitions) Validate(schema interface{}, value interface{}, location string) []string {
+	v := &validator{defs: d}
+	v.validate(Normalize(schema), value, location, "", nil)
+	return v.errors
+}
+
+// validator collects the violations found while validating a value
+type validator struct {
+	defs   *Definitions
+	errors []string
+}
+
+// fail records a violation at location
+func (v *validator) fail(location, format string, args ...interface{}) {
+	if location == "" {
+		location = "value"
+	}
+	v.errors = append(v.errors, location+": "+fmt.Sprintf(format, args...))
+}
+
+// validate checks value against node, read from file, with stack holding the
+// references being followed
+func (v *validator) validate(node interface{}, value interface{}, location, file string, stack []string) {
+	s, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		name, target, targetFile, err := v.defs.lookup(ref, file)
+		if err != nil {
+			v.fail(location, "%v", err)
+			return
+		}
+		key := name
+		if key == "" {
+			key = targetFile + ref
+		}
+		// A value is finite, so a cycle only repeats at the same location
+		frame := key + "@" + location
+		for _, seen := range stack {
+			if seen == frame {
+				return
+			}
+		}
+		v.validate(target, value, location, targetFile, append(stack, frame))
+		return
+	}
+
+	if value == nil {
+		if nullable, _ := s["nullable"].(bool); nullable {
+			return
+		}
+	}
+
+	if !v.checkType(s, value, location) {
+		return
+	}
+
+	if values, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range values {
+			if equal(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(location, "must be one of %v", values)
+		}
+	}
+
+	if expected, ok := s["const"]; ok && !equal(expected, value) {
+		v.fail(location, "must be %v", expected)
+	}
+
+	v.checkCombinators(s, value, location, file, stack)
+
+	switch typed := value.(type) {
+	case string:
+		v.checkString(s, typed, location)
+	case float64, int:
+		v.checkNumber(s, toFloat(typed), location)
+	case []interface{}:
+		v.checkArray(s, typed, location, file, stack)
+	case map[string]interface{}:
+		v.checkObject(s, typed, location, file, stack)
+	}
+}
+
+// checkType verifies the type keyword and reports whether validation of the
+// value should continue
+func (v *validator) checkType(s map[string]interface{}, value interface{}, location string) bool {
+	var types []string
+	switch typ := s["type"].(type) {
+	case string:
+		types = []string{typ}
+	case []interface{}:
+		for _, t := range typ {
+			if str, ok := t.(string); ok {
+				types = append(types, str)
+			}
+		}
+	}
+
+	if len(types) == 0 {
+		return true
+	}
+
+	for _, typ := range types {
+		if hasType(value, typ) {
+			return true
+		}
+	}
+
+	v.fail(location, "must be of type %s", strings.Join(types, " or "))
+	return false
+}
+
+// checkCombinators verifies allOf, anyOf, oneOf and not
+func (v *validator) checkCombinators(s map[string]interface{}, value interface{}, location, file string, stack []string) {
+	if parts, ok := s["allOf"].([]interface{}); ok {
+		for _, part := range parts {
+			v.validate(part, value, location, file, stack)
+		}
+	}
+
+	if variants, ok := s["anyOf"].([]interface{}); ok && v.countMatches(variants, value, location, file, stack) == 0 {
+		v.fail(location, "must match at least one of the allowed schemas")
+	}
+
+	if variants, ok := s["oneOf"].([]interface{}); ok && v.countMatches(variants, value, location, file, stack) != 1 {
+		v.fail(location, "must match exactly one of the allowed schemas")
+	}
+
+	if not, ok := s["not"]; ok && v.countMatches([]interface{}{not}, value, location, file, stack) == 1 {
+		v.fail(location, "must not match the disallowed schema")
+	}
+}
+
+// countMatches counts the variants value is valid against
+func (v *validator) countMatches(variants []interface{}, value interface{}, location, file string, stack []string) int {
+	matches := 0
+	for _, variant := range variants {
+		sub := &validator{defs: v.defs}
+		sub.validate(variant, value, location, file, stack)
+		if len(sub.errors) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// checkString verifies the string keywords
+func (v *validator) checkString(s map[string]interface{}, value, location string) {
+	length := len([]rune(value))
+	if minLength, ok := toInt(s["minLength"]); ok && length < minLength {
+		v.fail(location, "must be at least %d characters long", minLength)
+	}
+	if maxLength, ok := toInt(s["maxLength"]); ok && length > maxLength {
+		v.fail(location, "must be at most %d characters long", maxLength)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err == nil && !re.MatchString(value) {
+			v.fail(location, "must match the pattern %s", pattern)
+		}
+	}
+}
+
+// checkNumber verifies the numeric keywords
+func (v *validator) checkNumber(s map[string]interface{}, value float64, location string) {
+	if minimum, ok := s["minimum"]; ok {
+		if exclusive, _ := s["exclusiveMinimum"].(bool); exclusive && value <= toFloat(minimum) {
+			v.fail(location, "must be greater than %v", minimum)
+		} else if value < toFloat(minimum) {
+			v.fail(location, "must be at least %v", minimum)
+		}
+	}
+	if maximum, ok := s["maximum"]; ok {
+		if exclusive, _ := s["exclusiveMaximum"].(bool); exclusive && value >= toFloat(maximum) {
+			v.fail(location, "must be less than %v", maximum)
+		} else if value > toFloat(maximum) {
+			v.fail(location, "must be at most %v", maximum)
+		}
+	}
+	if multipleOf, ok := s["multipleOf"]; ok && toFloat(multipleOf) != 0 {
+		if quotient := value / toFloat(multipleOf); quotient != math.Trunc(quotient) {
+			v.fail(location, "must be a multiple of %v", multipleOf)
+		}
+	}
+}
+
+// checkArray verifies the array keywords and validates the items
+func (v *validator) checkArray(s map[string]interface{}, value []interface{}, location, file string, stack []string) {
+	if minItems, ok := toInt(s["minItems"]); ok && len(value) < minItems {
+		v.fail(location, "must have at least %d items", minItems)
+	}
+	if maxItems, ok := toInt(s["maxItems"]); ok && len(value) > maxItems {
+		v.fail(location, "must have at most %d items", maxItems)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if equal(value[i], value[j]) {
+					v.fail(location, "must not contain duplicate items")
+					i = len(value)
+					break
+				}
+			}
+		}
+	}
+	if items, ok := s["items"]; ok {
+		for i, item := range value {
+			v.validate(items, item, fmt.Sprintf("%s[%d]", location, i), file, stack)
+		}
+	}
+}
+
+// checkObject verifies the object keywords and validates the properties
+func (v *validator) checkObject(s map[string]interface{}, value map[string]interface{}, location, file string, stack []string) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if str, ok := name.(string); ok {
+				if _, present := value[str]; !present {
+					v.fail(join(location, str), "is required")
+				}
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if property, ok := properties[key]; ok {
+			v.validate(property, value[key], join(location, key), file, stack)
+			continue
+		}
+
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(join(location, key), "is not an allowed property")
+			}
+		case map[string]interface{}:
+			v.validate(additional, value[key], join(location, key), file, stack)
+		}
+	}
+}
+
+// hasType reports whether value is of the JSON-schema type typ
+func hasType(value interface{}, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		switch value.(type) {
+		case float64, int:
+			return true
+		}
+	case "integer":
+		switch n := value.(type) {
+		case int:
+			return true
+		case float64:
+			return n == math.Trunc(n)
+		}
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// equal compares two decoded values, treating numbers of any type alike
+func equal(a, b interface{}) bool {
+	if isNumber(a) && isNumber(b) {
+		return toFloat(a) == toFloat(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// isNumber reports whether value is a decoded number
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int, float64:
+		return true
+	}
+	return false
+}
+
+// toFloat converts a decoded number to float64
+func toFloat(value interface{}) float64 {
+	switch n := value.(type) {
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// toInt converts a decoded number to int
+func toInt(value interface{}) (int, bool) {
+	switch n := value.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// join appends a property name to a location
+func join(location, key string) string {
+	if location == "" {
+		return key
+	}
+	return location + "." + key
+}
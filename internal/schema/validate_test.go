@@ -0,0 +1,200 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidate(t *testing.T) {
+	defs := writeDefinitions(t, map[string]string{
+		"user.yaml": `
+User:
+  type: object
+  required: [id]
+  properties:
+    id: {type: string}
+    address: {$ref: 'common.yaml#/Address'}
+`,
+		"common.yaml": `
+Address:
+  type: object
+  required: [city]
+  properties:
+    city: {type: string, minLength: 2}
+`,
+		"tree.yaml": `
+Tree:
+  type: object
+  properties:
+    value: {type: integer}
+    children:
+      type: array
+      items: {$ref: '#/definitions/Tree'}
+`,
+	})
+
+	tests := []struct {
+		name     string
+		schema   string
+		value    string
+		location string
+		want     []string
+	}{
+		{
+			name:   "valid object",
+			schema: "{$ref: '#/definitions/User'}",
+			value:  `{"id": "u1", "address": {"city": "Pune"}}`,
+			want:   nil,
+		},
+		{
+			name:     "type mismatch",
+			schema:   "{type: string}",
+			value:    `42`,
+			location: "body",
+			want:     []string{"body: must be of type string"},
+		},
+		{
+			name:   "type mismatch without a location",
+			schema: "{type: [string, 'null']}",
+			value:  `true`,
+			want:   []string{"value: must be of type string or null"},
+		},
+		{
+			name:   "nullable",
+			schema: "{type: string, nullable: true}",
+			value:  `null`,
+			want:   nil,
+		},
+		{
+			name:   "integer",
+			schema: "{type: integer}",
+			value:  `1.5`,
+			want:   []string{"value: must be of type integer"},
+		},
+		{
+			name:     "required and nested refs",
+			schema:   "{$ref: '#/definitions/User'}",
+			value:    `{"address": {"city": "X"}}`,
+			location: "body",
+			want: []string{
+				"body.id: is required",
+				"body.address.city: must be at least 2 characters long",
+			},
+		},
+		{
+			name:     "recursive definitions",
+			schema:   "{$ref: '#/definitions/Tree'}",
+			value:    `{"value": 1, "children": [{"value": 2}, {"value": "three", "children": []}]}`,
+			location: "body",
+			want:     []string{"body.children[1].value: must be of type integer"},
+		},
+		{
+			name:     "missing ref",
+			schema:   "{$ref: '#/definitions/Missing'}",
+			value:    `{}`,
+			location: "body",
+			want:     []string{"body: cannot resolve $ref #/definitions/Missing: definition not found"},
+		},
+		{
+			name:   "string keywords",
+			schema: "{type: string, maxLength: 3, pattern: '^[a-z]+$'}",
+			value:  `"ABCD"`,
+			want: []string{
+				"value: must be at most 3 characters long",
+				"value: must match the pattern ^[a-z]+$",
+			},
+		},
+		{
+			name:   "string length counts runes",
+			schema: "{type: string, maxLength: 3}",
+			value:  `"äöü"`,
+			want:   nil,
+		},
+		{
+			name:   "enum and const",
+			schema: "{enum: [a, b], const: a}",
+			value:  `"c"`,
+			want:   []string{"value: must be one of [a b]", "value: must be a"},
+		},
+		{
+			name:   "numeric enum",
+			schema: "{enum: [1, 2]}",
+			value:  `2`,
+			want:   nil,
+		},
+		{
+			name:   "minimum and maximum",
+			schema: "{type: number, minimum: 1, maximum: 10, multipleOf: 2}",
+			value:  `11`,
+			want:   []string{"value: must be at most 10", "value: must be a multiple of 2"},
+		},
+		{
+			name:   "exclusive bounds",
+			schema: "{type: number, minimum: 1, exclusiveMinimum: true, maximum: 10, exclusiveMaximum: true}",
+			value:  `1`,
+			want:   []string{"value: must be greater than 1"},
+		},
+		{
+			name:     "array keywords",
+			schema:   "{type: array, minItems: 4, uniqueItems: true, items: {type: number}}",
+			value:    `[1, 1, "x"]`,
+			location: "query.ids",
+			want: []string{
+				"query.ids: must have at least 4 items",
+				"query.ids: must not contain duplicate items",
+				"query.ids[2]: must be of type number",
+			},
+		},
+		{
+			name:   "additional properties",
+			schema: "{type: object, properties: {a: {type: string}}, additionalProperties: false}",
+			value:  `{"a": "x", "b": 1}`,
+			want:   []string{"b: is not an allowed property"},
+		},
+		{
+			name:   "additional properties schema",
+			schema: "{type: object, additionalProperties: {type: integer}}",
+			value:  `{"a": 1, "b": "2"}`,
+			want:   []string{"b: must be of type integer"},
+		},
+		{
+			name:   "anyOf",
+			schema: "{anyOf: [{type: string}, {type: integer}]}",
+			value:  `true`,
+			want:   []string{"value: must match at least one of the allowed schemas"},
+		},
+		{
+			name:   "oneOf",
+			schema: "{oneOf: [{type: number}, {type: integer}]}",
+			value:  `3`,
+			want:   []string{"value: must match exactly one of the allowed schemas"},
+		},
+		{
+			name:   "allOf and not",
+			schema: "{allOf: [{type: string}, {minLength: 3}], not: {const: abcd}}",
+			value:  `"abcd"`,
+			want:   []string{"value: must not match the disallowed schema"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s interface{}
+			if err := yaml.Unmarshal([]byte(tt.schema), &s); err != nil {
+				t.Fatalf("parsing schema: %v", err)
+			}
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("parsing value: %v", err)
+			}
+
+			got := defs.Validate(s, value, tt.location)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
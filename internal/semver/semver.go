@@ -0,0 +1,377 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version such as 1.2.3 or 2.0.0-beta.1
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// Parse parses a version, accepting a leading "v" or "=" and ignoring build metadata
+func Parse(s string) (Version, error) {
+	var v Version
+	s = strings.TrimLeft(strings.TrimSpace(s), "v=")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.Prerelease = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+	return v, nil
+}
+
+// String formats the version
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 when a is lower than, equal to or greater than b
+func Compare(a, b Version) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return comparePrerelease(a.Prerelease, b.Prerelease)
+}
+
+// comparePrerelease compares prerelease tags; a release ranks above its prereleases
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				if aNum < bNum {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(aParts) < len(bParts):
+		return -1
+	case len(aParts) > len(bParts):
+		return 1
+	}
+	return 0
+}
+
+// comparator is a single condition of a range, e.g. ">=1.2.0"
+type comparator struct {
+	op      string
+	version Version
+}
+
+// matches reports whether v satisfies the comparator
+func (c comparator) matches(v Version) bool {
+	cmp := Compare(v, c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// Satisfies reports whether version satisfies an npm style range such as
+// "^1.2.0", "~1.2", ">=1.0.0 <2.0.0", "1.x || 2.x" or "1.0.0 - 1.4.0".
+// Prereleases only match comparators of the same major.minor.patch.
+func Satisfies(version, constraint string) bool {
+	v, err := Parse(version)
+	if err != nil {
+		return false
+	}
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		comparators, err := parseRange(alternative)
+		if err != nil {
+			continue
+		}
+		if satisfiesAll(v, comparators) {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfiesAll reports whether v satisfies every comparator of a range
+func satisfiesAll(v Version, comparators []comparator) bool {
+	for _, c := range comparators {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if v.Prerelease == "" {
+		return true
+	}
+	for _, c := range comparators {
+		cv := c.version
+		if cv.Prerelease != "" && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRange reports whether constraint can be parsed as a range
+func ValidRange(constraint string) bool {
+	for _, alternative := range strings.Split(constraint, "||") {
+		if _, err := parseRange(alternative); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// IsExact reports whether constraint pins a single version, e.g. "1.2.0"
+func IsExact(constraint string) bool {
+	_, err := Parse(constraint)
+	return err == nil
+}
+
+// MaxSatisfying returns the highest of versions that satisfies constraint, or ""
+func MaxSatisfying(versions []string, constraint string) string {
+	var best string
+	var bestVersion Version
+	for _, version := range versions {
+		if !Satisfies(version, constraint) {
+			continue
+		}
+		v, _ := Parse(version)
+		if best == "" || Compare(v, bestVersion) > 0 {
+			best, bestVersion = version, v
+		}
+	}
+	return best
+}
+
+// Latest returns the highest release of versions, ignoring prereleases, or ""
+func Latest(versions []string) string {
+	var best string
+	var bestVersion Version
+	for _, version := range versions {
+		v, err := Parse(version)
+		if err != nil || v.Prerelease != "" {
+			continue
+		}
+		if best == "" || Compare(v, bestVersion) > 0 {
+			best, bestVersion = version, v
+		}
+	}
+	return best
+}
+
+// parseRange parses the space separated comparators of a range without "||"
+func parseRange(s string) ([]comparator, error) {
+	fields := strings.Fields(s)
+
+	// Hyphen ranges: "1.2.3 - 2.3.4"
+	if len(fields) == 3 && fields[1] == "-" {
+		low, _, err := partial(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		high, n, err := partial(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		upper := comparator{op: "<=", version: high}
+		if n < 3 {
+			upper = comparator{op: "<", version: bump(high, n)}
+		}
+		return []comparator{{op: ">=", version: low}, upper}, nil
+	}
+
+	if len(fields) == 0 {
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	}
+
+	var comparators []comparator
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		// Allow a space between an operator and its version, e.g. ">= 1.2.0"
+		if strings.Trim(field, "<>=~^") == "" && i+1 < len(fields) {
+			field += fields[i+1]
+			i++
+		}
+		parsed, err := parseComparator(field)
+		if err != nil {
+			return nil, err
+		}
+		comparators = append(comparators, parsed...)
+	}
+	return comparators, nil
+}
+
+// parseComparator expands a single comparator, including the ^ and ~ shorthands
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op = prefix
+			s = s[len(prefix):]
+			break
+		}
+	}
+
+	v, n, err := partial(s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "^":
+		var upper Version
+		switch {
+		case v.Major > 0 || n == 1:
+			upper = Version{Major: v.Major + 1}
+		case v.Minor > 0 || n == 2:
+			upper = Version{Minor: v.Minor + 1}
+		default:
+			upper = Version{Patch: v.Patch + 1}
+		}
+		return []comparator{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	case "~":
+		if n == 1 {
+			return []comparator{{op: ">=", version: v}, {op: "<", version: Version{Major: v.Major + 1}}}, nil
+		}
+		return []comparator{{op: ">=", version: v}, {op: "<", version: Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+	case ">", "<=":
+		if n == 0 {
+			if op == ">" {
+				return []comparator{{op: "<", version: Version{}}}, nil
+			}
+			return []comparator{{op: ">=", version: Version{}}}, nil
+		}
+		if n < 3 {
+			// ">1.2" means ">=1.3.0", "<=1.2" means "<1.3.0"
+			if op == ">" {
+				return []comparator{{op: ">=", version: bump(v, n)}}, nil
+			}
+			return []comparator{{op: "<", version: bump(v, n)}}, nil
+		}
+		return []comparator{{op: op, version: v}}, nil
+	case ">=", "<":
+		return []comparator{{op: op, version: v}}, nil
+	default:
+		if n == 0 {
+			return []comparator{{op: ">=", version: Version{}}}, nil
+		}
+		if n < 3 {
+			return []comparator{{op: ">=", version: v}, {op: "<", version: bump(v, n)}}, nil
+		}
+		return []comparator{{op: "=", version: v}}, nil
+	}
+}
+
+// partial parses a possibly incomplete version such as "1", "1.2", "1.x" or
+// "*", returning the number of components given
+func partial(s string) (Version, int, error) {
+	s = strings.TrimPrefix(s, "v")
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return Version{}, 0, nil
+	}
+
+	var prerelease string
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		prerelease = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, 0, fmt.Errorf("invalid version %q", s)
+	}
+
+	numbers := make([]int, 0, 3)
+	for _, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		numbers = append(numbers, n)
+	}
+
+	var v Version
+	if len(numbers) > 0 {
+		v.Major = numbers[0]
+	}
+	if len(numbers) > 1 {
+		v.Minor = numbers[1]
+	}
+	if len(numbers) > 2 {
+		v.Patch = numbers[2]
+		v.Prerelease = prerelease
+	}
+	return v, len(numbers), nil
+}
+
+// bump returns the lowest version above a partial version with n components
+func bump(v Version, n int) Version {
+	if n <= 1 {
+		return Version{Major: v.Major + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor + 1}
+}
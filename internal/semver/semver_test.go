@@ -0,0 +1,211 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantErr bool
+	}{
+		{in: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{in: "v1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{in: "=1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{in: "1.2.3+build.5", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{in: "2.0.0-beta.1", want: Version{Major: 2, Prerelease: "beta.1"}},
+		{in: "1.2", wantErr: true},
+		{in: "1.2.x", wantErr: true},
+		{in: "1.-2.3", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0.0", "2.0.0", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+	}
+
+	for _, tt := range tests {
+		a, _ := Parse(tt.a)
+		b, _ := Parse(tt.b)
+		if got := Compare(a, b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		// Caret ranges
+		{"1.5.0", "^1.2.0", true},
+		{"2.0.0", "^1.2.0", false},
+		{"1.1.9", "^1.2.0", false},
+		{"0.2.5", "^0.2.3", true},
+		{"0.3.0", "^0.2.3", false},
+		{"0.0.3", "^0.0.3", true},
+		{"0.0.4", "^0.0.3", false},
+		{"0.9.0", "^0.x", true},
+		{"1.0.0", "^0.x", false},
+		{"0.1.5", "^0.1", true},
+		{"0.2.0", "^0.1", false},
+		{"1.9.0", "^1", true},
+
+		// Tilde ranges
+		{"1.2.9", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"1.2.2", "~1.2.3", false},
+		{"1.9.0", "~1", true},
+		{"2.0.0", "~1", false},
+		{"0.2.0", "~0.2", true},
+
+		// X-ranges and partial versions
+		{"1.4.0", "1.x", true},
+		{"2.0.0", "1.x", false},
+		{"1.2.7", "1.2.x", true},
+		{"1.3.0", "1.2.*", false},
+		{"1.3.0", "1.2", false},
+		{"3.1.4", "*", true},
+		{"3.1.4", "", true},
+
+		// Comparators, hyphen ranges and alternatives
+		{"1.5.0", ">=1.0.0 <2.0.0", true},
+		{"2.0.0", ">=1.0.0 <2.0.0", false},
+		{"1.5.0", ">= 1.0.0", true},
+		{"1.3.0", ">1.2", true},
+		{"1.2.9", ">1.2", false},
+		{"1.2.9", "<=1.2", true},
+		{"1.3.0", "<=1.2", false},
+		{"1.4.0", "1.0.0 - 1.4.0", true},
+		{"1.4.1", "1.0.0 - 1.4.0", false},
+		{"1.4.9", "1.0.0 - 1.4", true},
+		{"2.5.0", "1.x || 2.x", true},
+		{"3.0.0", "1.x || 2.x", false},
+		{"1.2.3", "1.2.3", true},
+		{"1.2.4", "1.2.3", false},
+
+		// Prereleases only match comparators of the same version
+		{"1.0.0-beta.2", ">=1.0.0-beta.1", true},
+		{"1.0.1-beta.2", ">=1.0.0-beta.1", false},
+		{"1.3.0-rc.1", "^1.2.0", false},
+		{"1.2.0-rc.1", "^1.2.0-beta", true},
+		{"1.2.0-alpha", "^1.2.0-beta", false},
+		{"2.0.0-rc.1", "2.x", false},
+
+		// Invalid input
+		{"banana", "^1.0.0", false},
+		{"1.0.0", "^banana", false},
+	}
+
+	for _, tt := range tests {
+		if got := Satisfies(tt.version, tt.constraint); got != tt.want {
+			t.Errorf("Satisfies(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestValidRange(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       bool
+	}{
+		{"^1.2.0", true},
+		{"~0.1", true},
+		{"1.x || >=3.0.0", true},
+		{"1.0.0 - 2", true},
+		{"latest", false},
+		{"^1.2.3.4", false},
+		{"1.x || nope", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidRange(tt.constraint); got != tt.want {
+			t.Errorf("ValidRange(%q) = %v, want %v", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestIsExact(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       bool
+	}{
+		{"1.2.0", true},
+		{"v1.2.0", true},
+		{"1.2.0-beta.1", true},
+		{"^1.2.0", false},
+		{"~1.2.0", false},
+		{"1.x", false},
+		{"latest", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsExact(tt.constraint); got != tt.want {
+			t.Errorf("IsExact(%q) = %v, want %v", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	versions := []string{"0.9.0", "1.0.0", "1.2.0", "1.4.0-beta.1", "1.3.5", "2.0.0", "2.1.0-rc.1"}
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.0.0", "1.3.5"},
+		{"~1.2.0", "1.2.0"},
+		{"^0.x", "0.9.0"},
+		{"*", "2.0.0"},
+		{">=2.1.0-rc.0", "2.1.0-rc.1"},
+		{"^3.0.0", ""},
+	}
+
+	for _, tt := range tests {
+		if got := MaxSatisfying(versions, tt.constraint); got != tt.want {
+			t.Errorf("MaxSatisfying(%q) = %q, want %q", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	tests := []struct {
+		versions []string
+		want     string
+	}{
+		{[]string{"1.0.0", "1.10.0", "1.9.0"}, "1.10.0"},
+		{[]string{"1.0.0", "2.0.0-rc.1"}, "1.0.0"},
+		{[]string{"2.0.0-rc.1", "not-a-version"}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := Latest(tt.versions); got != tt.want {
+			t.Errorf("Latest(%v) = %q, want %q", tt.versions, got, tt.want)
+		}
+	}
+}
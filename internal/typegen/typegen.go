@@ -0,0 +1,530 @@
+package typegen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/events"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+	"github.com/godspeedsystems/godspeed-cli/internal/utils"
+)
+
+// OutputPath is where the generated types are written, relative to the project root
+var OutputPath = filepath.Join("src", "types", "generated.ts")
+
+// Result is the JSON result of gen-types. Changed reports whether the file
+// was rewritten, or with --check whether it is out of date.
+type Result struct {
+	Path    string `json:"path"`
+	Check   bool   `json:"check"`
+	Changed bool   `json:"changed"`
+}
+
+const fileHeader = `// This file is generated by "godspeed gen-types" from src/events and src/definitions.
+// Do not edit it by hand, run "godspeed gen-types" again instead.
+`
+
+// Generate writes TypeScript types for the project's events and definitions.
+// With check set, nothing is written and an exitcode.ValidationFailure error
+// is returned when the file on disk is missing or differs from what would be
+// generated.
+func Generate(check bool) error {
+	if err := utils.RequireProject(); err != nil {
+		return err
+	}
+
+	content, err := Render(filepath.Join("src", "events"), filepath.Join("src", "definitions"))
+	if err != nil {
+		return fmt.Errorf("generating types: %w", err)
+	}
+
+	existing, err := ioutil.ReadFile(OutputPath)
+	changed := err != nil || string(existing) != content
+	output.Set(Result{Path: OutputPath, Check: check, Changed: changed})
+
+	if check {
+		if changed {
+			return exitcode.New(exitcode.ValidationFailure, "%s is out of date. Run `godspeed gen-types` and commit the result", OutputPath)
+		}
+
+		color.Green("%s is up to date.", OutputPath)
+		return nil
+	}
+
+	if err := utils.CreateDir(filepath.Dir(OutputPath)); err != nil {
+		return fmt.Errorf("creating types directory: %w", err)
+	}
+
+	if err := ioutil.WriteFile(OutputPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", OutputPath, err)
+	}
+
+	color.Green("Types generated successfully at %s", OutputPath)
+	return nil
+}
+
+// Render generates the TypeScript source for the events and definitions
+func Render(eventsPath, definitionsPath string) (string, error) {
+	definitions, err := schema.LoadDefinitions(definitionsPath)
+	if err != nil {
+		return "", err
+	}
+
+	allEvents := map[string]events.Event{}
+	if utils.DirExists(eventsPath) {
+		if allEvents, err = events.Load(eventsPath); err != nil {
+			return "", err
+		}
+	}
+
+	g := &generator{defs: definitions}
+
+	var out strings.Builder
+	out.WriteString(fileHeader)
+
+	// Shared definitions, keyed by type name to catch names that collide
+	seen := make(map[string]string)
+	for _, name := range definitions.Names() {
+		typeName := DefinitionTypeName(name)
+		if other, ok := seen[typeName]; ok {
+			return "", fmt.Errorf("%s and definition %s both map to the type name %s", other, name, typeName)
+		}
+		seen[typeName] = "definition " + name
+
+		def, err := definitions.RewriteDefinition(name, schema.DefinitionsRefPrefix)
+		if err != nil {
+			return "", fmt.Errorf("definition %s: %v", name, err)
+		}
+
+		out.WriteString("\n")
+		out.WriteString(g.declaration(typeName, def))
+	}
+
+	// Event inputs and outputs
+	for _, key := range events.SortedKeys(allEvents) {
+		event := allEvents[key]
+		name := event.TypeName()
+		for _, typeName := range []string{name + "Input", name + "Output"} {
+			if other, ok := seen[typeName]; ok {
+				return "", fmt.Errorf("%s and event %s both map to the type name %s", other, key, typeName)
+			}
+			seen[typeName] = "event " + key
+		}
+
+		inputType, err := g.eventInput(event)
+		if err != nil {
+			return "", fmt.Errorf("event %s: %v", key, err)
+		}
+
+		outputType, err := g.eventOutput(event)
+		if err != nil {
+			return "", fmt.Errorf("event %s: %v", key, err)
+		}
+
+		out.WriteString("\n")
+		out.WriteString(comment(key, event.Summary))
+		out.WriteString(fmt.Sprintf("export interface %sInput %s\n", name, inputType))
+		out.WriteString("\n")
+		out.WriteString(comment(key, event.Summary))
+		out.WriteString(fmt.Sprintf("export interface %sOutput %s\n", name, outputType))
+	}
+
+	return out.String(), nil
+}
+
+// DefinitionTypeName converts a definition name into a TypeScript type name,
+// e.g. "billing/Invoice" becomes "BillingInvoice"
+func DefinitionTypeName(name string) string {
+	var result strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '$'
+	}) {
+		r, size := utf8.DecodeRuneInString(word)
+		result.WriteRune(unicode.ToUpper(r))
+		result.WriteString(word[size:])
+	}
+
+	typeName := result.String()
+	if first, _ := utf8.DecodeRuneInString(typeName); typeName == "" || unicode.IsDigit(first) {
+		typeName = "Definition" + typeName
+	}
+	return typeName
+}
+
+// generator converts JSON schemas into TypeScript types
+type generator struct {
+	defs *schema.Definitions
+}
+
+// declaration renders an exported interface or type alias for a schema
+func (g *generator) declaration(name string, s interface{}) string {
+	schemaMap, _ := s.(map[string]interface{})
+	description, _ := schemaMap["description"].(string)
+
+	var out strings.Builder
+	out.WriteString(comment(description))
+
+	if isPlainObject(schemaMap) {
+		out.WriteString(fmt.Sprintf("export interface %s %s\n", name, g.objectType(schemaMap, 0)))
+	} else {
+		out.WriteString(fmt.Sprintf("export type %s = %s;\n", name, g.typeOf(s, 0)))
+	}
+	return out.String()
+}
+
+// eventInput renders the inputs an event handler receives in ctx.inputs.data
+func (g *generator) eventInput(event events.Event) (string, error) {
+	fields := []field{}
+
+	if body := event.RequestBody(); body != nil {
+		rewritten, err := g.defs.Rewrite(body, schema.DefinitionsRefPrefix)
+		if err != nil {
+			return "", err
+		}
+		bodyMap, _ := rewritten.(map[string]interface{})
+		required, _ := bodyMap["required"].(bool)
+		fields = append(fields, field{name: "body", optional: !required, typ: g.typeOf(events.ContentSchema(bodyMap), 1)})
+	}
+
+	groups := map[string][]field{}
+	for _, param := range event.RequestParams() {
+		rewritten, err := g.defs.Rewrite(param, schema.DefinitionsRefPrefix)
+		if err != nil {
+			return "", err
+		}
+		paramMap, _ := rewritten.(map[string]interface{})
+		name, _ := paramMap["name"].(string)
+		in, _ := paramMap["in"].(string)
+		if name == "" {
+			continue
+		}
+
+		required, _ := paramMap["required"].(bool)
+		groups[in] = append(groups[in], field{
+			name:        name,
+			optional:    !required && in != "path",
+			typ:         g.typeOf(paramMap["schema"], 2),
+			description: stringValue(paramMap["description"]),
+		})
+	}
+
+	// Godspeed exposes path params as "params"
+	for _, group := range []struct{ in, name string }{{"path", "params"}, {"query", "query"}, {"header", "headers"}, {"cookie", "cookies"}} {
+		if params, ok := groups[group.in]; ok {
+			fields = append(fields, field{name: group.name, typ: renderFields(params, 1)})
+		}
+	}
+
+	return renderFields(fields, 0), nil
+}
+
+// eventOutput renders the response body type for each declared status code
+func (g *generator) eventOutput(event events.Event) (string, error) {
+	if event.Responses == nil {
+		return "{}", nil
+	}
+
+	rewritten, err := g.defs.Rewrite(event.Responses, schema.DefinitionsRefPrefix)
+	if err != nil {
+		return "", err
+	}
+	responses, _ := rewritten.(map[string]interface{})
+
+	var fields []field
+	for _, status := range sortedKeys(responses) {
+		response, _ := responses[status].(map[string]interface{})
+		fields = append(fields, field{
+			name:        status,
+			typ:         g.typeOf(events.ContentSchema(response), 1),
+			description: stringValue(response["description"]),
+		})
+	}
+
+	return renderFields(fields, 0), nil
+}
+
+// typeOf renders the TypeScript type of a schema at the given indent level
+func (g *generator) typeOf(s interface{}, indent int) string {
+	schemaMap, ok := s.(map[string]interface{})
+	if !ok || len(schemaMap) == 0 {
+		return "unknown"
+	}
+
+	t := g.baseType(schemaMap, indent)
+	if nullable, _ := schemaMap["nullable"].(bool); nullable && t != "unknown" {
+		t += " | null"
+	}
+	return t
+}
+
+// baseType renders a schema without its nullability
+func (g *generator) baseType(s map[string]interface{}, indent int) string {
+	if ref, ok := s["$ref"].(string); ok {
+		if strings.HasPrefix(ref, schema.DefinitionsRefPrefix) {
+			return DefinitionTypeName(strings.TrimPrefix(ref, schema.DefinitionsRefPrefix))
+		}
+		return "unknown"
+	}
+
+	if values, ok := s["enum"].([]interface{}); ok && len(values) > 0 {
+		literals := make([]string, len(values))
+		for i, value := range values {
+			literals[i] = literal(value)
+		}
+		return strings.Join(literals, " | ")
+	}
+
+	if value, ok := s["const"]; ok {
+		return literal(value)
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if variants, ok := s[keyword].([]interface{}); ok && len(variants) > 0 {
+			return g.combine(variants, " | ", indent)
+		}
+	}
+
+	if parts, ok := s["allOf"].([]interface{}); ok && len(parts) > 0 {
+		return g.combine(parts, " & ", indent)
+	}
+
+	switch typ := s["type"].(type) {
+	case []interface{}:
+		var variants []string
+		for _, t := range typ {
+			variant := copyWithout(s, "type")
+			variant["type"] = t
+			variants = append(variants, g.baseType(variant, indent))
+		}
+		return strings.Join(variants, " | ")
+	case string:
+		switch typ {
+		case "string":
+			return "string"
+		case "number", "integer":
+			return "number"
+		case "boolean":
+			return "boolean"
+		case "null":
+			return "null"
+		case "array":
+			item := g.typeOf(s["items"], indent)
+			if strings.ContainsAny(item, " |&") && !strings.HasPrefix(item, "{") {
+				return fmt.Sprintf("Array<%s>", item)
+			}
+			return item + "[]"
+		case "object":
+			return g.objectType(s, indent)
+		}
+	}
+
+	if _, ok := s["properties"]; ok {
+		return g.objectType(s, indent)
+	}
+
+	return "unknown"
+}
+
+// combine renders oneOf/anyOf/allOf variants joined by sep
+func (g *generator) combine(variants []interface{}, sep string, indent int) string {
+	rendered := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		t := g.typeOf(variant, indent)
+		if strings.Contains(t, " ") && !strings.HasPrefix(t, "{") {
+			t = "(" + t + ")"
+		}
+		rendered = append(rendered, t)
+	}
+	return strings.Join(rendered, sep)
+}
+
+// objectType renders an object schema as an inline type literal
+func (g *generator) objectType(s map[string]interface{}, indent int) string {
+	properties, _ := s["properties"].(map[string]interface{})
+
+	required := make(map[string]bool)
+	if list, ok := s["required"].([]interface{}); ok {
+		for _, name := range list {
+			if str, ok := name.(string); ok {
+				required[str] = true
+			}
+		}
+	}
+
+	var fields []field
+	for _, name := range sortedKeys(properties) {
+		propMap, _ := properties[name].(map[string]interface{})
+		fields = append(fields, field{
+			name:        name,
+			optional:    !required[name],
+			typ:         g.typeOf(properties[name], indent+1),
+			description: stringValue(propMap["description"]),
+		})
+	}
+
+	switch additional := s["additionalProperties"].(type) {
+	case bool:
+		if additional && len(fields) == 0 {
+			return "Record<string, unknown>"
+		}
+	case map[string]interface{}:
+		fields = append(fields, field{name: "[key: string]", typ: g.typeOf(additional, indent+1), raw: true})
+	}
+
+	if len(fields) == 0 && properties == nil {
+		return "Record<string, unknown>"
+	}
+
+	return renderFields(fields, indent)
+}
+
+// field is a single member of an interface or type literal
+type field struct {
+	name        string
+	typ         string
+	optional    bool
+	description string
+	raw         bool
+}
+
+// renderFields renders fields as a type literal at the given indent level
+func renderFields(fields []field, indent int) string {
+	if len(fields) == 0 {
+		return "{}"
+	}
+
+	pad := strings.Repeat("  ", indent+1)
+
+	var out strings.Builder
+	out.WriteString("{\n")
+	for _, f := range fields {
+		if f.description != "" {
+			out.WriteString(fmt.Sprintf("%s/** %s */\n", pad, singleLine(f.description)))
+		}
+
+		name := f.name
+		if !f.raw {
+			name = propertyName(f.name)
+		}
+		if f.optional {
+			name += "?"
+		}
+		out.WriteString(fmt.Sprintf("%s%s: %s;\n", pad, name, f.typ))
+	}
+	out.WriteString(strings.Repeat("  ", indent) + "}")
+	return out.String()
+}
+
+// comment renders a JSDoc comment, or nothing if all parts are empty
+func comment(parts ...string) string {
+	var lines []string
+	for _, part := range parts {
+		if part != "" {
+			lines = append(lines, singleLine(part))
+		}
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "/** " + strings.Join(lines, " - ") + " */\n"
+}
+
+// propertyName quotes property names that are not valid identifiers
+func propertyName(name string) string {
+	valid := name != ""
+	for i, r := range name {
+		if !(unicode.IsLetter(r) || r == '_' || r == '$' || (i > 0 && unicode.IsDigit(r))) {
+			valid = false
+			break
+		}
+	}
+
+	if valid {
+		return name
+	}
+
+	allDigits := name != ""
+	for _, r := range name {
+		if !unicode.IsDigit(r) {
+			allDigits = false
+			break
+		}
+	}
+	if allDigits {
+		return name
+	}
+
+	return fmt.Sprintf("%q", name)
+}
+
+// literal renders an enum or const value as a TypeScript literal type
+func literal(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// isPlainObject reports whether a schema can be rendered as an interface
+func isPlainObject(s map[string]interface{}) bool {
+	if s == nil {
+		return false
+	}
+	for _, keyword := range []string{"$ref", "enum", "const", "oneOf", "anyOf", "allOf", "nullable"} {
+		if _, ok := s[keyword]; ok {
+			return false
+		}
+	}
+	if _, ok := s["additionalProperties"].(map[string]interface{}); ok {
+		return false
+	}
+	typ, _ := s["type"].(string)
+	_, hasProperties := s["properties"]
+	return typ == "object" && hasProperties || typ == "" && hasProperties
+}
+
+// copyWithout returns a shallow copy of s without the given key
+func copyWithout(s map[string]interface{}, key string) map[string]interface{} {
+	result := make(map[string]interface{}, len(s))
+	for k, v := range s {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// sortedKeys returns the keys of m in a stable order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringValue returns v if it is a string
+func stringValue(v interface{}) string {
+	str, _ := v.(string)
+	return str
+}
+
+// singleLine collapses whitespace so text fits in a one line comment
+func singleLine(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "*/", "* /")
+}
@@ -0,0 +1,164 @@
+package typegen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDefinitionTypeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"User", "User"},
+		{"billing/Invoice", "BillingInvoice"},
+		{"billing/invoice-line", "BillingInvoiceLine"},
+		{"user_profile", "User_profile"},
+		{"élan", "Élan"},
+		{"billing/éclair", "BillingÉclair"},
+		{"日本/user", "日本User"},
+		{"2fa/Token", "Definition2faToken"},
+		{"---", "Definition"},
+	}
+
+	for _, tt := range tests {
+		if got := DefinitionTypeName(tt.name); got != tt.want {
+			t.Errorf("DefinitionTypeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"empty", "{}", "unknown"},
+		{"string", "{type: string}", "string"},
+		{"integer", "{type: integer}", "number"},
+		{"nullable", "{type: string, nullable: true}", "string | null"},
+		{"type list", "{type: [string, 'null']}", "string | null"},
+		{"definition ref", "{$ref: '#/definitions/billing/Invoice'}", "BillingInvoice"},
+		{"other ref", "{$ref: 'common.yaml#/Address'}", "unknown"},
+		{"enum", "{enum: [a, 1, null]}", `"a" | 1 | null`},
+		{"const", "{const: yes}", `"yes"`},
+		{"array", "{type: array, items: {type: string}}", "string[]"},
+		{"array of unions", "{type: array, items: {type: [string, number]}}", "Array<string | number>"},
+		{"oneOf", "{oneOf: [{type: string}, {type: [number, boolean]}]}", "string | (number | boolean)"},
+		{"allOf", "{allOf: [{$ref: '#/definitions/A'}, {$ref: '#/definitions/B'}]}", "A & B"},
+		{"free-form object", "{type: object}", "Record<string, unknown>"},
+		{"object", "{type: object, required: [id], properties: {id: {type: string}, 'x-y': {type: number, description: a value}}}",
+			"{\n  id: string;\n  /** a value */\n  \"x-y\"?: number;\n}"},
+		{"map", "{type: object, additionalProperties: {type: integer}}", "{\n  [key: string]: number;\n}"},
+	}
+
+	g := &generator{defs: &schema.Definitions{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s interface{}
+			if err := yaml.Unmarshal([]byte(tt.schema), &s); err != nil {
+				t.Fatalf("parsing schema: %v", err)
+			}
+			if got := g.typeOf(schema.Normalize(s), 0); got != tt.want {
+				t.Errorf("typeOf = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		definitions map[string]string
+		events      map[string]string
+		contains    []string
+		wantErr     string
+	}{
+		{
+			name: "definitions and events",
+			definitions: map[string]string{
+				"billing/invoice.yaml": "Invoice:\n  type: object\n  properties:\n    total: {type: number}\n",
+			},
+			events: map[string]string{
+				"invoices.yaml": "http.get./invoices/:id:\n  fn: get\n  responses:\n    200:\n      content:\n        application/json:\n          schema: {$ref: '#/definitions/billing/Invoice'}\n",
+			},
+			contains: []string{
+				"export interface BillingInvoice {\n  total?: number;\n}",
+				"export interface HttpGetInvoicesByIdInput {}",
+				"export interface HttpGetInvoicesByIdOutput {\n  200: BillingInvoice;\n}",
+			},
+		},
+		{
+			name: "colliding definitions",
+			definitions: map[string]string{
+				"billing/invoice.yaml": "Invoice: {type: object}\n",
+				"common.yaml":          "BillingInvoice: {type: string}\n",
+			},
+			wantErr: "definition BillingInvoice and definition billing/Invoice both map to the type name BillingInvoice",
+		},
+		{
+			name: "definition colliding with an event",
+			definitions: map[string]string{
+				"common.yaml": "HttpGetUsersInput: {type: string}\n",
+			},
+			events: map[string]string{
+				"users.yaml": "http.get./users:\n  fn: list\n",
+			},
+			wantErr: "definition HttpGetUsersInput and event http.get./users both map to the type name HttpGetUsersInput",
+		},
+		{
+			name: "colliding events",
+			events: map[string]string{
+				"users.yaml": "http.get./user-list:\n  fn: a\nhttp.get./user_list:\n  fn: b\n",
+			},
+			wantErr: "both map to the type name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			definitionsPath := filepath.Join(dir, "definitions")
+			eventsPath := filepath.Join(dir, "events")
+			writeFiles(t, definitionsPath, tt.definitions)
+			writeFiles(t, eventsPath, tt.events)
+
+			got, err := Render(eventsPath, definitionsPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("Render output lacks %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+// writeFiles writes files below dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
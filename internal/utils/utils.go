@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,8 @@ import (
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+	"github.com/godspeedsystems/godspeed-cli/internal/output"
 )
 
 // FileExists checks if a file exists at the given path
@@ -116,39 +119,50 @@ func RemoveDir(path string) error {
 	return os.RemoveAll(path)
 }
 
-// ExecuteCommand executes a command with the given arguments
+// ExecuteCommand executes a command with the given arguments. If the command
+// exits with a non-zero code, the returned error carries that code.
 func ExecuteCommand(command string, args []string) error {
 	cmd := exec.Command(command, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	return cmd.Run()
+	return CommandError(command, cmd.Run())
+}
+
+// CommandError turns the error of running a command into one carrying the
+// command's exit code, or exitcode.ToolFailure if it could not be run
+func CommandError(command string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitcode.New(exitErr.ExitCode(), "%s exited with code %d", command, exitErr.ExitCode())
+	}
+	return exitcode.New(exitcode.ToolFailure, "running %s: %v", command, err)
 }
 
 // ExecuteCommandWithOutput executes a command and returns its output
 func ExecuteCommandWithOutput(command string, args []string) (string, error) {
 	cmd := exec.Command(command, args...)
-	output, err := cmd.CombinedOutput()
-	return string(output), err
+	out, err := cmd.CombinedOutput()
+	return string(out), err
 }
 
-// IsGodspeedProject checks if the current directory is a godspeed project
-func IsGodspeedProject() bool {
+// RequireProject returns an exitcode.NotProject error unless the current
+// directory is a godspeed project
+func RequireProject() error {
 	// Check for .godspeed file
 	if !FileExists(".godspeed") {
-		color.Red("The current directory is not a Godspeed Framework project.")
-		color.Yellow("godspeed commands work inside godspeed project directory.")
-		return false
+		return exitcode.New(exitcode.NotProject, "The current directory is not a Godspeed Framework project. godspeed commands work inside godspeed project directory.")
 	}
 
 	// Check for package.json
 	if !FileExists("package.json") {
-		color.Red("The current directory is not a Godspeed project.")
-		color.Yellow("godspeed commands only work inside godspeed project directory.")
-		return false
+		return exitcode.New(exitcode.NotProject, "The current directory is not a Godspeed project. godspeed commands only work inside godspeed project directory.")
 	}
 
-	return true
+	return nil
 }
 
 // UserHomeDir returns the user's home directory
@@ -166,10 +180,14 @@ func GetGodspeedDir() string {
 	return filepath.Join(UserHomeDir(), ".godspeed")
 }
 
-// NewSpinner creates a new spinner with godspeed style
+// NewSpinner creates a new spinner with godspeed style. Spinners are silent
+// with --output json.
 func NewSpinner(text string) *spinner.Spinner {
 	s := spinner.New([]string{"🌍 ", "🌎 ", "🌏 ", "🌐 ", "🌑 ", "🌒 ", "🌓 ", "🌔 "}, 180*time.Millisecond)
 	s.Prefix = text
+	if output.JSON() {
+		s.Writer = io.Discard
+	}
 	return s
 }
 
@@ -188,44 +206,49 @@ type Service struct {
 	Initialized bool   `json:"initialized"`
 }
 
+// LinkResult is the JSON result of godspeed link and unlink
+type LinkResult struct {
+	Linked  bool    `json:"linked"`
+	Service Service `json:"service"`
+}
+
 // UpdateServicesJson updates the services.json file to add or remove the current project
-func UpdateServicesJson(add bool) {
+func UpdateServicesJson(add bool) error {
 	servicesFile := filepath.Join(GetGodspeedDir(), "services.json")
 
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting current directory: %v", err)
+	}
+
+	currentProject := Service{
+		ServiceID:   filepath.Base(currentDir),
+		Name:        filepath.Base(currentDir),
+		Path:        currentDir,
+		Status:      "active",
+		LastUpdated: time.Now().UTC().Format(time.RFC3339),
+		Initialized: true,
+	}
+	result := LinkResult{Linked: add, Service: currentProject}
+
 	// If services.json doesn't exist, return early if removing
 	if !FileExists(servicesFile) && !add {
-		return
+		output.Set(result)
+		return nil
 	}
 
 	var servicesData ServicesJson
 	if FileExists(servicesFile) {
 		data, err := os.ReadFile(servicesFile)
 		if err != nil {
-			color.Red("Error reading services.json: %v", err)
-			return
+			return fmt.Errorf("reading services.json: %v", err)
 		}
 
 		if err := json.Unmarshal(data, &servicesData); err != nil {
-			color.Red("Error parsing services.json: %v", err)
-			return
+			return fmt.Errorf("parsing services.json: %v", err)
 		}
 	}
 
-	currentDir, err := os.Getwd()
-	if err != nil {
-		color.Red("Error getting current directory: %v", err)
-		return
-	}
-
-	currentProject := Service{
-		ServiceID:   filepath.Base(currentDir),
-		Name:        filepath.Base(currentDir),
-		Path:        currentDir,
-		Status:      "active",
-		LastUpdated: time.Now().UTC().Format(time.RFC3339),
-		Initialized: true,
-	}
-
 	if add {
 		// Check if the project already exists
 		exists := false
@@ -253,14 +276,12 @@ func UpdateServicesJson(add bool) {
 	// Write the updated services.json
 	updatedData, err := json.MarshalIndent(servicesData, "", "  ")
 	if err != nil {
-		color.Red("Error creating JSON: %v", err)
-		return
+		return fmt.Errorf("creating JSON: %v", err)
 	}
 
 	// Create .godspeed directory if needed
 	if err := CreateDir(GetGodspeedDir()); err != nil {
-		color.Red("Error creating .godspeed directory: %v", err)
-		return
+		return fmt.Errorf("creating .godspeed directory: %v", err)
 	}
 
 	if err := os.WriteFile(servicesFile, updatedData, 0644); err != nil {
@@ -269,15 +290,14 @@ func UpdateServicesJson(add bool) {
 			if !add {
 				action = "unlink"
 			}
-			color.Red("Permission denied: Cannot write to services.json")
-			color.Yellow("Try running: sudo godspeed %s", action)
-		} else {
-			color.Red("Error writing services.json: %v", err)
+			return fmt.Errorf("permission denied: cannot write to services.json. Try running: sudo godspeed %s", action)
 		}
-		return
+		return fmt.Errorf("writing services.json: %v", err)
 	}
 
 	color.Green("Project data updated successfully.")
+	output.Set(result)
+	return nil
 }
 
 // IsDockerRunning checks if Docker is running
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/godspeedsystems/godspeed-cli/internal/exitcode"
+)
+
+func TestCommandError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("runs sh")
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		code    int
+		message string
+	}{
+		{"success", exec.Command("sh", "-c", "exit 0").Run(), exitcode.Success, ""},
+		{"exit code", exec.Command("sh", "-c", "exit 3").Run(), 3, "dev exited with code 3"},
+		{"exit code above the CLI's codes", exec.Command("sh", "-c", "exit 42").Run(), 42, "dev exited with code 42"},
+		{"not found", exec.Command("godspeed-test-no-such-command").Run(), exitcode.ToolFailure, "running dev: "},
+		{"other error", errors.New("pipe closed"), exitcode.ToolFailure, "running dev: pipe closed"},
+	}
+
+	for _, tt := range tests {
+		err := CommandError("dev", tt.err)
+		if got := exitcode.Of(err); got != tt.code {
+			t.Errorf("%s: exit code = %d, want %d", tt.name, got, tt.code)
+		}
+		if err == nil {
+			if tt.message != "" {
+				t.Errorf("%s: error = nil, want %q", tt.name, tt.message)
+			}
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), tt.message) {
+			t.Errorf("%s: error = %q, want %q", tt.name, err.Error(), tt.message)
+		}
+	}
+}